@@ -0,0 +1,304 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc"
+	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc/report"
+	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc/schema_generation"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// commands maps the names of the commands other than
+// the default replay processing to the functions running them.
+// Every command receives the command line arguments that follow its name.
+var commands = map[string]func(args []string) int{
+	"generate-schema":  generateSchemaCommand,
+	"k-anonymity":      kAnonymityCommand,
+	"merge-summaries":  mergeSummariesCommand,
+	"report":           reportCommand,
+	"serve-anonymizer": serveAnonymizerCommand,
+	"summarize":        summarizeCommand,
+}
+
+// generateSchemaCommand saves the JSON Schema documents describing the outputs.
+func generateSchemaCommand(args []string) int {
+
+	flags, okFlags := utils.ParseGenerateSchemaFlags(args)
+	if !okFlags {
+		log.Error("Failed ParseGenerateSchemaFlags()")
+		return 1
+	}
+
+	logFile, okLogging := utils.SetLogging(
+		flags.LogFlags.LogPath,
+		int(flags.LogFlags.LogLevelValue),
+	)
+	if !okLogging {
+		log.Error("Failed to setLogging()")
+		return 1
+	}
+	defer logFile.Close()
+
+	err := schema_generation.GenerateSchemaFiles(flags.OutputDirectory)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to generate the JSON Schema files.")
+		return 1
+	}
+
+	log.WithField("outputDirectory", flags.OutputDirectory).
+		Info("Generated the JSON Schema files.")
+	return 0
+}
+
+// kAnonymityCommand creates the k-anonymity report over the chosen attributes
+// from the dataset index of a processed dataset.
+func kAnonymityCommand(args []string) int {
+
+	flags, okFlags := utils.ParseKAnonymityFlags(args)
+	if !okFlags {
+		log.Error("Failed ParseKAnonymityFlags()")
+		return 1
+	}
+
+	logFile, okLogging := utils.SetLogging(
+		flags.LogFlags.LogPath,
+		int(flags.LogFlags.LogLevelValue),
+	)
+	if !okLogging {
+		log.Error("Failed to setLogging()")
+		return 1
+	}
+	defer logFile.Close()
+
+	err := persistent_data.CreateKAnonymityReportFile(
+		flags.DatasetIndexFile,
+		flags.OutputFile,
+		flags.Attributes,
+		flags.K,
+	)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to create the k-anonymity report.")
+		return 1
+	}
+
+	log.WithField("outputFile", flags.OutputFile).
+		Info("Created the k-anonymity report.")
+	return 0
+}
+
+// mergeSummariesCommand merges the package or dataset summaries
+// of several runs into a single dataset summary.
+func mergeSummariesCommand(args []string) int {
+
+	flags, okFlags := utils.ParseMergeSummariesFlags(args)
+	if !okFlags {
+		log.Error("Failed ParseMergeSummariesFlags()")
+		return 1
+	}
+
+	logFile, okLogging := utils.SetLogging(
+		flags.LogFlags.LogPath,
+		int(flags.LogFlags.LogLevelValue),
+	)
+	if !okLogging {
+		log.Error("Failed to setLogging()")
+		return 1
+	}
+	defer logFile.Close()
+
+	datasetSummary, err := persistent_data.MergeSummaryFiles(
+		flags.SummaryFiles,
+		flags.SummaryBins,
+	)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to merge the summary files.")
+		return 1
+	}
+
+	err = persistent_data.CreateDatasetSummaryFile(flags.OutputFile, datasetSummary)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to save the dataset summary.")
+		return 1
+	}
+
+	log.WithFields(log.Fields{
+		"outputFile":        flags.OutputFile,
+		"numberOfSummaries": len(flags.SummaryFiles),
+	}).Info("Merged the summary files.")
+	return 0
+}
+
+// reportCommand creates the HTML and Markdown reports describing the dataset
+// from the package summaries and the processing info files.
+func reportCommand(args []string) int {
+
+	flags, okFlags := utils.ParseReportFlags(args)
+	if !okFlags {
+		log.Error("Failed ParseReportFlags()")
+		return 1
+	}
+
+	logFile, okLogging := utils.SetLogging(
+		flags.LogFlags.LogPath,
+		int(flags.LogFlags.LogLevelValue),
+	)
+	if !okLogging {
+		log.Error("Failed to setLogging()")
+		return 1
+	}
+	defer logFile.Close()
+
+	datasetSummary, err := persistent_data.MergeSummaryFiles(
+		flags.SummaryFiles,
+		flags.SummaryBins,
+	)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to merge the summary files.")
+		return 1
+	}
+
+	failureReasons := make(map[string]int64)
+	for _, processingInfoFile := range flags.ProcessingInfoFiles {
+		processingInfo, err := persistent_data.ReadProcessingInfoFile(processingInfoFile)
+		if err != nil {
+			log.WithField("error", err).Error("Failed to read the processing info file.")
+			return 1
+		}
+		for reason, count := range processingInfo.FailureReasonCounts() {
+			failureReasons[reason] += count
+		}
+	}
+
+	datasetReport := report.NewReport(
+		flags.Title,
+		datasetSummary,
+		failureReasons,
+		flags.MaxRows,
+	)
+	err = report.SaveReport(datasetReport, flags.OutputDirectory)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to save the report.")
+		return 1
+	}
+
+	log.WithField("outputDirectory", flags.OutputDirectory).
+		Info("Created the dataset report.")
+	return 0
+}
+
+// summarizeCommand rebuilds the package and dataset summaries
+// from the existing outputs without parsing the replays again.
+func summarizeCommand(args []string) int {
+
+	flags, okFlags := utils.ParseSummarizeFlags(args)
+	if !okFlags {
+		log.Error("Failed ParseSummarizeFlags()")
+		return 1
+	}
+
+	logFile, okLogging := utils.SetLogging(
+		flags.LogFlags.LogPath,
+		int(flags.LogFlags.LogLevelValue),
+	)
+	if !okLogging {
+		log.Error("Failed to setLogging()")
+		return 1
+	}
+	defer logFile.Close()
+
+	err := file_utils.GetOrCreateDirectory(flags.OutputDirectory)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to create the output directory.")
+		return 1
+	}
+
+	err = dataproc.SummarizeOutputs(
+		flags.Inputs,
+		flags.OutputDirectory,
+		flags.NumberOfThreads,
+		flags.SummaryBins,
+	)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to summarize the outputs.")
+		return 1
+	}
+
+	log.WithField("outputDirectory", flags.OutputDirectory).
+		Info("Summarized the outputs.")
+	return 0
+}
+
+// serveAnonymizerCommand runs the anonymization server handing out
+// incrementing IDs stored in a local database until it is interrupted.
+func serveAnonymizerCommand(args []string) int {
+
+	flags, okFlags := utils.ParseServeAnonymizerFlags(args)
+	if !okFlags {
+		log.Error("Failed ParseServeAnonymizerFlags()")
+		return 1
+	}
+
+	logFile, okLogging := utils.SetLogging(
+		flags.LogFlags.LogPath,
+		int(flags.LogFlags.LogLevelValue),
+	)
+	if !okLogging {
+		log.Error("Failed to setLogging()")
+		return 1
+	}
+	defer logFile.Close()
+
+	anonymizer, err := dataproc.NewLocalDBAnonymizer(flags.AnonymizerDatabase)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to open the anonymizer database.")
+		return 1
+	}
+	defer anonymizer.Close()
+
+	var tlsConfig *tls.Config
+	if flags.TLSCert != "" {
+		tlsConfig, err = utils.ServerTLSConfig(flags.TLSCert, flags.TLSKey, flags.TLSClientCA)
+		if err != nil {
+			log.WithField("error", err).Error("Failed to set up TLS.")
+			return 1
+		}
+	}
+
+	listener, err := net.Listen("tcp", flags.Address)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to listen on the address.")
+		return 1
+	}
+
+	// Finishing the requests in progress before the database is closed:
+	grpcServer := dataproc.NewAnonymizeGRPCServer(anonymizer, tlsConfig)
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupts
+		log.Info("Stopping the anonymization server.")
+		grpcServer.GracefulStop()
+	}()
+
+	log.WithFields(log.Fields{
+		"address":            listener.Addr().String(),
+		"anonymizerDatabase": flags.AnonymizerDatabase,
+		"tls":                tlsConfig != nil,
+		"mTLS":               flags.TLSClientCA != "",
+	}).Info("Serving the anonymization server.")
+	err = grpcServer.Serve(listener)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to serve the anonymization server.")
+		return 1
+	}
+
+	return 0
+}
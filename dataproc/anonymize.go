@@ -2,109 +2,210 @@ package dataproc
 
 import (
 	"context"
+	"sync"
 	"time"
 
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
 	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
 	pb "github.com/Kaszanas/SC2InfoExtractorGo/proto"
 	settings "github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
 	"github.com/icza/s2prot"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
-// checkAnonymizationInitializeGRPC verifies if the anonymization should
-// be performed and returns a pointer to GRPCAnonymizer.
-func checkAnonymizationInitializeGRPC(
-	performAnonymizationBool bool,
-) *GRPCAnonymizer {
-	if !performAnonymizationBool {
-		return nil
+// Anonymizer replaces the toons of the players with persistent anonymized IDs,
+// it is shared by all of the workers processing the replays.
+type Anonymizer interface {
+	// AnonymizeToon returns the anonymized ID of the toon.
+	AnonymizeToon(toonString string) (string, bool)
+	// AnonymizeToons resolves the anonymized IDs of the toons in advance,
+	// so that the workers do not wait for them during the extraction.
+	AnonymizeToons(toonStrings []string) bool
+	// Close releases the resources held by the anonymizer.
+	Close() error
+}
+
+// NewAnonymizer verifies if the player anonymization should be performed
+// and returns the anonymizer chosen in the CLI, nil if there is nothing to anonymize.
+func NewAnonymizer(cliFlags utils.CLIFlags) (Anonymizer, error) {
+
+	if !cliFlags.PerformPlayerAnonymization {
+		return nil, nil
+	}
+
+	switch cliFlags.AnonymizerMode {
+	case datastruct.HMACAnonymizerMode:
+		log.Info("Detected that user wants anonymization, setting up HMACAnonymizer{}")
+		return NewHMACAnonymizer(cliFlags.AnonymizerKeyFile)
+	case datastruct.LocalDBAnonymizerMode:
+		log.Info("Detected that user wants anonymization, setting up LocalDBAnonymizer{}")
+		return NewLocalDBAnonymizer(cliFlags.AnonymizerDatabase)
 	}
 
 	log.Info("Detected that user wants anonymization, attempting to set up GRPCAnonymizer{}")
-	grpcAnonymizer := GRPCAnonymizer{}
-	if !grpcAnonymizer.grpcDialConnect() {
-		log.Error("Could not connect to the gRPC server!")
+	return newGRPCAnonymizer(GRPCAnonymizerOptions{
+		Address:     cliFlags.AnonymizerAddress,
+		CachePath:   cliFlags.AnonymizerCache,
+		TLS:         cliFlags.AnonymizerTLS,
+		TLSCAFile:   cliFlags.AnonymizerTLSCA,
+		TLSCertFile: cliFlags.AnonymizerTLSCert,
+		TLSKeyFile:  cliFlags.AnonymizerTLSKey,
+	})
+}
+
+// GRPCAnonymizerOptions configures the connection to the anonymization server.
+type GRPCAnonymizerOptions struct {
+	Address string
+	// CachePath is the file caching the responses between the runs,
+	// the responses are cached only in memory if it is empty:
+	CachePath string
+	// TLS enables TLS, the server is verified against the certificate authority
+	// in TLSCAFile or the system roots if it is empty:
+	TLS       bool
+	TLSCAFile string
+	// TLSCertFile and TLSKeyFile hold the client certificate used for mTLS:
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// newGRPCAnonymizer creates a GRPCAnonymizer connected to the server
+// described by the options.
+func newGRPCAnonymizer(options GRPCAnonymizerOptions) (*GRPCAnonymizer, error) {
+
+	cache, err := openAnonymizedIDStore(options.CachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	grpcAnonymizer := GRPCAnonymizer{
+		cache:           cache,
+		pendingRequests: make(map[string]*pendingAnonymizeRequest),
+	}
+	err = grpcAnonymizer.grpcDialConnect(options)
+	if err != nil {
+		log.WithField("error", err).Error("Could not connect to the gRPC server!")
+		cache.close()
+		return nil, err
 	}
 	grpcAnonymizer.grpcInitializeClient()
-	grpcAnonymizer.Cache = make(map[string]string)
 
-	return &grpcAnonymizer
+	return &grpcAnonymizer, nil
 }
 
 // anonymizeReplay is the main function that is responsible for
 // anonymizing the replay data. It calls other functions that are
 // responsible for anonymizing chat messages and player information.
+// The values that were replaced are counted in replayData.PIIAudit.
 func anonymizeReplay(
 	replayData *replay_data.CleanedReplay,
-	grpcAnonymizer *GRPCAnonymizer,
+	anonymizer Anonymizer,
 	performChatAnonymizationBool bool,
+	chatAnonymizationMode datastruct.ChatAnonymizationModeEnum,
 	performPlayerAnonymizationBool bool,
 ) bool {
 
 	log.Debug("Entered anonymizeReplay()")
 
+	audit := replay_data.PIIAudit{}
+
 	// Anonymization of Chat events that might
 	// contain sensitive information for research purposes:
 	if performChatAnonymizationBool {
-		if !anonimizeMessageEvents(replayData) {
-			log.Error("Failed to anonimize messageEvents.")
-			return false
-		}
+		anonymizeMessageEvents(replayData, chatAnonymizationMode, audit)
 	}
 
 	// Anonymizing player information such as toon, nickname,
-	// and clan this is done in order to redact potentially sensitive information:
-	if performPlayerAnonymizationBool {
-		if !anonymizePlayers(replayData, grpcAnonymizer) {
+	// and clan wherever it appears in the replay,
+	// this is done in order to redact potentially sensitive information:
+	if performPlayerAnonymizationBool && anonymizer != nil {
+		scrubber, ok := newPIIScrubber(replayData, anonymizer, audit)
+		if !ok {
 			log.Error("Failed to anonimize player information.")
 			return false
 		}
+		scrubber.scrubReplay(replayData)
 	}
 
-	log.Debug("Finished anonymizeReplay()")
+	replayData.PIIAudit = audit
+
+	log.WithField("piiAudit", audit).Debug("Finished anonymizeReplay()")
 	return true
 }
 
-//nolint:all
+// keepAliveParameters keep the idle connection to the anonymization server alive
+// and detect the broken connections between the requests:
 var keepAliveParameters = keepalive.ClientParameters{
-	Time:                20 * time.Second, // send pings every 10 seconds if there is no activity
-	Timeout:             10 * time.Second, // wait 1 second for ping ack before considering the connection dead
+	Time:                20 * time.Second, // send pings every 20 seconds if there is no activity
+	Timeout:             10 * time.Second, // wait 10 seconds for ping ack before considering the connection dead
 	PermitWithoutStream: true,             // send pings even without active streams
 }
 
-// Create new class, AnonymizerClient, that wraps the gRPC client
+// GRPCAnonymizer wraps the gRPC client of the anonymization server
 // (pb.NewAnonymizeServiceClient(conn) should happen once).
-// The class will store the gRPC connection and can store a local cache of responses.
+// It stores the gRPC connection and a cache of the responses
+// that is shared by all of the workers.
 type GRPCAnonymizer struct {
 	Connection *grpc.ClientConn
 	Client     pb.AnonymizeServiceClient
-	Cache      map[string]string
+	// cacheMutex guards the cache shared by the workers,
+	// it is not held during the requests to the server:
+	cacheMutex sync.RWMutex
+	cache      *anonymizedIDStore
+	// pendingMutex guards the requests of single toons that are in progress,
+	// so that the workers needing the same toon wait for a single request:
+	pendingMutex    sync.Mutex
+	pendingRequests map[string]*pendingAnonymizeRequest
+	// batchUnsupported is set if the server does not implement getAnonymizedIDs:
+	batchUnsupported bool
 }
 
-// grpcConnect initializes a connection to a specified in settings grpc server.
-func (anonymizer *GRPCAnonymizer) grpcDialConnect() bool {
+// pendingAnonymizeRequest is a request of the anonymized ID of a single toon
+// that is in progress, done is closed when the result is available.
+type pendingAnonymizeRequest struct {
+	done         chan struct{}
+	anonymizedID string
+	ok           bool
+}
+
+// grpcDialConnect initializes a connection to the grpc server described by the options,
+// the connection is established lazily with the first request.
+func (anonymizer *GRPCAnonymizer) grpcDialConnect(options GRPCAnonymizerOptions) error {
 
 	log.Debug("Entered GRPCAnonymizer.grpcDialConnect()")
 
+	transportCredentials := insecure.NewCredentials()
+	if options.TLS {
+		tlsConfig, err := utils.ClientTLSConfig(
+			options.TLSCAFile,
+			options.TLSCertFile,
+			options.TLSKeyFile,
+		)
+		if err != nil {
+			return err
+		}
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+
 	// Set up a connection to the server:
-	conn, err := grpc.NewClient(settings.GrpcServerAddress,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	conn, err := grpc.NewClient(options.Address,
+		grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithKeepaliveParams(keepAliveParameters),
 	)
-
 	if err != nil {
-		log.WithField("error", err).
-			Fatal("Failed to connect to grpc anonymization service")
-		return false
+		return err
 	}
 
 	anonymizer.Connection = conn
 
 	log.Debug("Finished GRPCAnonymizer.grpcDialConnect()")
-	return true
-
+	return nil
 }
 
 // grpcInitializeClient initializes a client for the gRPC connection.
@@ -113,52 +214,209 @@ func (anonymizer *GRPCAnonymizer) grpcInitializeClient() {
 	anonymizer.Client = pb.NewAnonymizeServiceClient(anonymizer.Connection)
 }
 
-// anonymizeToon checks if the player toon is already
+// AnonymizeToon checks if the player toon is already
 // in the cache and if it is not it calls grpcAnonymizeID.
-func (anonymizer *GRPCAnonymizer) anonymizeToon(toonString string) (string, bool) {
+func (anonymizer *GRPCAnonymizer) AnonymizeToon(toonString string) (string, bool) {
 
-	log.Debug("Entered GRPCAnonymizer.anonymizeToon()")
+	log.Debug("Entered GRPCAnonymizer.AnonymizeToon()")
 
 	// Check if the toon is already in cache not to spam the connection with requests:
-	val, ok := anonymizer.Cache[toonString]
+	anonymizer.cacheMutex.RLock()
+	val, ok := anonymizer.cache.get(toonString)
+	anonymizer.cacheMutex.RUnlock()
 	if ok {
 		return val, true
 	}
 
-	// If the toonString is not within cache already we check
-	// if it is possible to obtain it from anonymization server:
-	anonymizedID, grpcAnonOk := grpcGetAnonymizeID(
-		toonString,
-		anonymizer.Client,
-		anonymizer.Connection)
+	// Only one request per toon is sent, the other workers
+	// needing the same toon wait for its result:
+	anonymizer.pendingMutex.Lock()
+	request, pending := anonymizer.pendingRequests[toonString]
+	if pending {
+		anonymizer.pendingMutex.Unlock()
+		<-request.done
+		return request.anonymizedID, request.ok
+	}
+	request = &pendingAnonymizeRequest{done: make(chan struct{})}
+	anonymizer.pendingRequests[toonString] = request
+	anonymizer.pendingMutex.Unlock()
+
+	request.anonymizedID, request.ok = anonymizer.requestToon(toonString)
+
+	anonymizer.pendingMutex.Lock()
+	delete(anonymizer.pendingRequests, toonString)
+	anonymizer.pendingMutex.Unlock()
+	close(request.done)
+
+	log.Debug("Finished GRPCAnonymizer.AnonymizeToon()")
+	return request.anonymizedID, request.ok
+}
+
+// requestToon requests the anonymized ID of the toon from the server
+// and caches it, the cache lock is held only while the cache is accessed.
+func (anonymizer *GRPCAnonymizer) requestToon(toonString string) (string, bool) {
+
+	// The toon could have been cached before the request was registered:
+	anonymizer.cacheMutex.RLock()
+	val, ok := anonymizer.cache.get(toonString)
+	anonymizer.cacheMutex.RUnlock()
+	if ok {
+		return val, true
+	}
+
+	anonymizedID, grpcAnonOk := grpcGetAnonymizeID(toonString, anonymizer.Client)
 	if !grpcAnonOk {
 		return "", false
 	}
-	anonymizer.Cache[toonString] = anonymizedID
-	log.Debug("Finished GRPCAnonymizer.anonymizeToon()")
+
+	anonymizer.cacheMutex.Lock()
+	defer anonymizer.cacheMutex.Unlock()
+	// The batch requests could have cached the toon in the meantime:
+	val, ok = anonymizer.cache.get(toonString)
+	if ok {
+		return val, true
+	}
+	if !anonymizer.cacheID(toonString, anonymizedID) {
+		return "", false
+	}
 
 	return anonymizedID, true
 }
 
+// AnonymizeToons requests the IDs of the toons that are not cached
+// in batches of settings.AnonymizeBatchSize toons, in the order of the toons.
+// Servers that do not implement the batches receive a request per toon.
+func (anonymizer *GRPCAnonymizer) AnonymizeToons(toonStrings []string) bool {
+
+	log.Debug("Entered GRPCAnonymizer.AnonymizeToons()")
+
+	anonymizer.cacheMutex.Lock()
+	defer anonymizer.cacheMutex.Unlock()
+
+	uncachedToons := []string{}
+	requestedToons := make(map[string]bool)
+	for _, toonString := range toonStrings {
+		if _, ok := anonymizer.cache.get(toonString); ok || requestedToons[toonString] {
+			continue
+		}
+		requestedToons[toonString] = true
+		uncachedToons = append(uncachedToons, toonString)
+	}
+	log.WithFields(log.Fields{
+		"nToons":         len(toonStrings),
+		"nUncachedToons": len(uncachedToons),
+	}).Info("Requesting the anonymized IDs of the uncached toons.")
+
+	for start := 0; start < len(uncachedToons); start += settings.AnonymizeBatchSize {
+		batch := uncachedToons[start:min(start+settings.AnonymizeBatchSize, len(uncachedToons))]
+
+		anonymizedIDs, ok := anonymizer.requestBatch(batch)
+		if !ok {
+			return false
+		}
+		for i, toonString := range batch {
+			if !anonymizer.cacheID(toonString, anonymizedIDs[i]) {
+				return false
+			}
+		}
+	}
+
+	log.Debug("Finished GRPCAnonymizer.AnonymizeToons()")
+	return true
+}
+
+// requestBatch requests the IDs of the batch of toons with a single request,
+// falling back to a request per toon if the server does not implement the batches.
+func (anonymizer *GRPCAnonymizer) requestBatch(batch []string) ([]string, bool) {
+
+	if !anonymizer.batchUnsupported {
+		var result *pb.ReceiveIDs
+		err := callWithRetries(func(ctx context.Context) error {
+			var err error
+			result, err = anonymizer.Client.GetAnonymizedIDs(
+				ctx,
+				&pb.SendNicknames{Nicknames: batch})
+			return err
+		})
+		switch {
+		case err == nil && len(result.AnonymizedIDs) == len(batch):
+			return result.AnonymizedIDs, true
+		case err == nil:
+			log.WithFields(log.Fields{
+				"nToons":         len(batch),
+				"nAnonymizedIDs": len(result.AnonymizedIDs),
+			}).Error("Received a different number of anonymized IDs than requested!")
+			return nil, false
+		case status.Code(err) == codes.Unimplemented:
+			log.Warn("The anonymization server does not support batches, requesting the toons one by one.")
+			anonymizer.batchUnsupported = true
+		default:
+			log.WithField("error", err).
+				Error("Could not receive anonymized information from grpc service!")
+			return nil, false
+		}
+	}
+
+	anonymizedIDs := make([]string, 0, len(batch))
+	for _, toonString := range batch {
+		anonymizedID, ok := grpcGetAnonymizeID(toonString, anonymizer.Client)
+		if !ok {
+			return nil, false
+		}
+		anonymizedIDs = append(anonymizedIDs, anonymizedID)
+	}
+
+	return anonymizedIDs, true
+}
+
+// cacheID stores the anonymized ID received from the server,
+// the caller has to hold the write lock.
+func (anonymizer *GRPCAnonymizer) cacheID(toonString string, anonymizedID string) bool {
+
+	err := anonymizer.cache.put(toonString, anonymizedID)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to cache the anonymized ID!")
+		return false
+	}
+
+	return true
+}
+
+// Close closes the connection to the gRPC server and the cache file.
+func (anonymizer *GRPCAnonymizer) Close() error {
+
+	anonymizer.cacheMutex.Lock()
+	defer anonymizer.cacheMutex.Unlock()
+
+	cacheErr := anonymizer.cache.close()
+	err := anonymizer.Connection.Close()
+	if err != nil {
+		return err
+	}
+	return cacheErr
+}
+
 // grpcGetAnonymizeID is using https://github.com/Kaszanas/SC2AnonServerPy
 // in order to anonymize users.
 func grpcGetAnonymizeID(
 	toonString string,
 	grpcClient pb.AnonymizeServiceClient,
-	grpcConnection *grpc.ClientConn,
 ) (string, bool) {
 
 	log.Debug("Entered grpcAnonymize()")
 
 	// Contact the server and print out its response:
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-	result, err := grpcClient.GetAnonymizedID(
-		ctx,
-		&pb.SendNickname{Nickname: toonString})
+	var result *pb.ReceiveID
+	err := callWithRetries(func(ctx context.Context) error {
+		var err error
+		result, err = grpcClient.GetAnonymizedID(
+			ctx,
+			&pb.SendNickname{Nickname: toonString})
+		return err
+	})
 	if err != nil {
 		log.WithField("error", err).
-			Fatalf("Could not receive anonymized information from grpc service!")
+			Error("Could not receive anonymized information from grpc service!")
 		return "", false
 	}
 	log.WithField("gRPC_response", result.AnonymizedID).
@@ -168,60 +426,70 @@ func grpcGetAnonymizeID(
 	return result.AnonymizedID, true
 }
 
-func anonymizePlayers(
-	replayData *replay_data.CleanedReplay,
-	grpcAnonymizer *GRPCAnonymizer) bool {
-
-	log.Info("Entererd anonymizePlayers().")
-
-	var newToonDescMap = make(map[string]replay_data.EnhancedToonDescMap)
-
-	// Iterate over players:
-	log.Info("Starting to iterate over replayData.Details.PlayerList.")
-
-	// Iterate over Toon description map:
-	for toon, playerDesc := range replayData.ToonPlayerDescMap {
-
-		// Using gRPC for anonymization:
-		anonymizedID, anonymizeToonOk := grpcAnonymizer.anonymizeToon(toon)
-		if !anonymizeToonOk {
-			return false
+// callWithRetries calls the anonymization server up to settings.AnonymizeMaxAttempts times,
+// waiting with an exponential backoff after the attempts that failed with a transient error.
+func callWithRetries(call func(ctx context.Context) error) error {
+
+	backoff := settings.AnonymizeRetryInitialBackoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), settings.AnonymizeRequestTimeout)
+		err := call(ctx)
+		cancel()
+		if err == nil || !isTransientGRPCError(err) || attempt >= settings.AnonymizeMaxAttempts {
+			return err
 		}
-		anonymizedPlayerDesc := playerDesc
-		anonymizedPlayerDesc.Name = "redacted"
-		anonymizedPlayerDesc.ClanTag = "redacted"
-
-		newToonDescMap[anonymizedID] = anonymizedPlayerDesc
 
+		log.WithFields(log.Fields{
+			"error":   err,
+			"attempt": attempt,
+			"backoff": backoff,
+		}).Warn("Request to the anonymization server failed, retrying.")
+		time.Sleep(backoff)
+		backoff = min(2*backoff, settings.AnonymizeRetryMaxBackoff)
 	}
+}
 
-	// Replacing Toon desc map with anonymmized version containing
-	// a persistent anonymized ID of the player:
-	log.Info("Replacing ToonPlayerDescMap with anonymized version.")
-	replayData.ToonPlayerDescMap = newToonDescMap
-
-	log.WithField("toonDescMapAnonymized", replayData.ToonPlayerDescMap).
-		Debug("Replaced toonDescMap with anonymized version")
-
-	log.Debug("Finished anonymizePlayers()")
-	return true
+// isTransientGRPCError returns true for the errors that might not repeat,
+// such as the server being temporarily unavailable.
+func isTransientGRPCError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
 }
 
-// anonymizeMessageEvents checks against settings.UnusedMessageEvents
-// and creates a new clean version without specified events.
-func anonimizeMessageEvents(replayData *replay_data.CleanedReplay) bool {
+// anonymizeMessageEvents removes the events listed in settings.AnonymizeMessageEvents
+// or replaces only their text, keeping the loop, the sender and the recipient.
+func anonymizeMessageEvents(
+	replayData *replay_data.CleanedReplay,
+	chatAnonymizationMode datastruct.ChatAnonymizationModeEnum,
+	audit replay_data.PIIAudit,
+) {
+
+	log.Debug("Entered anonymizeMessageEvents().")
 
-	log.Debug("Entered anonimizeMessageEvents().")
 	var anonymizedMessageEvents []s2prot.Struct
 	for _, event := range replayData.MessageEvents {
 		eventType := event["evtTypeName"].(string)
 		if !contains(settings.AnonymizeMessageEvents, eventType) {
 			anonymizedMessageEvents = append(anonymizedMessageEvents, event)
+			continue
+		}
+
+		if chatAnonymizationMode == datastruct.DropChatAnonymizationMode {
+			audit["messageEvents."+eventType]++
+			continue
+		}
+		textPath := "messageEvents." + eventType + "." + settings.ChatTextField
+		if _, ok := event[settings.ChatTextField]; ok {
+			event[settings.ChatTextField] = redactedValue
+			audit[textPath]++
 		}
+		anonymizedMessageEvents = append(anonymizedMessageEvents, event)
 	}
 
 	replayData.MessageEvents = anonymizedMessageEvents
 
 	log.Debug("Finished anonymizeMessageEvents()")
-	return true
 }
@@ -0,0 +1,70 @@
+package dataproc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// hmacAnonymizedIDBytes is the number of bytes of the HMAC
+// that are kept in the anonymized ID, 16 bytes yield 32 hexadecimal characters.
+const hmacAnonymizedIDBytes = 16
+
+// HMACAnonymizer derives the anonymized IDs from a keyed HMAC-SHA256 of the toon.
+// It does not hold any state, the same key always yields the same IDs,
+// so that the anonymization is reproducible without any server or database.
+type HMACAnonymizer struct {
+	key []byte
+}
+
+// NewHMACAnonymizer reads the secret key of the HMAC from the key file,
+// the surrounding whitespace of the key is ignored.
+func NewHMACAnonymizer(keyFile string) (*HMACAnonymizer, error) {
+
+	log.Debug("Entered NewHMACAnonymizer()")
+
+	if keyFile == "" {
+		return nil, fmt.Errorf("the key file of the HMAC anonymizer is not provided")
+	}
+	keyBytes, err := os.ReadFile(keyFile)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to read the anonymizer key file!")
+		return nil, fmt.Errorf("failed to read the anonymizer key file: %v", err)
+	}
+	key := bytes.TrimSpace(keyBytes)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("the anonymizer key file %s is empty", keyFile)
+	}
+
+	log.Debug("Finished NewHMACAnonymizer()")
+	return &HMACAnonymizer{key: key}, nil
+}
+
+// AnonymizeToon returns the hexadecimal prefix of the HMAC of the toon.
+func (anonymizer *HMACAnonymizer) AnonymizeToon(toonString string) (string, bool) {
+	return anonymizer.keyedHash(toonString)[:2*hmacAnonymizedIDBytes], true
+}
+
+// keyedHash returns the hexadecimal HMAC of the value.
+func (anonymizer *HMACAnonymizer) keyedHash(value string) string {
+
+	mac := hmac.New(sha256.New, anonymizer.key)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AnonymizeToons does nothing, the IDs are derived when they are needed.
+func (anonymizer *HMACAnonymizer) AnonymizeToons(toonStrings []string) bool {
+	return true
+}
+
+// Close does nothing, the HMACAnonymizer does not hold any resources.
+func (anonymizer *HMACAnonymizer) Close() error {
+	return nil
+}
@@ -0,0 +1,98 @@
+package dataproc
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LocalDBAnonymizer assigns incrementing IDs to the toons in the order
+// in which they are first seen, the same way as the anonymization server.
+// The IDs are stored in a JSON lines database file, every assignment
+// is appended and synced before it is used, so the IDs persist between the runs.
+// It is safe to use by the concurrent workers of a single process,
+// the database file is locked so that it cannot be opened by another process.
+type LocalDBAnonymizer struct {
+	mutex sync.RWMutex
+	store *anonymizedIDStore
+}
+
+// NewLocalDBAnonymizer opens the database file, creating it if it does not exist,
+// and loads the IDs that were assigned in the previous runs.
+func NewLocalDBAnonymizer(databasePath string) (*LocalDBAnonymizer, error) {
+
+	log.Debug("Entered NewLocalDBAnonymizer()")
+
+	if databasePath == "" {
+		return nil, fmt.Errorf("the database of the local anonymizer is not provided")
+	}
+	store, err := openAnonymizedIDStore(databasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug("Finished NewLocalDBAnonymizer()")
+	return &LocalDBAnonymizer{store: store}, nil
+}
+
+// AnonymizeToon returns the ID assigned to the toon,
+// assigning and storing the next ID if the toon was not seen before.
+func (anonymizer *LocalDBAnonymizer) AnonymizeToon(toonString string) (string, bool) {
+
+	anonymizer.mutex.RLock()
+	anonymizedID, ok := anonymizer.store.get(toonString)
+	anonymizer.mutex.RUnlock()
+	if ok {
+		return anonymizedID, true
+	}
+
+	anonymizer.mutex.Lock()
+	defer anonymizer.mutex.Unlock()
+
+	return anonymizer.assignID(toonString)
+}
+
+// AnonymizeToons assigns the IDs to the toons that were not seen before
+// in the order of the toons.
+func (anonymizer *LocalDBAnonymizer) AnonymizeToons(toonStrings []string) bool {
+
+	anonymizer.mutex.Lock()
+	defer anonymizer.mutex.Unlock()
+
+	for _, toonString := range toonStrings {
+		if _, ok := anonymizer.assignID(toonString); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// assignID returns the ID of the toon, assigning the next ID if the toon
+// was not seen before. The caller has to hold the write lock.
+func (anonymizer *LocalDBAnonymizer) assignID(toonString string) (string, bool) {
+
+	anonymizedID, ok := anonymizer.store.get(toonString)
+	if ok {
+		return anonymizedID, true
+	}
+
+	anonymizedID = anonymizer.store.nextID()
+	err := anonymizer.store.put(toonString, anonymizedID)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to save the anonymizer database entry!")
+		return "", false
+	}
+
+	return anonymizedID, true
+}
+
+// Close closes the database file.
+func (anonymizer *LocalDBAnonymizer) Close() error {
+
+	anonymizer.mutex.Lock()
+	defer anonymizer.mutex.Unlock()
+
+	return anonymizer.store.close()
+}
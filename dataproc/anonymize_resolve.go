@@ -0,0 +1,91 @@
+package dataproc
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc/cleanup"
+	"github.com/icza/s2prot/rep"
+	log "github.com/sirupsen/logrus"
+)
+
+// ResolveInputToons reads the lobby slots of every replay without decoding its events
+// and resolves the anonymized IDs of all of the toons before the extraction begins.
+// The toons are resolved in a sorted order, so that the newly assigned IDs
+// do not depend on the order in which the replays are processed.
+// Unreadable replays are skipped, they are rejected during the extraction.
+func ResolveInputToons(
+	anonymizer Anonymizer,
+	replayFiles []string,
+	numberOfThreads int,
+) bool {
+
+	log.Debug("Entered ResolveInputToons()")
+
+	files := make(chan string)
+	toonSets := make([]map[string]bool, numberOfThreads)
+	var wg sync.WaitGroup
+	for i := range toonSets {
+		toonSets[i] = make(map[string]bool)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for replayFile := range files {
+				for _, toon := range replayToons(replayFile) {
+					toonSets[i][toon] = true
+				}
+			}
+		}()
+	}
+	for _, replayFile := range replayFiles {
+		files <- replayFile
+	}
+	close(files)
+	wg.Wait()
+
+	uniqueToons := make(map[string]bool)
+	for _, toonSet := range toonSets {
+		for toon := range toonSet {
+			uniqueToons[toon] = true
+		}
+	}
+	toons := make([]string, 0, len(uniqueToons))
+	for toon := range uniqueToons {
+		toons = append(toons, toon)
+	}
+	slices.Sort(toons)
+
+	log.WithField("nToons", len(toons)).Info("Resolving the anonymized IDs of the input toons.")
+	if !anonymizer.AnonymizeToons(toons) {
+		log.Error("Failed to resolve the anonymized IDs of the input toons!")
+		return false
+	}
+
+	log.Debug("Finished ResolveInputToons()")
+	return true
+}
+
+// replayToons returns the toons of the occupied lobby slots of the replay,
+// which are the toons of the players and the observers that are anonymized.
+func replayToons(replayFile string) []string {
+
+	replayData, err := rep.NewFromFileEvts(replayFile, false, false, false)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"file":  replayFile,
+			"error": err,
+		}).Warn("Failed to read the toons of the replay, skipping.")
+		return nil
+	}
+	defer replayData.Close()
+
+	toons := []string{}
+	for i := range replayData.InitData.LobbyState.Slots {
+		slot := &replayData.InitData.LobbyState.Slots[i]
+		if _, ok := cleanup.SlotRole(slot); ok {
+			toons = append(toons, slot.ToonHandle())
+		}
+	}
+
+	return toons
+}
@@ -0,0 +1,311 @@
+package dataproc
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/icza/s2prot"
+	log "github.com/sirupsen/logrus"
+)
+
+// redactedValue replaces the identifying values that have no anonymized ID.
+const redactedValue = "redacted"
+
+// piiScrubber replaces the toons, the nicknames and the clan tags
+// of the players and the observers wherever they appear in the replay
+// and counts the replaced values in the audit.
+type piiScrubber struct {
+	// anonymizedToons maps the toons to the IDs provided by the anonymizer:
+	anonymizedToons map[string]string
+	// toonsRegexp matches any of the toons as a whole token,
+	// it is nil if there are no toons:
+	toonsRegexp *regexp.Regexp
+	// namesRegexp matches any of the nicknames and the clan tags regardless of the case,
+	// the matches are accepted only at the word boundaries, it is nil if there are no names:
+	namesRegexp *regexp.Regexp
+	audit       replay_data.PIIAudit
+}
+
+// newPIIScrubber collects the identifying values of the players and the observers
+// and requests their anonymized IDs from the anonymizer.
+func newPIIScrubber(
+	replayData *replay_data.CleanedReplay,
+	anonymizer Anonymizer,
+	audit replay_data.PIIAudit,
+) (*piiScrubber, bool) {
+
+	log.Debug("Entered newPIIScrubber()")
+
+	scrubber := piiScrubber{
+		anonymizedToons: make(map[string]string),
+		audit:           audit,
+	}
+	names := []string{}
+
+	// Iterate over Toon description map in a stable order,
+	// the anonymization server assigns the IDs in the order of requests:
+	toons := sortedToonKeys(replayData.ToonPlayerDescMap)
+	for _, toon := range toons {
+		playerDesc := replayData.ToonPlayerDescMap[toon]
+		names = append(names, playerDesc.Name, playerDesc.ClanTag)
+	}
+	for _, observer := range replayData.Observers {
+		toons = append(toons, observer.Toon)
+		names = append(names, observer.Name)
+	}
+
+	for _, toon := range toons {
+		if _, ok := scrubber.anonymizedToons[toon]; ok || toon == "" {
+			continue
+		}
+		anonymizedID, ok := anonymizer.AnonymizeToon(toon)
+		if !ok {
+			log.WithField("toon", toon).Error("Failed to anonymize the toon!")
+			return nil, false
+		}
+		scrubber.anonymizedToons[toon] = anonymizedID
+	}
+
+	toonsAlternation := longestFirstAlternation(toons)
+	if toonsAlternation != "" {
+		scrubber.toonsRegexp = regexp.MustCompile(`\b(?:` + toonsAlternation + `)\b`)
+	}
+	namesAlternation := longestFirstAlternation(names)
+	if namesAlternation != "" {
+		scrubber.namesRegexp = regexp.MustCompile("(?i)" + namesAlternation)
+	}
+
+	log.Debug("Finished newPIIScrubber()")
+	return &scrubber, true
+}
+
+// longestFirstAlternation joins the quoted values into a regular expression alternation.
+// Longer values are matched first, so that the values that contain
+// other values, such as the toons sharing a prefix, are replaced as a whole.
+func longestFirstAlternation(values []string) string {
+
+	quotedValues := []string{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			quotedValues = append(quotedValues, regexp.QuoteMeta(value))
+		}
+	}
+	slices.SortFunc(quotedValues, func(a string, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+	quotedValues = slices.Compact(quotedValues)
+
+	return strings.Join(quotedValues, "|")
+}
+
+// scrubReplay replaces the identifying values in all of the sections of the replay.
+func (scrubber *piiScrubber) scrubReplay(replayData *replay_data.CleanedReplay) {
+
+	log.Debug("Entered piiScrubber.scrubReplay()")
+
+	// Replacing Toon desc map with anonymmized version containing
+	// a persistent anonymized ID of the player:
+	anonymizedToonDescMap := make(map[string]replay_data.EnhancedToonDescMap)
+	for toon, playerDesc := range replayData.ToonPlayerDescMap {
+		playerDesc.Name = scrubber.redact("ToonPlayerDescMap.nickname")
+		playerDesc.ClanTag = scrubber.redact("ToonPlayerDescMap.clanTag")
+		anonymizedToonDescMap[scrubber.anonymizedToons[toon]] = playerDesc
+		scrubber.audit["ToonPlayerDescMap.toon"]++
+	}
+	replayData.ToonPlayerDescMap = anonymizedToonDescMap
+
+	// Observers are anonymized in the same way as the players:
+	for i := range replayData.Observers {
+		observer := &replayData.Observers[i]
+		observer.Toon = scrubber.scrubIdentifyingValue("observers.toon", observer.Toon).(string)
+		observer.Name = scrubber.redact("observers.nickname")
+	}
+
+	// Map author is not a free text, only the toons are replaced in it:
+	gameDescription := &replayData.InitData.GameDescription
+	gameDescription.MapAuthorName = scrubber.scrubToons(
+		"initData.gameDescription.mapAuthorName",
+		gameDescription.MapAuthorName,
+	)
+
+	// Tracker events are generated by the game and contain
+	// only the names of the game data, they are not searched:
+	for _, event := range replayData.GameEvents {
+		scrubber.scrubEvent("gameEvents", event)
+	}
+	for _, event := range replayData.MessageEvents {
+		scrubber.scrubEvent("messageEvents", event)
+	}
+
+	log.Debug("Finished piiScrubber.scrubReplay()")
+}
+
+// scrubEvent replaces the settings.IdentifyingEventFields of the event,
+// the toons and the names in its settings.FreeTextEventFields
+// and the toons in all of its other text fields.
+func (scrubber *piiScrubber) scrubEvent(stream string, event map[string]any) {
+
+	eventType, _ := event["evtTypeName"].(string)
+	eventPath := stream + "." + eventType
+	identifyingFields := settings.IdentifyingEventFields[eventType]
+	freeTextFields := settings.FreeTextEventFields[eventType]
+	for field, value := range event {
+		switch {
+		case field == "evtTypeName":
+		case slices.Contains(identifyingFields, field):
+			event[field] = scrubber.scrubIdentifyingValue(eventPath+"."+field, value)
+		case slices.Contains(freeTextFields, field):
+			if text, ok := value.(string); ok {
+				event[field] = scrubber.scrubText(eventPath+"."+field, text)
+			}
+		default:
+			event[field] = scrubber.scrubValue(eventPath+"."+field, value)
+		}
+	}
+}
+
+// scrubValue replaces the toons in the strings
+// of the value and of all of the nested structures.
+func (scrubber *piiScrubber) scrubValue(path string, value any) any {
+
+	switch typedValue := value.(type) {
+	case string:
+		return scrubber.scrubToons(path, typedValue)
+	case s2prot.Struct:
+		for field, nestedValue := range typedValue {
+			typedValue[field] = scrubber.scrubValue(path+"."+field, nestedValue)
+		}
+	case map[string]any:
+		for field, nestedValue := range typedValue {
+			typedValue[field] = scrubber.scrubValue(path+"."+field, nestedValue)
+		}
+	case []any:
+		for i, nestedValue := range typedValue {
+			typedValue[i] = scrubber.scrubValue(path, nestedValue)
+		}
+	}
+
+	return value
+}
+
+// scrubToons replaces the toons that are whole tokens of the text
+// with their anonymized IDs.
+func (scrubber *piiScrubber) scrubToons(path string, text string) string {
+
+	scrubbedText := scrubber.replaceToons(text)
+	if scrubbedText != text {
+		scrubber.audit[path]++
+	}
+	return scrubbedText
+}
+
+// scrubText replaces the toons with their anonymized IDs
+// and the nicknames and the clan tags with redactedValue within the free text.
+func (scrubber *piiScrubber) scrubText(path string, text string) string {
+
+	scrubbedText := scrubber.replaceNames(scrubber.replaceToons(text))
+	if scrubbedText != text {
+		scrubber.audit[path]++
+	}
+	return scrubbedText
+}
+
+// replaceToons replaces the toons that are whole tokens of the text.
+func (scrubber *piiScrubber) replaceToons(text string) string {
+
+	if scrubber.toonsRegexp == nil {
+		return text
+	}
+	return scrubber.toonsRegexp.ReplaceAllStringFunc(text, func(toon string) string {
+		return scrubber.anonymizedToons[toon]
+	})
+}
+
+// replaceNames replaces the nicknames and the clan tags with redactedValue
+// if they are not a part of a longer word, for example "ent" is replaced
+// in "gg ent" but not in "Entertainment".
+func (scrubber *piiScrubber) replaceNames(text string) string {
+
+	if scrubber.namesRegexp == nil {
+		return text
+	}
+
+	var scrubbedText strings.Builder
+	position := 0
+	searchFrom := 0
+	for searchFrom < len(text) {
+		match := scrubber.namesRegexp.FindStringIndex(text[searchFrom:])
+		if match == nil {
+			break
+		}
+		start, end := searchFrom+match[0], searchFrom+match[1]
+		if !isWordBoundary(text, start) || !isWordBoundary(text, end) {
+			// Matches starting within the rejected match are searched as well:
+			_, runeSize := utf8.DecodeRuneInString(text[start:])
+			searchFrom = start + max(runeSize, 1)
+			continue
+		}
+		scrubbedText.WriteString(text[position:start])
+		scrubbedText.WriteString(redactedValue)
+		position = end
+		searchFrom = end
+	}
+	scrubbedText.WriteString(text[position:])
+
+	return scrubbedText.String()
+}
+
+// isWordBoundary verifies if the position does not split a word,
+// it is not surrounded by the letters, digits or underscores on both sides.
+func isWordBoundary(text string, position int) bool {
+
+	if position == 0 || position == len(text) {
+		return true
+	}
+	previousRune, _ := utf8.DecodeLastRuneInString(text[:position])
+	nextRune, _ := utf8.DecodeRuneInString(text[position:])
+
+	return !isWordRune(previousRune) || !isWordRune(nextRune)
+}
+
+// isWordRune verifies if the rune is a letter, a digit or an underscore.
+func isWordRune(character rune) bool {
+	return character == '_' || unicode.IsLetter(character) || unicode.IsDigit(character)
+}
+
+// scrubIdentifyingValue replaces a value that identifies a user as a whole,
+// the known toons are replaced with their anonymized IDs, other strings with redactedValue
+// and other values, such as the clan logos, with nil.
+func (scrubber *piiScrubber) scrubIdentifyingValue(path string, value any) any {
+
+	if value == nil || value == "" {
+		return value
+	}
+
+	scrubber.audit[path]++
+	stringValue, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	if anonymizedID, ok := scrubber.anonymizedToons[stringValue]; ok {
+		return anonymizedID
+	}
+	return redactedValue
+}
+
+// redact returns the value replacing the nickname or the clan tag
+// of a player or an observer.
+func (scrubber *piiScrubber) redact(path string) string {
+
+	scrubber.audit[path]++
+	return redactedValue
+}
@@ -0,0 +1,260 @@
+package dataproc
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/icza/s2prot"
+)
+
+// scrubTestReplay creates a replay of two players and an observer whose names
+// and toons appear in the lobby events, the chat and the map author.
+// Map author is not a free text, so only the toons are replaced in it.
+func scrubTestReplay() *replay_data.CleanedReplay {
+
+	return &replay_data.CleanedReplay{
+		InitData: replay_data.CleanedInitData{
+			GameDescription: replay_data.CleanedGameDescription{MapAuthorName: "Alice"},
+		},
+		ToonPlayerDescMap: map[string]replay_data.EnhancedToonDescMap{
+			"2-S2-1-111": {Name: "Alice", ClanTag: "CLN", PlayerID: 1},
+			"2-S2-1-222": {Name: "Bob", PlayerID: 2},
+		},
+		Observers: []replay_data.ObserverDesc{
+			{Name: "Carol", Toon: "2-S2-1-333", UserID: 2},
+		},
+		GameEvents: []map[string]any{
+			{
+				"evtTypeName": "GameUserJoin",
+				"name":        "Eve",
+				"toonHandle":  "2-S2-1-111",
+				"clanTag":     "",
+				"clanLogo":    s2prot.Struct{"cacheHandle": "logo"},
+			},
+			{
+				"evtTypeName": "TriggerChatMessage",
+				"chatMessage": "see 2-S2-1-222",
+			},
+		},
+		MessageEvents: []s2prot.Struct{
+			{"evtTypeName": "Chat", "recipient": int64(0), "string": "gg ALICE and [cln]bob"},
+			{"evtTypeName": "Ping", "recipient": int64(0), "point": s2prot.Struct{"x": int64(1)}},
+		},
+	}
+}
+
+// TestAnonymizeReplay verifies that the identifying values are replaced
+// in all of the sections of the replay and counted in the audit.
+func TestAnonymizeReplay(t *testing.T) {
+
+	anonymizer, err := NewLocalDBAnonymizer(
+		filepath.Join(t.TempDir(), "anonymized_toons.jsonl"),
+	)
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	defer anonymizer.Close()
+
+	replayData := scrubTestReplay()
+	if !anonymizeReplay(replayData, anonymizer, false, datastruct.DropChatAnonymizationMode, true) {
+		t.Fatalf("Test Failed! Failed to anonymize the replay")
+	}
+
+	for anonymizedID, playerDesc := range replayData.ToonPlayerDescMap {
+		if anonymizedID != "1" && anonymizedID != "2" {
+			t.Errorf("Test Failed! Unexpected key of ToonPlayerDescMap %q", anonymizedID)
+		}
+		if playerDesc.Name != redactedValue || playerDesc.ClanTag != redactedValue {
+			t.Errorf("Test Failed! Player was not redacted: %+v", playerDesc)
+		}
+	}
+	observer := replayData.Observers[0]
+	if observer.Toon != "3" || observer.Name != redactedValue {
+		t.Errorf("Test Failed! Observer was not anonymized: %+v", observer)
+	}
+
+	expectedValues := []struct {
+		name     string
+		actual   any
+		expected any
+	}{
+		{"map author", replayData.InitData.GameDescription.MapAuthorName, "Alice"},
+		{"join name", replayData.GameEvents[0]["name"], redactedValue},
+		{"join toon", replayData.GameEvents[0]["toonHandle"], "1"},
+		{"join clan tag", replayData.GameEvents[0]["clanTag"], ""},
+		{"join clan logo", replayData.GameEvents[0]["clanLogo"], nil},
+		{"trigger chat", replayData.GameEvents[1]["chatMessage"], "see 2"},
+		{"chat", replayData.MessageEvents[0]["string"], "gg redacted and [redacted]redacted"},
+	}
+	for _, expectedValue := range expectedValues {
+		if expectedValue.actual != expectedValue.expected {
+			t.Errorf(
+				"Test Failed! Expected %q for the %s, got %q",
+				expectedValue.expected,
+				expectedValue.name,
+				expectedValue.actual,
+			)
+		}
+	}
+
+	expectedAudit := replay_data.PIIAudit{
+		"ToonPlayerDescMap.toon":                    2,
+		"ToonPlayerDescMap.nickname":                2,
+		"ToonPlayerDescMap.clanTag":                 2,
+		"observers.toon":                            1,
+		"observers.nickname":                        1,
+		"gameEvents.GameUserJoin.name":              1,
+		"gameEvents.GameUserJoin.toonHandle":        1,
+		"gameEvents.GameUserJoin.clanLogo":          1,
+		"gameEvents.TriggerChatMessage.chatMessage": 1,
+		"messageEvents.Chat.string":                 1,
+	}
+	if len(replayData.PIIAudit) != len(expectedAudit) {
+		t.Errorf("Test Failed! Expected the audit %v, got %v", expectedAudit, replayData.PIIAudit)
+	}
+	for path, count := range expectedAudit {
+		if replayData.PIIAudit[path] != count {
+			t.Errorf(
+				"Test Failed! Expected %d for %s in the audit, got %d",
+				count,
+				path,
+				replayData.PIIAudit[path],
+			)
+		}
+	}
+}
+
+// TestPIIScrubberTokens verifies that the toons and the names are replaced
+// only as whole tokens and that the names are searched only in the free text.
+func TestPIIScrubberTokens(t *testing.T) {
+
+	anonymizer, err := NewLocalDBAnonymizer(
+		filepath.Join(t.TempDir(), "anonymized_toons.jsonl"),
+	)
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	defer anonymizer.Close()
+
+	replayData := &replay_data.CleanedReplay{
+		ToonPlayerDescMap: map[string]replay_data.EnhancedToonDescMap{
+			"2-S2-1-123":  {Name: "Al", ClanTag: "ENT", PlayerID: 1},
+			"2-S2-1-1234": {Name: "Bob", PlayerID: 2},
+		},
+	}
+	scrubber, ok := newPIIScrubber(replayData, anonymizer, replay_data.PIIAudit{})
+	if !ok {
+		t.Fatalf("Test Failed! Failed to create the scrubber")
+	}
+	shortToonID := scrubber.anonymizedToons["2-S2-1-123"]
+	longToonID := scrubber.anonymizedToons["2-S2-1-1234"]
+	if shortToonID == "" || longToonID == "" || shortToonID == longToonID {
+		t.Fatalf("Test Failed! Unexpected anonymized IDs %v", scrubber.anonymizedToons)
+	}
+
+	testCases := []struct {
+		name     string
+		scrub    func(path string, text string) string
+		text     string
+		expected string
+	}{
+		{
+			name:     "toons sharing a prefix",
+			scrub:    scrubber.scrubToons,
+			text:     "2-S2-1-1234 vs 2-S2-1-123",
+			expected: longToonID + " vs " + shortToonID,
+		},
+		{
+			name:     "toon within a longer number",
+			scrub:    scrubber.scrubToons,
+			text:     "2-S2-1-12345",
+			expected: "2-S2-1-12345",
+		},
+		{
+			name:     "names in the text field",
+			scrub:    scrubber.scrubToons,
+			text:     "Al of ENT",
+			expected: "Al of ENT",
+		},
+		{
+			name:     "names within longer words",
+			scrub:    scrubber.scrubText,
+			text:     "Blizzard Entertainment, Alas",
+			expected: "Blizzard Entertainment, Alas",
+		},
+		{
+			name:     "names as whole words",
+			scrub:    scrubber.scrubText,
+			text:     "gg al, [ent]Bob 2-S2-1-123",
+			expected: "gg redacted, [redacted]redacted " + shortToonID,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			scrubbedText := testCase.scrub("path", testCase.text)
+			if scrubbedText != testCase.expected {
+				t.Errorf("Test Failed! Expected %q, got %q", testCase.expected, scrubbedText)
+			}
+		})
+	}
+}
+
+// TestAnonymizeMessageEvents verifies that the chat messages are dropped
+// or that only their text is redacted, keeping the other message events.
+func TestAnonymizeMessageEvents(t *testing.T) {
+
+	testCases := []struct {
+		name             string
+		mode             datastruct.ChatAnonymizationModeEnum
+		expectedEvents   int
+		expectedAuditKey string
+	}{
+		{
+			name:             "drop",
+			mode:             datastruct.DropChatAnonymizationMode,
+			expectedEvents:   1,
+			expectedAuditKey: "messageEvents.Chat",
+		},
+		{
+			name:             "redact text",
+			mode:             datastruct.RedactTextChatAnonymizationMode,
+			expectedEvents:   2,
+			expectedAuditKey: "messageEvents.Chat.string",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			replayData := scrubTestReplay()
+			if !anonymizeReplay(replayData, nil, true, testCase.mode, false) {
+				t.Fatalf("Test Failed! Failed to anonymize the replay")
+			}
+
+			if len(replayData.MessageEvents) != testCase.expectedEvents {
+				t.Fatalf(
+					"Test Failed! Expected %d message events, got %d",
+					testCase.expectedEvents,
+					len(replayData.MessageEvents),
+				)
+			}
+			if replayData.PIIAudit[testCase.expectedAuditKey] != 1 {
+				t.Errorf("Test Failed! Unexpected audit %v", replayData.PIIAudit)
+			}
+			for _, event := range replayData.MessageEvents {
+				if event["evtTypeName"] != "Chat" {
+					continue
+				}
+				if event["string"] != redactedValue || event["recipient"] != int64(0) {
+					t.Errorf("Test Failed! Expected only the text to be redacted, got %v", event)
+				}
+			}
+			// Players are not anonymized without the player anonymization:
+			if _, ok := replayData.ToonPlayerDescMap["2-S2-1-111"]; !ok {
+				t.Errorf("Test Failed! Expected the players to be left intact")
+			}
+		})
+	}
+}
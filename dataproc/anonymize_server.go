@@ -0,0 +1,88 @@
+package dataproc
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	pb "github.com/Kaszanas/SC2InfoExtractorGo/proto"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// AnonymizeServer implements the AnonymizeService of proto/anonymize.proto
+// in place of https://github.com/Kaszanas/SC2AnonServerPy,
+// handing out the IDs of the wrapped Anonymizer.
+type AnonymizeServer struct {
+	pb.UnimplementedAnonymizeServiceServer
+	anonymizer Anonymizer
+}
+
+// keepAliveEnforcementPolicy accepts the keepalive pings of the clients
+// sent with keepAliveParameters:
+var keepAliveEnforcementPolicy = keepalive.EnforcementPolicy{
+	MinTime:             10 * time.Second,
+	PermitWithoutStream: true,
+}
+
+// NewAnonymizeGRPCServer creates a gRPC server with the AnonymizeService
+// backed by the anonymizer registered on it.
+// The server accepts only TLS connections if tlsConfig is not nil.
+func NewAnonymizeGRPCServer(anonymizer Anonymizer, tlsConfig *tls.Config) *grpc.Server {
+
+	serverOptions := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepAliveEnforcementPolicy),
+	}
+	if tlsConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	grpcServer := grpc.NewServer(serverOptions...)
+	pb.RegisterAnonymizeServiceServer(grpcServer, &AnonymizeServer{anonymizer: anonymizer})
+
+	return grpcServer
+}
+
+// GetAnonymizedID returns the anonymized ID of the toon sent as the nickname.
+func (server *AnonymizeServer) GetAnonymizedID(
+	ctx context.Context,
+	request *pb.SendNickname,
+) (*pb.ReceiveID, error) {
+
+	anonymizedID, ok := server.anonymizer.AnonymizeToon(request.Nickname)
+	if !ok {
+		log.WithField("toon", request.Nickname).Error("Failed to anonymize the toon!")
+		return nil, status.Error(codes.Internal, "failed to anonymize the toon")
+	}
+
+	return &pb.ReceiveID{AnonymizedID: anonymizedID}, nil
+}
+
+// GetAnonymizedIDs returns the anonymized IDs of the toons sent as the nicknames
+// in the order of the nicknames.
+func (server *AnonymizeServer) GetAnonymizedIDs(
+	ctx context.Context,
+	request *pb.SendNicknames,
+) (*pb.ReceiveIDs, error) {
+
+	if !server.anonymizer.AnonymizeToons(request.Nicknames) {
+		log.WithField("nToons", len(request.Nicknames)).Error("Failed to anonymize the toons!")
+		return nil, status.Error(codes.Internal, "failed to anonymize the toons")
+	}
+
+	anonymizedIDs := make([]string, 0, len(request.Nicknames))
+	for _, toonString := range request.Nicknames {
+		anonymizedID, ok := server.anonymizer.AnonymizeToon(toonString)
+		if !ok {
+			log.WithField("toon", toonString).Error("Failed to anonymize the toon!")
+			return nil, status.Error(codes.Internal, "failed to anonymize the toon")
+		}
+		anonymizedIDs = append(anonymizedIDs, anonymizedID)
+	}
+
+	return &pb.ReceiveIDs{AnonymizedIDs: anonymizedIDs}, nil
+}
@@ -0,0 +1,502 @@
+package dataproc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/Kaszanas/SC2InfoExtractorGo/proto"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestAnonymizeServer runs the gRPC client used by the pipeline
+// against the anonymization server started in-process.
+func TestAnonymizeServer(t *testing.T) {
+
+	databasePath := filepath.Join(t.TempDir(), "anonymized_toons.jsonl")
+
+	// serve starts the server on a free port and returns the address and a stop function:
+	serve := func() (string, func()) {
+		localDBAnonymizer, err := NewLocalDBAnonymizer(databasePath)
+		if err != nil {
+			t.Fatalf("Test Failed! Unexpected error: %v", err)
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Test Failed! Cannot listen: %v", err)
+		}
+		grpcServer := NewAnonymizeGRPCServer(localDBAnonymizer, nil)
+		go grpcServer.Serve(listener)
+
+		return listener.Addr().String(), func() {
+			grpcServer.GracefulStop()
+			localDBAnonymizer.Close()
+		}
+	}
+
+	address, stop := serve()
+	grpcAnonymizer, err := newGRPCAnonymizer(GRPCAnonymizerOptions{Address: address})
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	expectedIDs := []struct {
+		toon         string
+		anonymizedID string
+	}{
+		{toon: "2-S2-1-111", anonymizedID: "1"},
+		{toon: "2-S2-1-222", anonymizedID: "2"},
+		{toon: "2-S2-1-111", anonymizedID: "1"},
+	}
+	for _, expected := range expectedIDs {
+		anonymizedID, ok := grpcAnonymizer.AnonymizeToon(expected.toon)
+		if !ok || anonymizedID != expected.anonymizedID {
+			t.Errorf(
+				"Test Failed! Expected %q for %s, got %q",
+				expected.anonymizedID,
+				expected.toon,
+				anonymizedID,
+			)
+		}
+	}
+	grpcAnonymizer.Close()
+	stop()
+
+	// IDs persist after the server is restarted:
+	address, stop = serve()
+	defer stop()
+	restartedAnonymizer, err := newGRPCAnonymizer(GRPCAnonymizerOptions{Address: address})
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	defer restartedAnonymizer.Close()
+	for toon, expectedID := range map[string]string{"2-S2-1-222": "2", "2-S2-1-333": "3"} {
+		anonymizedID, ok := restartedAnonymizer.AnonymizeToon(toon)
+		if !ok || anonymizedID != expectedID {
+			t.Errorf("Test Failed! Expected %q for %s, got %q", expectedID, toon, anonymizedID)
+		}
+	}
+}
+
+// unaryOnlyAnonymizeServer implements only the getAnonymizedID of the AnonymizeService
+// like the servers created before the batches were introduced.
+type unaryOnlyAnonymizeServer struct {
+	pb.UnimplementedAnonymizeServiceServer
+	anonymizer Anonymizer
+	nRequests  int
+}
+
+// GetAnonymizedID returns the anonymized ID of the toon sent as the nickname.
+func (server *unaryOnlyAnonymizeServer) GetAnonymizedID(
+	ctx context.Context,
+	request *pb.SendNickname,
+) (*pb.ReceiveID, error) {
+	server.nRequests++
+	anonymizedID, _ := server.anonymizer.AnonymizeToon(request.Nickname)
+	return &pb.ReceiveID{AnonymizedID: anonymizedID}, nil
+}
+
+// TestGRPCAnonymizerBatches verifies that the toons are resolved in batches
+// in the order of the toons, that the cache persists between the runs
+// and that the servers without the batches receive a request per toon.
+func TestGRPCAnonymizerBatches(t *testing.T) {
+
+	testDirectory := t.TempDir()
+	toons := []string{"2-S2-1-333", "2-S2-1-111", "2-S2-1-333", "2-S2-1-222"}
+
+	testCases := []struct {
+		name             string
+		batchSupported   bool
+		expectedRequests int
+	}{
+		{name: "batch server", batchSupported: true, expectedRequests: 0},
+		{name: "unary server", batchSupported: false, expectedRequests: 3},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			localDBAnonymizer, err := NewLocalDBAnonymizer(
+				filepath.Join(testDirectory, testCase.name+".jsonl"),
+			)
+			if err != nil {
+				t.Fatalf("Test Failed! Unexpected error: %v", err)
+			}
+			defer localDBAnonymizer.Close()
+
+			var grpcServer *grpc.Server
+			unaryServer := &unaryOnlyAnonymizeServer{anonymizer: localDBAnonymizer}
+			if testCase.batchSupported {
+				grpcServer = NewAnonymizeGRPCServer(localDBAnonymizer, nil)
+			} else {
+				grpcServer = grpc.NewServer()
+				pb.RegisterAnonymizeServiceServer(grpcServer, unaryServer)
+			}
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Test Failed! Cannot listen: %v", err)
+			}
+			go grpcServer.Serve(listener)
+			defer grpcServer.Stop()
+
+			cachePath := filepath.Join(testDirectory, testCase.name+"_cache.jsonl")
+			grpcAnonymizer, err := newGRPCAnonymizer(GRPCAnonymizerOptions{
+				Address:   listener.Addr().String(),
+				CachePath: cachePath,
+			})
+			if err != nil {
+				t.Fatalf("Test Failed! Unexpected error: %v", err)
+			}
+			if !grpcAnonymizer.AnonymizeToons(toons) {
+				t.Fatalf("Test Failed! Failed to resolve the toons")
+			}
+			grpcAnonymizer.Close()
+			if unaryServer.nRequests != testCase.expectedRequests {
+				t.Errorf(
+					"Test Failed! Expected %d unary requests, got %d",
+					testCase.expectedRequests,
+					unaryServer.nRequests,
+				)
+			}
+
+			// The cached IDs are used without contacting the stopped server:
+			grpcServer.Stop()
+			cachedAnonymizer, err := newGRPCAnonymizer(GRPCAnonymizerOptions{
+				Address:   listener.Addr().String(),
+				CachePath: cachePath,
+			})
+			if err != nil {
+				t.Fatalf("Test Failed! Unexpected error: %v", err)
+			}
+			defer cachedAnonymizer.Close()
+			for toon, expectedID := range map[string]string{
+				"2-S2-1-333": "1",
+				"2-S2-1-111": "2",
+				"2-S2-1-222": "3",
+			} {
+				anonymizedID, ok := cachedAnonymizer.AnonymizeToon(toon)
+				if !ok || anonymizedID != expectedID {
+					t.Errorf("Test Failed! Expected %q for %s, got %q", expectedID, toon, anonymizedID)
+				}
+			}
+		})
+	}
+}
+
+// flakyAnonymizeServer fails the first nFailures requests with the failureCode.
+type flakyAnonymizeServer struct {
+	pb.UnimplementedAnonymizeServiceServer
+	failureCode codes.Code
+	nFailures   int
+	nRequests   int
+}
+
+// GetAnonymizedID returns the ID "1" once the failures are exhausted.
+func (server *flakyAnonymizeServer) GetAnonymizedID(
+	ctx context.Context,
+	request *pb.SendNickname,
+) (*pb.ReceiveID, error) {
+	server.nRequests++
+	if server.nRequests <= server.nFailures {
+		return nil, status.Error(server.failureCode, "failure")
+	}
+	return &pb.ReceiveID{AnonymizedID: "1"}, nil
+}
+
+// TestGRPCAnonymizerRetries verifies that only the transient errors are retried
+// and that a toon that cannot be anonymized fails without stopping the process.
+func TestGRPCAnonymizerRetries(t *testing.T) {
+
+	testCases := []struct {
+		name             string
+		failureCode      codes.Code
+		nFailures        int
+		expectedOk       bool
+		expectedRequests int
+	}{
+		{name: "transient failures", failureCode: codes.Unavailable, nFailures: 2, expectedOk: true, expectedRequests: 3},
+		{name: "permanent failure", failureCode: codes.InvalidArgument, nFailures: 1, expectedOk: false, expectedRequests: 1},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			flakyServer := &flakyAnonymizeServer{
+				failureCode: testCase.failureCode,
+				nFailures:   testCase.nFailures,
+			}
+			grpcServer := grpc.NewServer()
+			pb.RegisterAnonymizeServiceServer(grpcServer, flakyServer)
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Test Failed! Cannot listen: %v", err)
+			}
+			go grpcServer.Serve(listener)
+			defer grpcServer.Stop()
+
+			grpcAnonymizer, err := newGRPCAnonymizer(GRPCAnonymizerOptions{
+				Address: listener.Addr().String(),
+			})
+			if err != nil {
+				t.Fatalf("Test Failed! Unexpected error: %v", err)
+			}
+			defer grpcAnonymizer.Close()
+
+			_, ok := grpcAnonymizer.AnonymizeToon("2-S2-1-111")
+			if ok != testCase.expectedOk {
+				t.Errorf("Test Failed! Expected %v, got %v", testCase.expectedOk, ok)
+			}
+			if flakyServer.nRequests != testCase.expectedRequests {
+				t.Errorf(
+					"Test Failed! Expected %d requests, got %d",
+					testCase.expectedRequests,
+					flakyServer.nRequests,
+				)
+			}
+		})
+	}
+}
+
+// blockingAnonymizeServer holds the requests of the slowToon until release is closed.
+type blockingAnonymizeServer struct {
+	pb.UnimplementedAnonymizeServiceServer
+	slowToon  string
+	release   chan struct{}
+	mutex     sync.Mutex
+	nRequests map[string]int
+}
+
+// GetAnonymizedID returns the toon sent as the nickname as its ID.
+func (server *blockingAnonymizeServer) GetAnonymizedID(
+	ctx context.Context,
+	request *pb.SendNickname,
+) (*pb.ReceiveID, error) {
+	server.mutex.Lock()
+	server.nRequests[request.Nickname]++
+	server.mutex.Unlock()
+	if request.Nickname == server.slowToon {
+		<-server.release
+	}
+	return &pb.ReceiveID{AnonymizedID: "id-" + request.Nickname}, nil
+}
+
+// TestGRPCAnonymizerPendingRequests verifies that a slow request does not block
+// the workers requesting other toons and that the workers requesting
+// the same toon wait for a single request.
+func TestGRPCAnonymizerPendingRequests(t *testing.T) {
+
+	blockingServer := &blockingAnonymizeServer{
+		slowToon:  "2-S2-1-111",
+		release:   make(chan struct{}),
+		nRequests: make(map[string]int),
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterAnonymizeServiceServer(grpcServer, blockingServer)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot listen: %v", err)
+	}
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	grpcAnonymizer, err := newGRPCAnonymizer(GRPCAnonymizerOptions{
+		Address: listener.Addr().String(),
+	})
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	defer grpcAnonymizer.Close()
+
+	var waitGroup sync.WaitGroup
+	slowIDs := make([]string, 3)
+	for i := range slowIDs {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			slowIDs[i], _ = grpcAnonymizer.AnonymizeToon(blockingServer.slowToon)
+		}()
+	}
+
+	fastDone := make(chan string)
+	go func() {
+		anonymizedID, _ := grpcAnonymizer.AnonymizeToon("2-S2-1-222")
+		fastDone <- anonymizedID
+	}()
+	select {
+	case anonymizedID := <-fastDone:
+		if anonymizedID != "id-2-S2-1-222" {
+			t.Errorf("Test Failed! Unexpected ID %q", anonymizedID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Test Failed! Request of another toon was blocked by the slow request")
+	}
+
+	close(blockingServer.release)
+	waitGroup.Wait()
+	for _, anonymizedID := range slowIDs {
+		if anonymizedID != "id-2-S2-1-111" {
+			t.Errorf("Test Failed! Unexpected IDs of the slow toon %v", slowIDs)
+			break
+		}
+	}
+	if blockingServer.nRequests[blockingServer.slowToon] != 1 {
+		t.Errorf(
+			"Test Failed! Expected a single request of the slow toon, got %d",
+			blockingServer.nRequests[blockingServer.slowToon],
+		)
+	}
+}
+
+// writeTestCertificate writes the PEM encoded certificate and key signed by the parent,
+// the certificate is self-signed if the parent is nil. Returns the certificate and the key.
+func writeTestCertificate(
+	t *testing.T,
+	directory string,
+	name string,
+	template *x509.Certificate,
+	parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot generate the key: %v", err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	certificateBytes, err := x509.CreateCertificate(
+		rand.Reader,
+		template,
+		parent,
+		&key.PublicKey,
+		parentKey,
+	)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot create the certificate: %v", err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot marshal the key: %v", err)
+	}
+
+	files := map[string]*pem.Block{
+		name + ".pem":     {Type: "CERTIFICATE", Bytes: certificateBytes},
+		name + "_key.pem": {Type: "EC PRIVATE KEY", Bytes: keyBytes},
+	}
+	for fileName, block := range files {
+		err = os.WriteFile(filepath.Join(directory, fileName), pem.EncodeToMemory(block), 0600)
+		if err != nil {
+			t.Fatalf("Test Failed! Cannot write %s: %v", fileName, err)
+		}
+	}
+
+	certificate, err := x509.ParseCertificate(certificateBytes)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot parse the certificate: %v", err)
+	}
+	return certificate, key
+}
+
+// TestAnonymizeServerMTLS verifies that the server requiring client certificates
+// accepts only the clients presenting a certificate signed by its certificate authority.
+func TestAnonymizeServerMTLS(t *testing.T) {
+
+	testDirectory := t.TempDir()
+	notAfter := time.Now().Add(time.Hour)
+	caCertificate, caKey := writeTestCertificate(t, testDirectory, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	writeTestCertificate(t, testDirectory, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotAfter:     notAfter,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caCertificate, caKey)
+	writeTestCertificate(t, testDirectory, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCertificate, caKey)
+	testFile := func(name string) string { return filepath.Join(testDirectory, name) }
+
+	localDBAnonymizer, err := NewLocalDBAnonymizer(testFile("anonymized_toons.jsonl"))
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	defer localDBAnonymizer.Close()
+	tlsConfig, err := utils.ServerTLSConfig(
+		testFile("server.pem"),
+		testFile("server_key.pem"),
+		testFile("ca.pem"),
+	)
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	grpcServer := NewAnonymizeGRPCServer(localDBAnonymizer, tlsConfig)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot listen: %v", err)
+	}
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	testCases := []struct {
+		name       string
+		options    GRPCAnonymizerOptions
+		expectedOk bool
+	}{
+		{
+			name: "client certificate",
+			options: GRPCAnonymizerOptions{
+				TLS:         true,
+				TLSCAFile:   testFile("ca.pem"),
+				TLSCertFile: testFile("client.pem"),
+				TLSKeyFile:  testFile("client_key.pem"),
+			},
+			expectedOk: true,
+		},
+		{
+			name: "no client certificate",
+			options: GRPCAnonymizerOptions{
+				TLS:       true,
+				TLSCAFile: testFile("ca.pem"),
+			},
+			expectedOk: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.options.Address = listener.Addr().String()
+			grpcAnonymizer, err := newGRPCAnonymizer(testCase.options)
+			if err != nil {
+				t.Fatalf("Test Failed! Unexpected error: %v", err)
+			}
+			defer grpcAnonymizer.Close()
+
+			_, ok := grpcAnonymizer.AnonymizeToon("2-S2-1-111")
+			if ok != testCase.expectedOk {
+				t.Errorf("Test Failed! Expected %v, got %v", testCase.expectedOk, ok)
+			}
+		})
+	}
+}
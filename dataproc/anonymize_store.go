@@ -0,0 +1,197 @@
+package dataproc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// anonymizedIDStoreEntry is a single line of the file backing the anonymizedIDStore.
+type anonymizedIDStoreEntry struct {
+	Toon         string `json:"toon"`
+	AnonymizedID string `json:"anonymizedID"`
+}
+
+// anonymizedIDStore maps the toons to their anonymized IDs. If it is backed
+// by a JSON lines file, every new ID is appended and synced before it is used,
+// so that the IDs persist between the runs. The file is locked exclusively
+// while it is open, so it can be used by a single process at a time.
+// The store is not safe for concurrent use, its owner is responsible for the locking.
+type anonymizedIDStore struct {
+	file          *os.File
+	anonymizedIDs map[string]string
+	// maxID is the highest of the integer IDs that were stored:
+	maxID int
+}
+
+// openAnonymizedIDStore opens and locks the file backing the store, creating it
+// if it does not exist, and loads the IDs that were stored in the previous runs.
+// A torn last line, left by a run that was interrupted while saving an ID, is removed.
+// An empty path creates a store that is kept only in memory.
+func openAnonymizedIDStore(storePath string) (*anonymizedIDStore, error) {
+
+	log.Debug("Entered openAnonymizedIDStore()")
+
+	store := &anonymizedIDStore{anonymizedIDs: make(map[string]string)}
+	if storePath == "" {
+		return store, nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(storePath), 0777)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to create the anonymized IDs directory!")
+		return nil, err
+	}
+	store.file, err = os.OpenFile(storePath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to open the anonymized IDs file!")
+		return nil, err
+	}
+	err = file_utils.LockFile(store.file)
+	if err != nil {
+		store.file.Close()
+		return nil, fmt.Errorf(
+			"the anonymized IDs file %s is used by another process: %v",
+			storePath,
+			err,
+		)
+	}
+
+	err = store.load(storePath)
+	if err != nil {
+		store.file.Close()
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"storePath": storePath,
+		"nToons":    len(store.anonymizedIDs),
+	}).Info("Loaded the anonymized IDs.")
+	log.Debug("Finished openAnonymizedIDStore()")
+	return store, nil
+}
+
+// load reads the IDs from the file backing the store. If a toon is stored
+// more than once the first of its IDs is kept, as it was the one that was used.
+func (store *anonymizedIDStore) load(storePath string) error {
+
+	fileBytes, err := io.ReadAll(store.file)
+	if err != nil {
+		return fmt.Errorf("failed to read the anonymized IDs file %s: %v", storePath, err)
+	}
+
+	lineStart := 0
+	for lineNumber := 1; lineStart < len(fileBytes); lineNumber++ {
+		lineEnd := bytes.IndexByte(fileBytes[lineStart:], '\n')
+		isLastLine := lineEnd < 0
+		if isLastLine {
+			lineEnd = len(fileBytes)
+		} else {
+			lineEnd += lineStart
+		}
+		line := fileBytes[lineStart:lineEnd]
+
+		entry := anonymizedIDStoreEntry{}
+		var lineErr error
+		if len(bytes.TrimSpace(line)) > 0 {
+			lineErr = json.Unmarshal(line, &entry)
+		}
+		switch {
+		case lineErr != nil && isLastLine:
+			log.WithFields(log.Fields{
+				"storePath":  storePath,
+				"lineNumber": lineNumber,
+			}).Warn("Removing the torn last line of the anonymized IDs file.")
+			return store.file.Truncate(int64(lineStart))
+		case lineErr != nil:
+			return fmt.Errorf(
+				"corrupted line %d of the anonymized IDs file %s: %v",
+				lineNumber,
+				storePath,
+				lineErr,
+			)
+		case isLastLine:
+			// Following entries have to start on a new line:
+			_, err = store.file.Write([]byte{'\n'})
+			if err != nil {
+				return err
+			}
+		}
+
+		if entry.Toon != "" {
+			if anonymizedID, ok := store.anonymizedIDs[entry.Toon]; ok {
+				log.WithFields(log.Fields{
+					"storePath":    storePath,
+					"lineNumber":   lineNumber,
+					"anonymizedID": anonymizedID,
+				}).Warn("Toon is stored more than once, keeping its first ID.")
+			} else {
+				store.anonymizedIDs[entry.Toon] = entry.AnonymizedID
+			}
+			store.updateMaxID(entry.AnonymizedID)
+		}
+		lineStart = lineEnd + 1
+	}
+
+	return nil
+}
+
+// updateMaxID keeps track of the highest of the integer IDs,
+// other IDs, such as the ones received from a server, are skipped.
+func (store *anonymizedIDStore) updateMaxID(anonymizedID string) {
+	if id, err := strconv.Atoi(anonymizedID); err == nil && id > store.maxID {
+		store.maxID = id
+	}
+}
+
+// get returns the anonymized ID of the toon if it is stored.
+func (store *anonymizedIDStore) get(toonString string) (string, bool) {
+	anonymizedID, ok := store.anonymizedIDs[toonString]
+	return anonymizedID, ok
+}
+
+// put stores the anonymized ID of the toon, saving it to the file first.
+func (store *anonymizedIDStore) put(toonString string, anonymizedID string) error {
+
+	if store.file != nil {
+		entryBytes, err := json.Marshal(anonymizedIDStoreEntry{
+			Toon:         toonString,
+			AnonymizedID: anonymizedID,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = store.file.Write(append(entryBytes, '\n'))
+		if err != nil {
+			return err
+		}
+		err = store.file.Sync()
+		if err != nil {
+			return err
+		}
+	}
+	store.anonymizedIDs[toonString] = anonymizedID
+	store.updateMaxID(anonymizedID)
+
+	return nil
+}
+
+// nextID returns the integer ID following the highest stored one.
+func (store *anonymizedIDStore) nextID() string {
+	return strconv.Itoa(store.maxID + 1)
+}
+
+// close closes the file backing the store.
+func (store *anonymizedIDStore) close() error {
+	if store.file == nil {
+		return nil
+	}
+	return store.file.Close()
+}
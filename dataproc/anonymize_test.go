@@ -0,0 +1,168 @@
+package dataproc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// TestHMACAnonymizer verifies that the IDs depend only on the key and the toon.
+func TestHMACAnonymizer(t *testing.T) {
+
+	testDirectory := t.TempDir()
+	keyFiles := map[string]string{
+		"key":       "secret\n",
+		"same key":  "  secret ",
+		"other key": "other secret",
+	}
+	anonymizers := map[string]*HMACAnonymizer{}
+	for name, key := range keyFiles {
+		keyFile := filepath.Join(testDirectory, name)
+		err := os.WriteFile(keyFile, []byte(key), 0644)
+		if err != nil {
+			t.Fatalf("Test Failed! Cannot write the key file: %v", err)
+		}
+		anonymizers[name], err = NewHMACAnonymizer(keyFile)
+		if err != nil {
+			t.Fatalf("Test Failed! Unexpected error: %v", err)
+		}
+	}
+
+	toon := "2-S2-1-1234567"
+	anonymizedID, _ := anonymizers["key"].AnonymizeToon(toon)
+	if len(anonymizedID) != 2*hmacAnonymizedIDBytes {
+		t.Errorf("Test Failed! Unexpected length of the ID %q", anonymizedID)
+	}
+	if sameKeyID, _ := anonymizers["same key"].AnonymizeToon(toon); sameKeyID != anonymizedID {
+		t.Errorf("Test Failed! Expected %q for the same key, got %q", anonymizedID, sameKeyID)
+	}
+	if otherKeyID, _ := anonymizers["other key"].AnonymizeToon(toon); otherKeyID == anonymizedID {
+		t.Errorf("Test Failed! Expected a different ID for a different key, got %q", otherKeyID)
+	}
+	if otherToonID, _ := anonymizers["key"].AnonymizeToon("2-S2-1-7654321"); otherToonID == anonymizedID {
+		t.Errorf("Test Failed! Expected a different ID for a different toon, got %q", otherToonID)
+	}
+
+	emptyKeyFile := filepath.Join(testDirectory, "empty")
+	err := os.WriteFile(emptyKeyFile, []byte(" \n"), 0644)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot write the key file: %v", err)
+	}
+	if _, err = NewHMACAnonymizer(emptyKeyFile); err == nil {
+		t.Errorf("Test Failed! Expected an error for an empty key")
+	}
+}
+
+// TestLocalDBAnonymizer verifies that the concurrent workers receive
+// unique incrementing IDs and that the IDs persist between the runs.
+func TestLocalDBAnonymizer(t *testing.T) {
+
+	databasePath := filepath.Join(t.TempDir(), "anonymizer", "anonymized_toons.jsonl")
+	anonymizer, err := NewLocalDBAnonymizer(databasePath)
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+
+	nToons := 50
+	nWorkers := 4
+	workerIDs := make([][]string, nWorkers)
+	var wg sync.WaitGroup
+	for worker := 0; worker < nWorkers; worker++ {
+		workerIDs[worker] = make([]string, nToons)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < nToons; i++ {
+				anonymizedID, ok := anonymizer.AnonymizeToon(fmt.Sprintf("2-S2-1-%d", i))
+				if !ok {
+					t.Errorf("Test Failed! Failed to anonymize the toon %d", i)
+					return
+				}
+				workerIDs[worker][i] = anonymizedID
+			}
+		}()
+	}
+	wg.Wait()
+	err = anonymizer.Close()
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot close the database: %v", err)
+	}
+
+	anonymizedIDs := workerIDs[0]
+	for worker := 1; worker < nWorkers; worker++ {
+		if !slices.Equal(workerIDs[worker], anonymizedIDs) {
+			t.Errorf("Test Failed! Workers received different IDs for the same toons")
+		}
+	}
+
+	uniqueIDs := map[string]bool{}
+	for _, anonymizedID := range anonymizedIDs {
+		uniqueIDs[anonymizedID] = true
+	}
+	if len(uniqueIDs) != nToons {
+		t.Errorf("Test Failed! Expected %d unique IDs, got %d", nToons, len(uniqueIDs))
+	}
+
+	reopenedAnonymizer, err := NewLocalDBAnonymizer(databasePath)
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	defer reopenedAnonymizer.Close()
+	for i, expectedID := range anonymizedIDs {
+		anonymizedID, _ := reopenedAnonymizer.AnonymizeToon(fmt.Sprintf("2-S2-1-%d", i))
+		if anonymizedID != expectedID {
+			t.Errorf("Test Failed! Expected the stored ID %q, got %q", expectedID, anonymizedID)
+		}
+	}
+	newID, _ := reopenedAnonymizer.AnonymizeToon("2-S2-1-new")
+	if newID != fmt.Sprint(nToons+1) {
+		t.Errorf("Test Failed! Expected the next ID %d, got %q", nToons+1, newID)
+	}
+}
+
+// TestLocalDBAnonymizerRecovery verifies that the next ID follows the highest stored ID,
+// that the torn last line is removed and that the database is locked while it is open.
+func TestLocalDBAnonymizerRecovery(t *testing.T) {
+
+	databasePath := filepath.Join(t.TempDir(), "anonymized_toons.jsonl")
+	databaseContents := `{"toon":"2-S2-1-1","anonymizedID":"1"}
+{"toon":"2-S2-1-2","anonymizedID":"3"}
+{"toon":"2-S2-1-1","anonymizedID":"2"}
+{"toon":"2-S2-1-3","anonym`
+	err := os.WriteFile(databasePath, []byte(databaseContents), 0644)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot write the database: %v", err)
+	}
+
+	anonymizer, err := NewLocalDBAnonymizer(databasePath)
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	if _, err = NewLocalDBAnonymizer(databasePath); err == nil {
+		t.Errorf("Test Failed! Expected an error for a database used by another anonymizer")
+	}
+
+	expectedIDs := map[string]string{"2-S2-1-1": "1", "2-S2-1-2": "3", "2-S2-1-3": "4"}
+	for toon, expectedID := range expectedIDs {
+		anonymizedID, _ := anonymizer.AnonymizeToon(toon)
+		if anonymizedID != expectedID {
+			t.Errorf("Test Failed! Expected %q for %s, got %q", expectedID, toon, anonymizedID)
+		}
+	}
+	err = anonymizer.Close()
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot close the database: %v", err)
+	}
+
+	reopenedAnonymizer, err := NewLocalDBAnonymizer(databasePath)
+	if err != nil {
+		t.Fatalf("Test Failed! Database was not repaired: %v", err)
+	}
+	defer reopenedAnonymizer.Close()
+	if anonymizedID, _ := reopenedAnonymizer.AnonymizeToon("2-S2-1-3"); anonymizedID != "4" {
+		t.Errorf("Test Failed! Expected the stored ID 4, got %q", anonymizedID)
+	}
+}
@@ -1,6 +1,11 @@
 package dataproc
 
 import (
+	"fmt"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/icza/s2prot"
 	"github.com/icza/s2prot/rep"
 	log "github.com/sirupsen/logrus"
 )
@@ -8,7 +13,12 @@ import (
 // Integrity:
 // checkIntegrity verifies if the internal saved state of the replayData
 // matches against structures with redundant information.
-func checkIntegrity(replayData *rep.Rep) (bool, string) {
+// The deep integrity level additionally verifies the event streams.
+func checkIntegrity(
+	replayData *rep.Rep,
+	integrityLevel datastruct.IntegrityLevelEnum,
+	eventErrorsPolicy datastruct.EventErrorsPolicy,
+) (bool, string) {
 
 	log.Debug("Entered checkIntegrity()")
 	maxPlayers := replayData.InitData.GameDescription.MaxPlayers()
@@ -74,6 +84,186 @@ func checkIntegrity(replayData *rep.Rep) (bool, string) {
 		return false, "Two fields containing info if the map isBlizzardMap are different!"
 	}
 
+	eventErrorsOk, failureReason := checkEventErrors(replayData, eventErrorsPolicy)
+	if !eventErrorsOk {
+		return false, failureReason
+	}
+
+	if integrityLevel == datastruct.DeepIntegrityLevel {
+		eventStreamsOk, failureReason := checkEventStreams(replayData)
+		if !eventStreamsOk {
+			return false, failureReason
+		}
+	}
+
 	log.Info("Integrity checks passed! Returning from checkIntegrity()")
 	return true, ""
 }
+
+// checkEventErrors rejects the replay if s2prot reported errors
+// while decoding the event streams that are rejected by the policy.
+func checkEventErrors(
+	replayData *rep.Rep,
+	eventErrorsPolicy datastruct.EventErrorsPolicy,
+) (bool, string) {
+
+	eventErrors := []struct {
+		eventStream string
+		hasErrors   bool
+		reject      bool
+	}{
+		{"game", replayData.GameEvtsErr, eventErrorsPolicy.RejectGameEvtsErr},
+		{"message", replayData.MessageEvtsErr, eventErrorsPolicy.RejectMessageEvtsErr},
+		{"tracker", replayData.TrackerEvtsErr, eventErrorsPolicy.RejectTrackerEvtsErr},
+	}
+	for _, eventError := range eventErrors {
+		if !eventError.hasErrors {
+			continue
+		}
+		if !eventError.reject {
+			log.WithField("eventStream", eventError.eventStream).
+				Warn("Decoding errors of the event stream were accepted.")
+			continue
+		}
+		log.WithField("eventStream", eventError.eventStream).
+			Error("Integrity check failed! Detected decoding errors of the event stream!")
+		return false, fmt.Sprintf("Decoding errors in the %s events!", eventError.eventStream)
+	}
+
+	return true, ""
+}
+
+// checkEventStreams verifies that the event streams are not truncated
+// or inconsistent: the loops of the events do not decrease and fit within
+// the length of the game, every player has PlayerStats events
+// at the expected cadence and the unit tags are not reused before the units die.
+func checkEventStreams(replayData *rep.Rep) (bool, string) {
+
+	log.Debug("Entered checkEventStreams()")
+
+	if replayData.TrackerEvts == nil {
+		log.Error("Integrity check failed! Tracker events are not available!")
+		return false, "Tracker events are not available!"
+	}
+	elapsedGameLoops := replayData.Header.Loops()
+
+	eventStreams := []struct {
+		eventStream   string
+		events        []s2prot.Event
+		checkLastLoop bool
+	}{
+		{"game", replayData.GameEvts, true},
+		{"message", replayData.MessageEvts, false},
+		{"tracker", replayData.TrackerEvts.Evts, true},
+	}
+	for _, eventStream := range eventStreams {
+		var previousLoop int64
+		for _, event := range eventStream.events {
+			loop := event.Loop()
+			if loop < previousLoop {
+				log.WithFields(log.Fields{
+					"eventStream":  eventStream.eventStream,
+					"loop":         loop,
+					"previousLoop": previousLoop}).
+					Error("Integrity check failed! Loops of the events decrease!")
+				return false, fmt.Sprintf("Loops of the %s events decrease!", eventStream.eventStream)
+			}
+			previousLoop = loop
+		}
+		if eventStream.checkLastLoop && previousLoop > elapsedGameLoops {
+			log.WithFields(log.Fields{
+				"eventStream":      eventStream.eventStream,
+				"lastLoop":         previousLoop,
+				"elapsedGameLoops": elapsedGameLoops}).
+				Error("Integrity check failed! Events are later than the end of the game!")
+			return false, fmt.Sprintf(
+				"Last %s event is later than the end of the game!",
+				eventStream.eventStream,
+			)
+		}
+	}
+
+	playerStatsOk, failureReason := checkPlayerStatsCadence(replayData)
+	if !playerStatsOk {
+		return false, failureReason
+	}
+
+	unitTagsOk, failureReason := checkUnitTags(replayData.TrackerEvts.Evts)
+	if !unitTagsOk {
+		return false, failureReason
+	}
+
+	log.Debug("Finished checkEventStreams()")
+	return true, ""
+}
+
+// checkPlayerStatsCadence verifies that every player has PlayerStats events
+// and that they are not further apart than settings.PlayerStatsIntervalLoops.
+// The events of the players that left the game stop early, so the last
+// event of the player is not compared with the end of the game.
+func checkPlayerStatsCadence(replayData *rep.Rep) (bool, string) {
+
+	if replayData.Header.Loops() < settings.PlayerStatsIntervalLoops {
+		return true, ""
+	}
+
+	previousLoops := make(map[int64]int64)
+	for playerID := range replayData.TrackerEvts.PIDPlayerDescMap {
+		previousLoops[playerID] = -1
+	}
+
+	for _, event := range replayData.TrackerEvts.Evts {
+		if event.Name != "PlayerStats" {
+			continue
+		}
+		playerID := event.Int("playerId")
+		previousLoop, ok := previousLoops[playerID]
+		if !ok {
+			continue
+		}
+		if event.Loop()-max(previousLoop, 0) > settings.PlayerStatsIntervalLoops {
+			log.WithFields(log.Fields{
+				"playerID":     playerID,
+				"loop":         event.Loop(),
+				"previousLoop": previousLoop}).
+				Error("Integrity check failed! PlayerStats events are missing!")
+			return false, "PlayerStats events are missing!"
+		}
+		previousLoops[playerID] = event.Loop()
+	}
+
+	for playerID, previousLoop := range previousLoops {
+		if previousLoop < 0 {
+			log.WithField("playerID", playerID).
+				Error("Integrity check failed! Player does not have any PlayerStats events!")
+			return false, "PlayerStats events are missing!"
+		}
+	}
+
+	return true, ""
+}
+
+// checkUnitTags verifies that the units are not born with the tags
+// of the units that are still alive.
+func checkUnitTags(trackerEvents []s2prot.Event) (bool, string) {
+
+	aliveUnitTags := make(map[int64]bool)
+	for _, event := range trackerEvents {
+		unitTag := event.Int("unitTagIndex")<<18 + event.Int("unitTagRecycle")
+		switch event.Name {
+		case "UnitBorn", "UnitInit":
+			if aliveUnitTags[unitTag] {
+				log.WithFields(log.Fields{
+					"unitTag": unitTag,
+					"loop":    event.Loop()}).
+					Error("Integrity check failed! Unit tag was reused before the unit died!")
+				return false, "Unit tag reused before the unit died!"
+			}
+			aliveUnitTags[unitTag] = true
+		case "UnitDied":
+			delete(aliveUnitTags, unitTag)
+		}
+	}
+
+	return true, ""
+}
@@ -0,0 +1,156 @@
+package dataproc
+
+import (
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/icza/s2prot"
+	"github.com/icza/s2prot/rep"
+)
+
+// integrityTestEvent creates an event of the type at the loop.
+func integrityTestEvent(name string, loop int64, fields s2prot.Struct) s2prot.Event {
+
+	event := s2prot.Event{
+		Struct:  s2prot.Struct{"loop": loop},
+		EvtType: &s2prot.EvtType{Name: name},
+	}
+	for key, value := range fields {
+		event.Struct[key] = value
+	}
+	return event
+}
+
+// integrityTestReplay creates a replay of two players that lasts 400 loops
+// holding the game and tracker events.
+func integrityTestReplay(gameEvents []s2prot.Event, trackerEvents []s2prot.Event) *rep.Rep {
+	return &rep.Rep{
+		Header:   rep.Header{Struct: s2prot.Struct{"elapsedGameLoops": int64(400)}},
+		GameEvts: gameEvents,
+		TrackerEvts: &rep.TrackerEvts{
+			Evts: trackerEvents,
+			PIDPlayerDescMap: map[int64]*rep.PlayerDesc{
+				1: {PlayerID: 1},
+				2: {PlayerID: 2},
+			},
+		},
+	}
+}
+
+// TestCheckEventStreams verifies that the deep integrity checks detect
+// truncated and inconsistent event streams.
+func TestCheckEventStreams(t *testing.T) {
+
+	playerStats := func(loop int64, playerID int64) s2prot.Event {
+		return integrityTestEvent("PlayerStats", loop, s2prot.Struct{"playerId": playerID})
+	}
+	unitEvent := func(name string, loop int64, tagIndex int64) s2prot.Event {
+		return integrityTestEvent(name, loop, s2prot.Struct{
+			"unitTagIndex":   tagIndex,
+			"unitTagRecycle": int64(1),
+		})
+	}
+	validTrackerEvents := func() []s2prot.Event {
+		return []s2prot.Event{
+			unitEvent("UnitBorn", 0, 1),
+			playerStats(1, 1),
+			playerStats(1, 2),
+			playerStats(160, 1),
+			playerStats(160, 2),
+			unitEvent("UnitDied", 200, 1),
+			unitEvent("UnitBorn", 210, 1),
+			playerStats(320, 1),
+			playerStats(320, 2),
+			playerStats(400, 1),
+		}
+	}
+	gameEvents := []s2prot.Event{
+		integrityTestEvent("CameraUpdate", 10, nil),
+		integrityTestEvent("Cmd", 390, nil),
+	}
+
+	testCases := []struct {
+		name          string
+		gameEvents    []s2prot.Event
+		trackerEvents []s2prot.Event
+		expectedOk    bool
+	}{
+		{
+			name:          "consistent events",
+			gameEvents:    gameEvents,
+			trackerEvents: validTrackerEvents(),
+			expectedOk:    true,
+		},
+		{
+			name: "decreasing loops",
+			gameEvents: []s2prot.Event{
+				integrityTestEvent("Cmd", 390, nil),
+				integrityTestEvent("CameraUpdate", 10, nil),
+			},
+			trackerEvents: validTrackerEvents(),
+			expectedOk:    false,
+		},
+		{
+			name: "events after the end of the game",
+			gameEvents: append(
+				gameEvents,
+				integrityTestEvent("Cmd", 480, nil),
+			),
+			trackerEvents: validTrackerEvents(),
+			expectedOk:    false,
+		},
+		{
+			name:       "missing PlayerStats",
+			gameEvents: gameEvents,
+			trackerEvents: []s2prot.Event{
+				playerStats(1, 1),
+				playerStats(1, 2),
+				playerStats(160, 1),
+				playerStats(320, 1),
+				playerStats(320, 2),
+			},
+			expectedOk: false,
+		},
+		{
+			name:       "unit tag reused before death",
+			gameEvents: gameEvents,
+			trackerEvents: append(
+				validTrackerEvents(),
+				unitEvent("UnitInit", 400, 1),
+			),
+			expectedOk: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			replayData := integrityTestReplay(testCase.gameEvents, testCase.trackerEvents)
+			ok, failureReason := checkEventStreams(replayData)
+			if ok != testCase.expectedOk {
+				t.Errorf(
+					"Test Failed! Expected %v, got %v with reason %q",
+					testCase.expectedOk,
+					ok,
+					failureReason,
+				)
+			}
+		})
+	}
+}
+
+// TestCheckEventErrors verifies that only the decoding errors
+// of the streams rejected by the policy reject the replay.
+func TestCheckEventErrors(t *testing.T) {
+
+	replayData := &rep.Rep{GameEvtsErr: true}
+
+	ok, _ := checkEventErrors(replayData, datastruct.EventErrorsPolicy{RejectTrackerEvtsErr: true})
+	if !ok {
+		t.Errorf("Test Failed! Errors of the game events should be accepted.")
+	}
+
+	ok, _ = checkEventErrors(replayData, datastruct.EventErrorsPolicy{RejectGameEvtsErr: true})
+	if ok {
+		t.Errorf("Test Failed! Errors of the game events should be rejected.")
+	}
+}
@@ -1,7 +1,12 @@
 package dataproc
 
 import (
+	"fmt"
 	"math"
+	"slices"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
 
 	"github.com/icza/s2prot/rep"
 	log "github.com/sirupsen/logrus"
@@ -54,48 +59,260 @@ type VerifyGameInfo struct {
 	isCompetitiveOrRanked bool
 }
 
+// gameModeNames maps the names of the game modes used by the validity rules
+// (settings.ValidityGameModes) to the game modes.
+var gameModeNames = map[string]int{
+	"ranked_1v1": Ranked1v1,
+	"ranked_2v2": Ranked2v2,
+	"ranked_3v3": Ranked3v3,
+	"ranked_4v4": Ranked4v4,
+	"custom_1v1": Custom1v1,
+	"custom_2v2": Custom2v2,
+	"custom_3v3": Custom3v3,
+	"custom_4v4": Custom4v4,
+}
+
+// validityViolation describes a validity rule that the replay did not satisfy.
+type validityViolation struct {
+	rule    string
+	message string
+}
+
 // Validity:
-// validateReplay performs programmatically hardcoded checks
+// validateReplay checks the replay against the validity rules of its game mode
 // in order to verify if the file is within "common sense" values.
-func validate1v1Replay(replayData *rep.Rep) bool {
+// Every rule that the replay violates is returned,
+// replays of the game modes without rules are not validated.
+func validateReplay(
+	replayData *rep.Rep,
+	validityRules datastruct.ValidityRules,
+) []validityViolation {
+
+	log.Debug("Entered validateReplay()")
+
+	gameMode, ok := replayGameMode(replayData)
+	if !ok {
+		log.Debug("Finished validateReplay(), game mode has no validity rules")
+		return nil
+	}
+	gameModeRules, ok := validityRules.GameModes[gameMode]
+	if !ok {
+		log.WithField("gameMode", gameMode).
+			Debug("Finished validateReplay(), game mode has no validity rules")
+		return nil
+	}
+
+	violations := checkValidityRules(replayData, gameModeRules)
+	for _, violation := range violations {
+		log.WithFields(log.Fields{
+			"gameMode":  gameMode,
+			"rule":      violation.rule,
+			"violation": violation.message}).
+			Error("Data validation failed! Replay violates a validity rule.")
+	}
+
+	log.Debug("Finished validateReplay()")
+	return violations
+}
+
+// replayGameMode returns the name of the game mode of the replay
+// and false if the replay does not match any of the game modes.
+func replayGameMode(replayData *rep.Rep) (string, bool) {
+
+	for _, gameMode := range settings.ValidityGameModes {
+		gameInfoFilter := gameModeFiltersMapping[gameModeNames[gameMode]]
+		if checkGameParameters(replayData, gameInfoFilter) {
+			return gameMode, true
+		}
+	}
+
+	return "", false
+}
+
+// checkValidityRules checks every enabled rule of the game mode against the replay.
+func checkValidityRules(
+	replayData *rep.Rep,
+	gameModeRules datastruct.GameModeValidityRules,
+) []validityViolation {
+
+	violations := []validityViolation{}
+	addViolation := func(rule string, format string, args ...interface{}) {
+		violations = append(violations, validityViolation{
+			rule:    rule,
+			message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Metadata players are identified by their position in the details:
+	detailsPlayers := replayData.Details.Players()
+	var mmrs, apms []float64
+	teamMMRs := make(map[int64][]float64)
+	for _, metadataPlayer := range replayData.Metadata.Players() {
+		mmrs = append(mmrs, metadataPlayer.MMR())
+		apms = append(apms, metadataPlayer.APM())
+
+		playerIndex := metadataPlayer.PlayerID() - 1
+		if playerIndex >= 0 && playerIndex < int64(len(detailsPlayers)) {
+			teamID := detailsPlayers[playerIndex].TeamID()
+			teamMMRs[teamID] = append(teamMMRs[teamID], metadataPlayer.MMR())
+		}
+	}
 
-	log.Debug("Entered validateData()")
-	playerList := replayData.Metadata.Players()
+	thresholdRules := []struct {
+		name      string
+		rule      datastruct.ThresholdRule
+		values    []float64
+		valueName string
+		isMinimum bool
+	}{
+		{"minMMR", gameModeRules.MinMMR, mmrs, "MMR", true},
+		{"maxMMR", gameModeRules.MaxMMR, mmrs, "MMR", false},
+		{"minAPM", gameModeRules.MinAPM, apms, "APM", true},
+		{"maxAPM", gameModeRules.MaxAPM, apms, "APM", false},
+	}
+	for _, thresholdRule := range thresholdRules {
+		if !thresholdRule.rule.Enabled {
+			continue
+		}
+		violatingValues := []float64{}
+		for _, value := range thresholdRule.values {
+			if thresholdRule.isMinimum && value < thresholdRule.rule.Value ||
+				!thresholdRule.isMinimum && value > thresholdRule.rule.Value {
+				violatingValues = append(violatingValues, value)
+			}
+		}
+		if len(violatingValues) > 0 {
+			addViolation(
+				thresholdRule.name,
+				"%s of the players %v is outside of the %s %v",
+				thresholdRule.valueName,
+				violatingValues,
+				thresholdRule.name,
+				thresholdRule.rule.Value,
+			)
+		}
+	}
 
-	if len(playerList) == 2 {
-		absoluteMMRDifference := math.Abs(playerList[0].MMR() - playerList[1].MMR())
-		// Around 1200 MMR:
-		if absoluteMMRDifference > 1200 {
-			log.Error("MMR Difference was found to be to big! validateData() failed, returning!")
-			return false
+	if gameModeRules.MaxMMRDifference.Enabled && len(teamMMRs) > 1 {
+		minTeamMMR, maxTeamMMR := math.Inf(1), math.Inf(-1)
+		for _, mmrs := range teamMMRs {
+			teamMMR := 0.0
+			for _, mmr := range mmrs {
+				teamMMR += mmr
+			}
+			teamMMR /= float64(len(mmrs))
+			minTeamMMR = math.Min(minTeamMMR, teamMMR)
+			maxTeamMMR = math.Max(maxTeamMMR, teamMMR)
+		}
+		if maxTeamMMR-minTeamMMR > gameModeRules.MaxMMRDifference.Value {
+			addViolation(
+				"maxMMRDifference",
+				"MMR difference of the teams %v is higher than %v",
+				maxTeamMMR-minTeamMMR,
+				gameModeRules.MaxMMRDifference.Value,
+			)
 		}
 	}
 
-	// In the history of StarCraft II there was no player that reached
-	// 8000 MMR so it should be below 8000 for all of the replays:
-	for _, playerStats := range playerList {
+	durationSeconds := float64(replayData.Header.Loops()) / gameLoopsPerSecond
+	if gameModeRules.MinDurationSeconds.Enabled &&
+		durationSeconds < gameModeRules.MinDurationSeconds.Value {
+		addViolation(
+			"minDurationSeconds",
+			"duration of the game %.1fs is shorter than %vs",
+			durationSeconds,
+			gameModeRules.MinDurationSeconds.Value,
+		)
+	}
+	if gameModeRules.MaxDurationSeconds.Enabled &&
+		durationSeconds > gameModeRules.MaxDurationSeconds.Value {
+		addViolation(
+			"maxDurationSeconds",
+			"duration of the game %.1fs is longer than %vs",
+			durationSeconds,
+			gameModeRules.MaxDurationSeconds.Value,
+		)
+	}
 
-		// Currently no player is 8000
-		if playerStats.MMR() > 8000 {
-			log.Error("Data validation failed! One of the players MMR is higher than 8000! Returning")
-			return false
+	// Every missing field is reported as a separate violation:
+	if gameModeRules.RequiredFields.Enabled {
+		for _, field := range gameModeRules.RequiredFields.Values {
+			if isFieldMissing(replayData, field) {
+				addViolation(
+					"requiredFields."+field,
+					"required field %s is missing",
+					field,
+				)
+			}
 		}
+	}
+
+	if gameModeRules.BlizzardMap.Enabled && !checkBlizzardMap(replayData) {
+		addViolation("blizzardMap", "the map is not an official Blizzard map")
+	}
 
-		if playerStats.APM() == 0 {
-			log.Error("Data validation failed! One of the players APM is equal to 0! Returning")
-			return false
+	if gameModeRules.AllowedMapAuthors.Enabled {
+		mapAuthor := replayData.InitData.GameDescription.MapAuthorName()
+		if !slices.Contains(gameModeRules.AllowedMapAuthors.Values, mapAuthor) {
+			addViolation(
+				"allowedMapAuthors",
+				"map author %q is not allowed",
+				mapAuthor,
+			)
 		}
 	}
 
-	// REVIEW: Should this be hidden behind a CLI flag?
-	isBlizzardMap := checkBlizzardMap(replayData)
-	if !isBlizzardMap {
-		log.Error("Data validation failed! checkBlizzardMap() returned false! Returning")
+	return violations
+}
+
+// isFieldMissing checks if one of the settings.ValidityRequiredFields
+// is empty or 0 in the replay, per player fields are missing
+// if they are missing for any of the players or if there are no players.
+func isFieldMissing(replayData *rep.Rep, field string) bool {
+
+	metadataPlayers := replayData.Metadata.Players()
+	detailsPlayers := replayData.Details.Players()
+
+	anyMetadataPlayer := func(isMissing func(player *rep.MetaPlayer) bool) bool {
+		if len(metadataPlayers) == 0 {
+			return true
+		}
+		for i := range metadataPlayers {
+			if isMissing(&metadataPlayers[i]) {
+				return true
+			}
+		}
 		return false
 	}
 
-	log.Debug("Finished validateData(), returning")
-	return true
+	switch field {
+	case "mmr":
+		return anyMetadataPlayer(func(player *rep.MetaPlayer) bool { return player.MMR() == 0 })
+	case "apm":
+		return anyMetadataPlayer(func(player *rep.MetaPlayer) bool { return player.APM() == 0 })
+	case "race":
+		return anyMetadataPlayer(func(player *rep.MetaPlayer) bool { return player.AssignedRace() == "" })
+	case "result":
+		return anyMetadataPlayer(func(player *rep.MetaPlayer) bool { return player.Result() == "" })
+	case "region":
+		if len(detailsPlayers) == 0 {
+			return true
+		}
+		for i := range detailsPlayers {
+			if detailsPlayers[i].Toon.Region() == rep.RegionUnknown {
+				return true
+			}
+		}
+		return false
+	case "map":
+		return replayData.Metadata.Title() == "" && replayData.Details.Title() == ""
+	case "gameVersion":
+		return replayData.Metadata.GameVersion() == "" && replayData.Header.VersionString() == ""
+	case "mapAuthor":
+		return replayData.InitData.GameDescription.MapAuthorName() == ""
+	}
+
+	return false
 }
 
 // checkBlizzardMap verifies if the currently processed
@@ -0,0 +1,151 @@
+package dataproc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/icza/s2prot"
+	"github.com/icza/s2prot/rep"
+)
+
+// validityTestPlayer describes a player of the replay created by validityTestReplay.
+type validityTestPlayer struct {
+	teamID int64
+	mmr    float64
+	apm    float64
+}
+
+// validityTestReplay creates a ranked replay holding the players
+// without decoding any of the replay files.
+func validityTestReplay(players []validityTestPlayer, isBlizzardMap bool) *rep.Rep {
+
+	detailsPlayers := []interface{}{}
+	metadataPlayers := []interface{}{}
+	for i, player := range players {
+		detailsPlayers = append(detailsPlayers, s2prot.Struct{
+			"teamId": player.teamID,
+			"toon":   s2prot.Struct{"region": int64(2)},
+		})
+		metadataPlayers = append(metadataPlayers, map[string]interface{}{
+			"PlayerID":     float64(i + 1),
+			"MMR":          player.mmr,
+			"APM":          player.apm,
+			"AssignedRace": "Prot",
+		})
+	}
+
+	gameDescription := rep.GameDescription{
+		Struct: s2prot.Struct{
+			"isBlizzardMap": isBlizzardMap,
+			"maxPlayers":    int64(len(players)),
+			"mapAuthorName": "1-S2-1-1",
+		},
+		GameOptions: rep.GameOptions{
+			Struct: s2prot.Struct{"amm": true, "competitive": true},
+		},
+	}
+
+	return &rep.Rep{
+		Header: rep.Header{Struct: s2prot.Struct{"elapsedGameLoops": int64(2240)}},
+		Details: rep.Details{Struct: s2prot.Struct{
+			"isBlizzardMap": isBlizzardMap,
+			"title":         "Map",
+			"playerList":    detailsPlayers,
+		}},
+		InitData: rep.InitData{GameDescription: gameDescription},
+		Metadata: rep.Metadata{Struct: s2prot.Struct{"Players": metadataPlayers}},
+	}
+}
+
+// TestValidateReplay verifies that every violated rule is reported
+// and that the rules are applied to the team games.
+func TestValidateReplay(t *testing.T) {
+
+	teamRules := datastruct.GameModeValidityRules{
+		MaxMMR:             datastruct.ThresholdRule{Enabled: true, Value: 8000},
+		MaxMMRDifference:   datastruct.ThresholdRule{Enabled: true, Value: 1200},
+		MinAPM:             datastruct.ThresholdRule{Enabled: true, Value: 1},
+		MinDurationSeconds: datastruct.ThresholdRule{Enabled: true, Value: 180},
+		RequiredFields: datastruct.ListRule{
+			Enabled: true,
+			Values:  []string{"mmr", "race", "result", "region", "map", "mapAuthor"},
+		},
+		BlizzardMap: datastruct.SwitchRule{Enabled: true},
+		AllowedMapAuthors: datastruct.ListRule{
+			Enabled: true,
+			Values:  []string{"1-S2-1-1"},
+		},
+	}
+	validityRules := datastruct.ValidityRules{
+		GameModes: map[string]datastruct.GameModeValidityRules{
+			"ranked_2v2": teamRules,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		players       []validityTestPlayer
+		isBlizzardMap bool
+		expectedRules []string
+	}{
+		{
+			name: "team averages within the MMR difference",
+			players: []validityTestPlayer{
+				{teamID: 0, mmr: 3000, apm: 100},
+				{teamID: 0, mmr: 5000, apm: 100},
+				{teamID: 1, mmr: 4000, apm: 100},
+				{teamID: 1, mmr: 4000, apm: 100},
+			},
+			isBlizzardMap: true,
+			expectedRules: []string{"minDurationSeconds", "requiredFields.result"},
+		},
+		{
+			name: "every violation is reported",
+			players: []validityTestPlayer{
+				{teamID: 0, mmr: 8100, apm: 0},
+				{teamID: 0, mmr: 6000, apm: 100},
+				{teamID: 1, mmr: 0, apm: 100},
+				{teamID: 1, mmr: 4000, apm: 100},
+			},
+			isBlizzardMap: false,
+			expectedRules: []string{
+				"maxMMR",
+				"minAPM",
+				"maxMMRDifference",
+				"minDurationSeconds",
+				"requiredFields.mmr",
+				"requiredFields.result",
+				"blizzardMap",
+			},
+		},
+		{
+			name: "game mode without rules is not validated",
+			players: []validityTestPlayer{
+				{teamID: 0, mmr: 9000, apm: 0},
+				{teamID: 1, mmr: 1000, apm: 0},
+			},
+			isBlizzardMap: false,
+			expectedRules: []string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			replayData := validityTestReplay(testCase.players, testCase.isBlizzardMap)
+			violations := validateReplay(replayData, validityRules)
+
+			rules := []string{}
+			for _, violation := range violations {
+				rules = append(rules, violation.rule)
+			}
+			if !reflect.DeepEqual(rules, testCase.expectedRules) {
+				t.Errorf(
+					"Test Failed! Expected violated rules %v, got %v",
+					testCase.expectedRules,
+					rules,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package cleanup
+
+import (
+	"strconv"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/icza/s2prot/rep"
+	log "github.com/sirupsen/logrus"
+)
+
+// SlotRole classifies the lobby slot as one of the replay_data.SlotRole roles,
+// returns false for the open and closed slots.
+func SlotRole(slot *rep.Slot) (string, bool) {
+
+	switch slot.Control() {
+	case rep.ControlComputer:
+		return replay_data.SlotRoleComputer, true
+	case rep.ControlHuman:
+		switch slot.Observe() {
+		case rep.ObserveSpectator:
+			return replay_data.SlotRoleObserver, true
+		case rep.ObserveReferee:
+			return replay_data.SlotRoleReferee, true
+		}
+		return replay_data.SlotRoleHuman, true
+	}
+
+	return "", false
+}
+
+// AIDifficulty returns the name of the difficulty of the computer slot.
+func AIDifficulty(slot *rep.Slot) string {
+
+	difficulty, ok := settings.AIDifficulties[slot.Difficulty()]
+	if !ok {
+		return strconv.FormatInt(slot.Difficulty(), 10)
+	}
+	return difficulty
+}
+
+// mergeToonDescMapWithSlot fills out the role of the player
+// and the difficulty of the computer players from the lobby slot of the player.
+func mergeToonDescMapWithSlot(
+	replayData *rep.Rep,
+	playerDescription *rep.PlayerDesc,
+	enhancedToonDescMap replay_data.EnhancedToonDescMap,
+) replay_data.EnhancedToonDescMap {
+
+	slots := replayData.InitData.LobbyState.Slots
+	if playerDescription.SlotID < 0 || playerDescription.SlotID >= int64(len(slots)) {
+		log.WithField("slotID", playerDescription.SlotID).
+			Warn("Slot of the player not found in the lobby state!")
+		return enhancedToonDescMap
+	}
+
+	slot := &slots[playerDescription.SlotID]
+	slotRole, ok := SlotRole(slot)
+	if !ok {
+		return enhancedToonDescMap
+	}
+	enhancedToonDescMap.SlotRole = slotRole
+	if slotRole == replay_data.SlotRoleComputer {
+		enhancedToonDescMap.AIDifficulty = AIDifficulty(slot)
+	}
+
+	return enhancedToonDescMap
+}
+
+// CleanObservers lists the observers and the referees of the game
+// from the lobby slots and the user init data.
+func CleanObservers(replayData *rep.Rep) []replay_data.ObserverDesc {
+
+	observers := []replay_data.ObserverDesc{}
+	userInitDatas := replayData.InitData.UserInitDatas
+	for i := range replayData.InitData.LobbyState.Slots {
+		slot := &replayData.InitData.LobbyState.Slots[i]
+		slotRole, ok := SlotRole(slot)
+		if !ok || (slotRole != replay_data.SlotRoleObserver &&
+			slotRole != replay_data.SlotRoleReferee) {
+			continue
+		}
+
+		observer := replay_data.ObserverDesc{
+			Toon:   slot.ToonHandle(),
+			UserID: slot.UserID(),
+			Role:   slotRole,
+		}
+		if userID := slot.UserID(); userID >= 0 && userID < int64(len(userInitDatas)) {
+			observer.Name = userInitDatas[userID].Name()
+		}
+		observers = append(observers, observer)
+	}
+
+	return observers
+}
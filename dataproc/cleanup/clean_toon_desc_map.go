@@ -41,6 +41,13 @@ func CleanToonDescMap(
 			enhancedToonDescMap[toonKey],
 		)
 
+		// Merging the role of the player from the lobby slot:
+		enhancedToonDescMap[toonKey] = mergeToonDescMapWithSlot(
+			replayData,
+			playerDescription,
+			enhancedToonDescMap[toonKey],
+		)
+
 		// Merging information contained in the details part of the replay:
 		var err error
 		enhancedToonDescMap[toonKey], err = mergeToonDescMapWithDetails(
@@ -177,6 +184,7 @@ func mergeToonDescMapWithDetails(
 		detailsEnhancedToonDescMap.Color.G = player.Color[2]
 		detailsEnhancedToonDescMap.Color.R = player.Color[3]
 		detailsEnhancedToonDescMap.Handicap = player.Handicap()
+		detailsEnhancedToonDescMap.TeamID = player.TeamID()
 		// There should be only one player that fits the unique toon key,
 		// if the player was found and the values were filled out we can exit the loop:
 		break
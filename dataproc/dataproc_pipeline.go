@@ -33,6 +33,7 @@ func PipelineWrapper(
 	packageToZipBool bool,
 	compressionMethod uint16,
 	foreignToEnglishMapping map[string]string,
+	anonymizer Anonymizer,
 	cliFlags utils.CLIFlags,
 ) {
 
@@ -54,13 +55,25 @@ func PipelineWrapper(
 	// If it is specified by the user to perform the processing without
 	// multiprocessing GOMAXPROCS needs to be set to 1 in order to allow 1 thread:
 	runtime.GOMAXPROCS(cliFlags.NumberOfThreads)
-	var channel = make(chan ReplayProcessingChannelContents, cliFlags.NumberOfThreads+1)
+	// Outputs saved directly to drive share the output directory:
+	driveOutputNames := NewOutputNameRegistry(cliFlags.InputDirectory)
+
+	numberOfWorkers := cliFlags.NumberOfThreads
+	// Colliding output names are suffixed in the order of processing,
+	// deterministic outputs saved to drive are processed by a single worker
+	// so that the chunks sorted by the paths are processed in order:
+	if cliFlags.Deterministic && !packageToZipBool && numberOfWorkers > 1 {
+		log.Info("Deterministic output saved to drive is processed by a single worker.")
+		numberOfWorkers = 1
+	}
+
+	var channel = make(chan ReplayProcessingChannelContents, numberOfWorkers+1)
 	var wg sync.WaitGroup
 	// Adding a task for each of the supplied chunks to speed up the processing:
-	wg.Add(cliFlags.NumberOfThreads)
+	wg.Add(numberOfWorkers)
 
 	// Spin up workers waiting for chunks to process:
-	for i := 0; i < cliFlags.NumberOfThreads; i++ {
+	for i := 0; i < numberOfWorkers; i++ {
 		go func() {
 			for {
 				channelContents, ok := <-channel
@@ -74,7 +87,9 @@ func PipelineWrapper(
 					compressionMethod,
 					channelContents.Index,
 					foreignToEnglishMapping,
+					anonymizer,
 					progressBar,
+					driveOutputNames,
 					cliFlags,
 				)
 			}
@@ -105,7 +120,9 @@ func MultiprocessingChunkPipeline(
 	compressionMethod uint16,
 	chunkIndex int,
 	englishToForeignMapping map[string]string,
+	anonymizer Anonymizer,
 	progressBar *progressbar.ProgressBar,
+	driveOutputNames *OutputNameRegistry,
 	cliFlags utils.CLIFlags,
 ) {
 
@@ -122,16 +139,6 @@ func MultiprocessingChunkPipeline(
 	}
 	defer processingInfoFile.Close()
 
-	// Initializing grpc connection if the user chose to perform anonymization.
-	grpcAnonymizer := checkAnonymizationInitializeGRPC(
-		cliFlags.PerformPlayerAnonymization,
-	)
-	// In order to free up resources We are defering the connection closing when
-	// all of the files have been processed:
-	if grpcAnonymizer != nil {
-		defer grpcAnonymizer.Connection.Close()
-	}
-
 	// TODO: These could be a separate data structure:
 	// Defining counters:
 	pipelineErrorCounter := 0
@@ -144,12 +151,49 @@ func MultiprocessingChunkPipeline(
 	var buffer *bytes.Buffer
 	var writer *zip.Writer
 	var packageSummary persistent_data.PackageSummary
+	var packageIndex persistent_data.PackageIndex
+	packageFilename := "package_" + strconv.Itoa(chunkIndex) + ".zip"
 	if packageToZipBool {
 		buffer, writer = utils.InitBufferWriter()
 		log.Info("Initialized buffer and writer.")
 
 		// Create package summary structure:
-		packageSummary = persistent_data.NewPackageSummary()
+		packageSummary = persistent_data.NewPackageSummary(cliFlags.SummaryBins)
+		// Create package index structure:
+		packageIndex = persistent_data.NewPackageIndex()
+	}
+	// Names of the entries only have to be unique within a package:
+	outputNames := driveOutputNames
+	if packageToZipBool {
+		outputNames = NewOutputNameRegistry(cliFlags.InputDirectory)
+	}
+
+	// Deterministic packages hold the entries sorted by their names,
+	// they are written when all of the replays of the package are processed:
+	var deterministicEntries archiveEntries
+
+	// Rejected replays are saved in the processing info,
+	// counted in the package summary and quarantined if requested:
+	rejectReplay := func(
+		replayFile string,
+		rejectedReplay persistent_data.RejectedReplay,
+	) {
+		processingInfoStruct.AddToFailed(replayFile, rejectedReplay.Reason)
+		if cliFlags.QuarantineDirectory != "" {
+			quarantineReplay(
+				replayFile,
+				rejectedReplay,
+				cliFlags.InputDirectory,
+				cliFlags.QuarantineDirectory,
+				cliFlags.QuarantineMode,
+			)
+		}
+		if packageToZipBool {
+			persistent_data.AddRejectedReplayToPackageSumm(
+				rejectedReplay,
+				&packageSummary,
+			)
+		}
 	}
 
 	// Processing files:
@@ -168,42 +212,85 @@ func MultiprocessingChunkPipeline(
 			}
 
 			// Running all of the processing logic and verifying if it worked:
-			didWork, cleanReplayStructure, replaySummary, failureReason := FileProcessingPipeline(
+			didWork, cleanReplayStructure, replaySummary, rejectedReplay := FileProcessingPipeline(
 				replayFile,
-				grpcAnonymizer,
+				anonymizer,
 				englishToForeignMapping,
 				cliFlags,
 			)
-
-			// Create final replay string:
-			// REVIEW: Something is wrong here, marshalling to JSON fails from the cleanReplayStructure:
-			stringifyOk, replayString := stringifyReplay(&cleanReplayStructure)
-			if !stringifyOk {
+			if !didWork {
 				pipelineErrorCounter++
 				log.WithFields(log.Fields{
 					"pipelineErrorCounter": pipelineErrorCounter,
 					"replayFile":           replayFile,
-				}).Error("Failed to stringify the replay.")
-				processingInfoStruct.AddToFailed(
-					replayFile,
-					"Failed to stringify the replay.",
-				)
+					"stage":                rejectedReplay.Stage,
+				}).Error("Failed to perform FileProcessingPipeline()!")
+				rejectReplay(replayFile, rejectedReplay)
 				return
 			}
 
-			if !didWork {
+			// Create final replay strings for the chosen output layout:
+			stringifyOk, replaySections := stringifyReplaySections(
+				&cleanReplayStructure,
+				cliFlags.OutputLayout,
+				cliFlags.MetadataOnly,
+			)
+			if !stringifyOk {
 				pipelineErrorCounter++
 				log.WithFields(log.Fields{
 					"pipelineErrorCounter": pipelineErrorCounter,
 					"replayFile":           replayFile,
-				}).Error("Failed to perform FileProcessingPipeline()!")
-				processingInfoStruct.AddToFailed(
+				}).Error("Failed to stringify the replay.")
+				rejectReplay(
 					replayFile,
-					failureReason,
+					newRejectedCleanedReplay(
+						persistent_data.RejectionStageStringify,
+						"Failed to stringify the replay.",
+						&cleanReplayStructure,
+					),
 				)
 				return
 			}
 
+			// Exporting the time series for machine learning:
+			if cliFlags.ExportTimeSeries {
+				timeSeriesOk, timeSeriesReplaySections := timeSeriesSections(
+					&cleanReplayStructure,
+					int64(cliFlags.TimeSeriesIntervalLoops),
+				)
+				if !timeSeriesOk {
+					pipelineErrorCounter++
+					log.WithFields(log.Fields{
+						"pipelineErrorCounter": pipelineErrorCounter,
+						"replayFile":           replayFile,
+					}).Error("Failed to export the time series.")
+					rejectReplay(
+						replayFile,
+						newRejectedCleanedReplay(
+							persistent_data.RejectionStageTimeSeries,
+							"Failed to export the time series.",
+							&cleanReplayStructure,
+						),
+					)
+					return
+				}
+				replaySections = append(replaySections, timeSeriesReplaySections...)
+			}
+
+			if cliFlags.Deterministic {
+				sortReplaySections(replaySections)
+			}
+
+			outputName := outputNames.claim(
+				createReplayOutputName(
+					replayFile,
+					&cleanReplayStructure,
+					cliFlags.OutputTemplate,
+					cliFlags.PartitionBy,
+				),
+				replayFile,
+			)
+
 			// Saving output to zip archive:
 			if packageToZipBool {
 				// Append it to a list and when a package is created create a package summary and clear the list for next iterations
@@ -213,11 +300,17 @@ func MultiprocessingChunkPipeline(
 				)
 				log.Info("Added replaySummary to packageSummary")
 
-				savedSuccess := utils.SaveFileToArchive(
-					replayString,
-					replayFile,
-					compressionMethod,
-					writer)
+				savedSuccess := true
+				if cliFlags.Deterministic {
+					deterministicEntries.add(replaySections, outputName)
+				} else {
+					savedSuccess = saveReplaySectionsToArchive(
+						replaySections,
+						outputName,
+						compressionMethod,
+						archiveModifiedTime(cliFlags.Deterministic),
+						writer)
+				}
 				if !savedSuccess {
 					compressionErrorCounter++
 					log.WithFields(log.Fields{
@@ -227,15 +320,28 @@ func MultiprocessingChunkPipeline(
 					return
 				}
 
+				indexEntry := createReplayIndexEntry(
+					replayFile,
+					cliFlags.InputDirectory,
+					&cleanReplayStructure,
+					replaySections,
+					outputName,
+					packageFilename,
+				)
+				if privacyEnabled(cliFlags) {
+					hideIndexEntrySource(&indexEntry, anonymizer)
+				}
+				packageIndex.Replays = append(packageIndex.Replays, indexEntry)
+
 				processedCounter++
 				processingInfoStruct.AddToProcessed(replayFile)
 				log.Info("Added file to zip archive.")
 				return
 			}
 
-			okSaveToDrive := file_utils.SaveReplayJSONFileToDrive(
-				replayString,
-				replayFile,
+			okSaveToDrive := saveReplaySectionsToDrive(
+				replaySections,
+				outputName,
 				cliFlags.OutputDirectory)
 			if !okSaveToDrive {
 				saveErrorCounter++
@@ -263,11 +369,18 @@ func MultiprocessingChunkPipeline(
 
 	if packageToZipBool {
 
+		if cliFlags.Deterministic &&
+			!deterministicEntries.saveSorted(compressionMethod, writer) {
+			log.WithField("packageNumber", chunkIndex).
+				Error("Failed to save the sorted entries of the package!")
+			return
+		}
+
 		// Writing the zip archive to drive:
 		writer.Close()
 		packagePath := filepath.Join(
 			cliFlags.OutputDirectory,
-			"package_"+strconv.Itoa(chunkIndex)+".zip",
+			packageFilename,
 		)
 
 		// Writing PackageSummaryFile to drive:
@@ -295,24 +408,107 @@ func MultiprocessingChunkPipeline(
 				"packageAbsolutePath": packageAbsPath,
 				"packageNumber":       chunkIndex}).
 				Error("Failed to save package to drive!")
+			return
+		}
+
+		// Writing PackageIndexFile to drive:
+		packageIndex.Package = persistent_data.PackageInfo{
+			Filename:    packageFilename,
+			SHA256:      file_utils.BytesSHA256(buffer.Bytes()),
+			Size:        int64(buffer.Len()),
+			ReplayCount: len(packageIndex.Replays),
+		}
+		err = persistent_data.CreatePackageIndexFile(
+			cliFlags.OutputDirectory,
+			packageIndex,
+			chunkIndex)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":       err,
+				"packagePath": packagePath,
+			}).Error("Failed to save package index to drive!")
 		}
 	}
 
 	log.Debug("Finished MultiprocessingChunkPipeline()")
 }
 
+// filterReplayFile reads the replay without its events, verifies the roles
+// of the lobby slots and evaluates the filter expression,
+// so that the rejected replays are not decoded in full.
+func filterReplayFile(
+	replayFile string,
+	cliFlags utils.CLIFlags,
+) (bool, persistent_data.RejectedReplay) {
+
+	log.Debug("Entered filterReplayFile()")
+
+	replayData, err := rep.NewFromFileEvts(replayFile, false, false, false)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"file":      replayFile,
+			"error":     err,
+			"readError": true}).
+			Error("Failed to read file.")
+		return false, newRejectedUnreadableReplay(
+			"rep.NewFromFileEvts() failed",
+			err,
+		)
+	}
+	defer replayData.Close()
+
+	slotRolesOk, failureReason := filterSlotRoles(
+		replayData,
+		cliFlags.FilterHumansOnly,
+		cliFlags.FilterNoObservers,
+	)
+	if !slotRolesOk {
+		log.WithField("file", replayFile).Info("Replay has disallowed lobby slots.")
+		return false, newRejectedReplay(
+			persistent_data.RejectionStageFiltering,
+			failureReason,
+			replayData,
+		)
+	}
+
+	if cliFlags.FilterExpression != nil &&
+		!filterReplay(replayData, cliFlags.FilterExpression) {
+		log.WithField("file", replayFile).Info("Replay does not match the filter expression.")
+		return false, newRejectedReplay(
+			persistent_data.RejectionStageFiltering,
+			"filter expression did not match",
+			replayData,
+		)
+	}
+
+	log.Debug("Finished filterReplayFile()")
+	return true, persistent_data.RejectedReplay{}
+}
+
 // FileProcessingPipeline is performing the whole data processing pipeline
 // for a replay file. Reads the replay, cleans the replay structure,
 // creates replay summary, anonymizes, and creates a JSON replay output.
 func FileProcessingPipeline(
 	replayFile string,
-	grpcAnonymizer *GRPCAnonymizer,
+	anonymizer Anonymizer,
 	englishToForeignMapping map[string]string,
 	cliFlags utils.CLIFlags,
-) (bool, replay_data.CleanedReplay, persistent_data.ReplaySummary, string) {
+) (bool, replay_data.CleanedReplay, persistent_data.ReplaySummary, persistent_data.RejectedReplay) {
 
 	log.Debug("Entered FileProcessingPipeline()")
 
+	// Filtering the lobby slots and with the expression before the events are decoded:
+	if cliFlags.FilterHumansOnly || cliFlags.FilterNoObservers ||
+		cliFlags.FilterExpression != nil {
+		filterOk, rejectedReplay := filterReplayFile(replayFile, cliFlags)
+		if !filterOk {
+			return false,
+				replay_data.CleanedReplay{},
+				persistent_data.ReplaySummary{},
+				rejectedReplay
+		}
+	}
+
 	// Read replay:
 	replayData, err := rep.NewFromFile(replayFile)
 	if err != nil {
@@ -324,34 +520,45 @@ func FileProcessingPipeline(
 		return false,
 			replay_data.CleanedReplay{},
 			persistent_data.ReplaySummary{},
-			"rep.NewFromFile() failed"
+			newRejectedUnreadableReplay(
+				"rep.NewFromFile() failed",
+				err,
+			)
 	}
 	log.WithField("file", replayFile).Info("Read data from a replay.")
 	defer replayData.Close()
 
 	// Performing integrity checks:
 	if cliFlags.PerformIntegrityCheck {
-		integrityOk, failureReason := checkIntegrity(replayData)
+		integrityOk, failureReason := checkIntegrity(
+			replayData,
+			cliFlags.IntegrityLevel,
+			cliFlags.EventErrorsPolicy,
+		)
 		if !integrityOk {
 			log.WithField("file", replayFile).
 				Error("Integrity check failed in file.")
 			return false,
 				replay_data.CleanedReplay{},
 				persistent_data.ReplaySummary{},
-				fmt.Sprintf("checkIntegrity() failed: %s", failureReason)
+				newRejectedReplay(
+					persistent_data.RejectionStageIntegrity,
+					fmt.Sprintf("checkIntegrity() failed: %s", failureReason),
+					replayData,
+				)
 		}
 	}
 
 	// Performing validity checks:
 	if cliFlags.PerformValidityCheck {
-		if cliFlags.FilterGameMode&Ranked1v1 != 0 && gameIs1v1Ranked(replayData) {
-			// Perform Validity check
-			if !validate1v1Replay(replayData) {
-				return false,
-					replay_data.CleanedReplay{},
-					persistent_data.ReplaySummary{},
-					"validateReplay() failed"
-			}
+		violations := validateReplay(replayData, cliFlags.ValidityRules)
+		if len(violations) > 0 {
+			log.WithField("file", replayFile).
+				Error("Validity check failed in file.")
+			return false,
+				replay_data.CleanedReplay{},
+				persistent_data.ReplaySummary{},
+				newRejectedValidityReplay(violations, replayData)
 		}
 	}
 
@@ -361,7 +568,30 @@ func FileProcessingPipeline(
 			return false,
 				replay_data.CleanedReplay{},
 				persistent_data.ReplaySummary{},
-				"filterGameModes() failed"
+				newRejectedReplay(
+					persistent_data.RejectionStageFiltering,
+					"filterGameModes() failed",
+					replayData,
+				)
+		}
+	}
+
+	// Filtering the short games and the early leaves:
+	if cliFlags.FilterMinDuration > 0 || cliFlags.FilterEarlyLeaveSeconds > 0 {
+		gameEndOk, failureReason := filterGameEnd(
+			detectGameEnd(replayData),
+			cliFlags.FilterMinDuration,
+			cliFlags.FilterEarlyLeaveSeconds,
+		)
+		if !gameEndOk {
+			return false,
+				replay_data.CleanedReplay{},
+				persistent_data.ReplaySummary{},
+				newRejectedReplay(
+					persistent_data.RejectionStageFiltering,
+					failureReason,
+					replayData,
+				)
 		}
 	}
 
@@ -376,27 +606,56 @@ func FileProcessingPipeline(
 		return false,
 			replay_data.CleanedReplay{},
 			persistent_data.ReplaySummary{},
-			"cleanReplay() failed"
+			newRejectedReplay(
+				persistent_data.RejectionStageCleanup,
+				"cleanReplay() failed",
+				replayData,
+			)
 	}
 	// REVIEW: Finish Review
 
+	// Fingerprint is calculated before the players are anonymized,
+	// it is hidden if it was calculated from the values that are anonymized or coarsened:
+	cleanReplayStructure.GameFingerprint = gameFingerprint(&cleanReplayStructure)
+	if privacyEnabled(cliFlags) {
+		cleanReplayStructure.GameFingerprint = hideSourceIdentifier(
+			cleanReplayStructure.GameFingerprint,
+			anonymizer,
+		)
+	}
+
+	// Quasi-identifiers are coarsened before the summary,
+	// so that the summaries do not hold the exact values:
+	coarsenReplay(
+		&cleanReplayStructure,
+		cliFlags.TimePrecision,
+		cliFlags.MMRBucketSize,
+		cliFlags.APMRoundTo,
+		cliFlags.RemoveRegion,
+	)
+
 	// Create replay summary:
-	summarizeOk, summarizedReplay := summarizeReplay(&cleanReplayStructure)
+	summarizeOk, summarizedReplay := summarizeReplay(&cleanReplayStructure, cliFlags.SummaryBins)
 	if !summarizeOk {
 		log.WithField("file", replayFile).
 			Error("Failed to create replay summary.")
 		return false,
 			replay_data.CleanedReplay{},
 			persistent_data.ReplaySummary{},
-			"summarizeReplay() failed"
+			newRejectedCleanedReplay(
+				persistent_data.RejectionStageSummary,
+				"summarizeReplay() failed",
+				&cleanReplayStructure,
+			)
 	}
 
 	// Anonymize replay:
-	if grpcAnonymizer != nil {
+	if cliFlags.PerformChatAnonymization || cliFlags.PerformPlayerAnonymization {
 		if !anonymizeReplay(
 			&cleanReplayStructure,
-			grpcAnonymizer,
+			anonymizer,
 			cliFlags.PerformChatAnonymization,
+			cliFlags.ChatAnonymizationMode,
 			cliFlags.PerformPlayerAnonymization,
 		) {
 			log.WithField("file", replayFile).
@@ -404,10 +663,17 @@ func FileProcessingPipeline(
 			return false,
 				replay_data.CleanedReplay{},
 				persistent_data.ReplaySummary{},
-				"anonymizeReplay() failed"
+				newRejectedCleanedReplay(
+					persistent_data.RejectionStageAnonymization,
+					"anonymizeReplay() failed",
+					&cleanReplayStructure,
+				)
+		}
+		for path, count := range cleanReplayStructure.PIIAudit {
+			summarizedReplay.Summary.ScrubbedFields[path] += count
 		}
 	}
 
 	log.Debug("Finished FileProcessingPipeline()")
-	return true, cleanReplayStructure, summarizedReplay, ""
+	return true, cleanReplayStructure, summarizedReplay, persistent_data.RejectedReplay{}
 }
@@ -177,6 +177,7 @@ func testPipelineWrapperWithDir(
 		packageToZip,
 		compressionMethod,
 		foreignToEnglishMapping,
+		nil,
 		flags,
 	)
 
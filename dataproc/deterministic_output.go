@@ -0,0 +1,72 @@
+package dataproc
+
+import (
+	"archive/zip"
+	"sort"
+	"time"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// archiveModifiedTime returns the modification time that is written into
+// the zip entries. Deterministic output uses a fixed timestamp so that
+// the packages created from the same input are identical.
+func archiveModifiedTime(deterministic bool) time.Time {
+	if deterministic {
+		return settings.DeterministicArchiveModified
+	}
+	return time.Now()
+}
+
+// archiveEntries collects the entries of a deterministic package.
+// The entries are written sorted by their names when the package is closed,
+// so that their order does not depend on the output template or the partitions.
+type archiveEntries []replaySection
+
+// add appends the sections of a replay named after their entries in the package.
+func (entries *archiveEntries) add(sections []replaySection, outputName replayOutputName) {
+	for _, section := range sections {
+		*entries = append(*entries, replaySection{
+			filename: outputName.archivePath(section.filename),
+			contents: section.contents,
+		})
+	}
+}
+
+// saveSorted writes all of the collected entries into the archive
+// in the order of their names with a fixed modification time.
+func (entries archiveEntries) saveSorted(
+	compressionMethod uint16,
+	writer *zip.Writer,
+) bool {
+
+	log.Debug("Entered archiveEntries.saveSorted()")
+
+	sortReplaySections(entries)
+	for _, entry := range entries {
+		savedSuccess := utils.SaveBytesToArchive(
+			[]byte(entry.contents),
+			entry.filename,
+			compressionMethod,
+			archiveModifiedTime(true),
+			writer,
+		)
+		if !savedSuccess {
+			log.WithField("entry", entry.filename).
+				Error("Failed to save the entry to the archive!")
+			return false
+		}
+	}
+
+	log.Debug("Finished archiveEntries.saveSorted()")
+	return true
+}
+
+// sortReplaySections orders the sections of a replay by their filenames.
+func sortReplaySections(sections []replaySection) {
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].filename < sections[j].filename
+	})
+}
@@ -0,0 +1,206 @@
+package dataproc
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc/downloader"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/chunk_utils"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+)
+
+// packageDeterministically saves the sections of the replays into a package
+// in the same way as the deterministic pipeline does. Replays listed in outputStems
+// are named as if an output template was used.
+func packageDeterministically(
+	t *testing.T,
+	listOfFiles []string,
+	replaySections map[string][]replaySection,
+	outputStems map[string]string,
+	outputLayout datastruct.OutputLayoutEnum,
+) []byte {
+
+	buffer, writer := utils.InitBufferWriter()
+	outputNames := NewOutputNameRegistry("/input")
+	var entries archiveEntries
+	for _, replayFile := range file_utils.SortFilesByRelativePath(listOfFiles, "/input") {
+		sections := append([]replaySection{}, replaySections[replayFile]...)
+		sortReplaySections(sections)
+		if outputLayout == datastruct.SingleFileLayout {
+			sections = sections[:1]
+		}
+		outputName := defaultReplayOutputName(replayFile)
+		if stem, ok := outputStems[replayFile]; ok {
+			outputName = replayOutputName{stem: stem}
+		}
+		entries.add(sections, outputNames.claim(outputName, replayFile))
+	}
+	if !entries.saveSorted(uint16(8), writer) {
+		t.Fatalf("Test Failed! Failed to save the entries to the archive")
+	}
+	err := writer.Close()
+	if err != nil {
+		t.Fatalf("Test Failed! Failed to close the archive: %v", err)
+	}
+
+	return buffer.Bytes()
+}
+
+// TestDeterministicPackageBytes verifies that the packages created from the same replays
+// are identical byte for byte regardless of the order in which the replays
+// and their sections were listed, and that the entries are sorted by their names
+// also when the names come from an output template.
+func TestDeterministicPackageBytes(t *testing.T) {
+
+	replaySections := map[string][]replaySection{
+		"/input/a/game.SC2Replay": {
+			{filename: "header.json", contents: `{"a":1}`},
+			{filename: "details.json", contents: `{"a":2}`},
+		},
+		"/input/b/game.SC2Replay": {
+			{filename: "header.json", contents: `{"b":1}`},
+			{filename: "details.json", contents: `{"b":2}`},
+		},
+		"/input/other.SC2Replay": {
+			{filename: "details.json", contents: `{"c":2}`},
+			{filename: "header.json", contents: `{"c":1}`},
+		},
+	}
+	orders := [][]string{
+		{"/input/a/game.SC2Replay", "/input/b/game.SC2Replay", "/input/other.SC2Replay"},
+		{"/input/other.SC2Replay", "/input/b/game.SC2Replay", "/input/a/game.SC2Replay"},
+	}
+	templateStems := map[string]string{
+		"/input/a/game.SC2Replay": "2024/Z-vs-Z/game",
+		"/input/b/game.SC2Replay": "2021/P-vs-T/game",
+		"/input/other.SC2Replay":  "2021/P-vs-T/game",
+	}
+
+	for _, outputStems := range []map[string]string{nil, templateStems} {
+		for _, outputLayout := range []datastruct.OutputLayoutEnum{
+			datastruct.SingleFileLayout,
+			datastruct.SplitSectionsLayout,
+		} {
+			firstPackage := packageDeterministically(
+				t, orders[0], replaySections, outputStems, outputLayout,
+			)
+			secondPackage := packageDeterministically(
+				t, orders[1], replaySections, outputStems, outputLayout,
+			)
+			if !bytes.Equal(firstPackage, secondPackage) {
+				t.Errorf(
+					"Test Failed! Packages of the layout %d differ depending on the order of the replays",
+					outputLayout,
+				)
+			}
+
+			reader, err := zip.NewReader(bytes.NewReader(firstPackage), int64(len(firstPackage)))
+			if err != nil {
+				t.Fatalf("Test Failed! Failed to read the archive: %v", err)
+			}
+			entryNames := []string{}
+			for _, file := range reader.File {
+				entryNames = append(entryNames, file.Name)
+			}
+			if !slices.IsSorted(entryNames) {
+				t.Errorf("Test Failed! Expected the entries to be sorted, got %v", entryNames)
+			}
+		}
+	}
+}
+
+// TestPipelineWrapperDeterministic processes every replaypack directory
+// in the test input directory twice and verifies that the packages
+// and their indexes are identical byte for byte.
+func TestPipelineWrapperDeterministic(t *testing.T) {
+
+	testInputDir, err := settings.GetTestInputDirectory()
+	if err != nil {
+		t.Skip("Could not get the test input directory.")
+	}
+	dirContents, err := os.ReadDir(testInputDir)
+	if err != nil {
+		t.Skip("Could not get the test directory contents.")
+	}
+
+	testedDirs := 0
+	for _, maybeDir := range dirContents {
+		if !maybeDir.IsDir() || contains(TEST_BYPASS_THESE_DIRS, maybeDir.Name()) {
+			continue
+		}
+		replayInputPath := filepath.Join(testInputDir, maybeDir.Name())
+		sliceOfFiles, err := file_utils.ListFiles(replayInputPath, ".SC2Replay")
+		if err != nil || len(sliceOfFiles) == 0 {
+			continue
+		}
+		testedDirs++
+
+		t.Run(maybeDir.Name(), func(t *testing.T) {
+			dependencyDirectory := t.TempDir()
+			var outputs [2][][]byte
+			for run := range outputs {
+				outputDirectory := t.TempDir()
+				flags := utils.CLIFlags{
+					InputDirectory:        replayInputPath,
+					OutputDirectory:       outputDirectory,
+					DependencyDirectory:   dependencyDirectory,
+					NumberOfThreads:       2,
+					NumberOfPackages:      2,
+					PerformIntegrityCheck: true,
+					PerformCleanup:        true,
+					Deterministic:         true,
+					LogFlags: utils.LogFlags{
+						LogLevelValue: datastruct.Info,
+						LogPath:       outputDirectory,
+					},
+				}
+
+				listOfFiles := file_utils.SortFilesByRelativePath(sliceOfFiles, replayInputPath)
+				chunks, packageToZip := chunk_utils.GetChunkListAndPackageBool(
+					listOfFiles,
+					flags.NumberOfPackages,
+					flags.NumberOfThreads,
+					len(listOfFiles),
+				)
+				foreignToEnglishMapping := downloader.DependencyDownloaderPipeline(
+					listOfFiles,
+					filepath.Join(dependencyDirectory, "map_foreign_to_english_mapping.json"),
+					flags,
+				)
+				PipelineWrapper(chunks, packageToZip, uint16(8), foreignToEnglishMapping, nil, flags)
+
+				for i := range chunks {
+					for _, filename := range []string{
+						"package_" + strconv.Itoa(i) + ".zip",
+						persistent_data.PackageIndexFilename(i),
+					} {
+						outputBytes, err := os.ReadFile(filepath.Join(outputDirectory, filename))
+						if err != nil {
+							t.Fatalf("Test Failed! Could not read %s: %v", filename, err)
+						}
+						outputs[run] = append(outputs[run], outputBytes)
+					}
+				}
+			}
+
+			for i := range outputs[0] {
+				if !bytes.Equal(outputs[0][i], outputs[1][i]) {
+					t.Errorf("Test Failed! Output %d differs between the runs", i)
+				}
+			}
+		})
+	}
+
+	if testedDirs == 0 {
+		t.Skip("No test replays found in the test input directory.")
+	}
+}
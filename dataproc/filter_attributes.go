@@ -0,0 +1,154 @@
+package dataproc
+
+import (
+	"strconv"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc/cleanup"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/filter_expression"
+	"github.com/icza/s2prot/rep"
+	log "github.com/sirupsen/logrus"
+)
+
+// filterReplay evaluates the filter expression against the attributes of the replay.
+// The replay does not need to have its events decoded.
+func filterReplay(
+	replayData *rep.Rep,
+	filterExpression *filter_expression.Expression,
+) bool {
+
+	log.Debug("Entered filterReplay()")
+
+	matches := filterExpression.Evaluate(replayFilterAttributes(replayData))
+
+	log.Debug("Finished filterReplay()")
+	return matches
+}
+
+// filterSlotRoles rejects the replays with the computer players if humansOnly is set
+// and the replays with the observers or the referees if noObservers is set.
+// Returns the reason of the rejection.
+func filterSlotRoles(
+	replayData *rep.Rep,
+	humansOnly bool,
+	noObservers bool,
+) (bool, string) {
+
+	computers, observers := countSlotRoles(replayData)
+	if humansOnly && computers > 0 {
+		log.WithField("computers", computers).Info("Replay has computer players.")
+		return false, "computer players are not allowed"
+	}
+	if noObservers && observers > 0 {
+		log.WithField("observers", observers).Info("Replay has observers.")
+		return false, "observers are not allowed"
+	}
+
+	return true, ""
+}
+
+// replayFilterAttributes reads the attributes described in settings.FilterAttributes
+// from the header, details and metadata of the replay.
+func replayFilterAttributes(replayData *rep.Rep) map[string]filter_expression.Value {
+
+	gameVersion := replayData.Metadata.GameVersion()
+	if gameVersion == "" {
+		gameVersion = replayData.Header.VersionString()
+	}
+	mapName := replayData.Metadata.Title()
+	if mapName == "" {
+		mapName = replayData.Details.Title()
+	}
+	replayTime := replayData.Details.TimeUTC()
+	loops := replayData.Header.Loops()
+
+	// Metadata players are identified by their position in the details:
+	metadataPlayers := make(map[int64]rep.MetaPlayer)
+	for _, metadataPlayer := range replayData.Metadata.Players() {
+		metadataPlayers[metadataPlayer.PlayerID()] = metadataPlayer
+	}
+
+	computers, observers := countSlotRoles(replayData)
+
+	var races, mmrs, apms, results, regions, slots []filter_expression.Value
+	matchupPlayers := make(map[string]replay_data.EnhancedToonDescMap)
+	for i, player := range replayData.Details.Players() {
+		metadataPlayer := metadataPlayers[int64(i+1)]
+		race := metadataPlayer.AssignedRace()
+		if race == "" {
+			race = fullRaceName(player.Race().Letter)
+		}
+
+		races = append(races, filter_expression.StringValue(race))
+		mmrs = append(mmrs, filter_expression.NumberValue(metadataPlayer.MMR()))
+		apms = append(apms, filter_expression.NumberValue(metadataPlayer.APM()))
+		results = append(results, filter_expression.StringValue(metadataPlayer.Result()))
+		regions = append(regions, filter_expression.StringValue(player.Toon.Region().Name))
+		slotRole := replay_data.SlotRoleHuman
+		if player.Control() == rep.ControlComputer {
+			slotRole = replay_data.SlotRoleComputer
+		}
+		slots = append(slots, filter_expression.StringValue(slotRole))
+
+		matchupPlayers[strconv.Itoa(i)] = replay_data.EnhancedToonDescMap{
+			AssignedRace: race,
+			TeamID:       player.TeamID(),
+		}
+	}
+
+	return map[string]filter_expression.Value{
+		"duration":     filter_expression.NumberValue(float64(loops) / gameLoopsPerSecond),
+		"loops":        filter_expression.NumberValue(float64(loops)),
+		"map":          filter_expression.StringValue(mapName),
+		"version":      filter_expression.VersionValue(gameVersion),
+		"build":        filter_expression.NumberValue(float64(replayData.Header.BaseBuild())),
+		"date":         filter_expression.StringValue(replayTime.Format("2006-01-02")),
+		"year":         filter_expression.NumberValue(float64(replayTime.Year())),
+		"players":      filter_expression.NumberValue(float64(len(replayData.Details.Players()))),
+		"matchup":      filter_expression.StringValue(teamMatchup(matchupPlayers)),
+		"speed":        filter_expression.StringValue(replayData.Details.GameSpeed().String()),
+		"blizzard_map": filter_expression.BoolValue(replayData.Details.IsBlizzardMap()),
+		"computers":    filter_expression.NumberValue(float64(computers)),
+		"observers":    filter_expression.NumberValue(float64(observers)),
+		"race":         filter_expression.PlayerValues(races),
+		"mmr":          filter_expression.PlayerValues(mmrs),
+		"apm":          filter_expression.PlayerValues(apms),
+		"result":       filter_expression.PlayerValues(results),
+		"region":       filter_expression.PlayerValues(regions),
+		"slot":         filter_expression.PlayerValues(slots),
+	}
+}
+
+// countSlotRoles counts the computer players
+// and the observers (including the referees) in the lobby slots.
+func countSlotRoles(replayData *rep.Rep) (int, int) {
+
+	computers, observers := 0, 0
+	for i := range replayData.InitData.LobbyState.Slots {
+		slotRole, ok := cleanup.SlotRole(&replayData.InitData.LobbyState.Slots[i])
+		if !ok {
+			continue
+		}
+		switch slotRole {
+		case replay_data.SlotRoleComputer:
+			computers++
+		case replay_data.SlotRoleObserver, replay_data.SlotRoleReferee:
+			observers++
+		}
+	}
+
+	return computers, observers
+}
+
+// fullRaceName maps the race letter of the details to the race name used in the outputs.
+func fullRaceName(raceLetter rune) string {
+	switch raceLetter {
+	case 'P':
+		return "Prot"
+	case 'T':
+		return "Terr"
+	case 'Z':
+		return "Zerg"
+	}
+	return ""
+}
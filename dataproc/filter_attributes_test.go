@@ -0,0 +1,96 @@
+package dataproc
+
+import (
+	"testing"
+
+	"github.com/icza/s2prot"
+	"github.com/icza/s2prot/rep"
+)
+
+// slotRolesTestReplay creates a replay with the lobby slots
+// described by their control and observe identifiers.
+func slotRolesTestReplay(slots [][2]int64) *rep.Rep {
+
+	lobbySlots := []rep.Slot{}
+	for _, slot := range slots {
+		lobbySlots = append(lobbySlots, rep.Slot{Struct: s2prot.Struct{
+			"control": slot[0],
+			"observe": slot[1],
+		}})
+	}
+
+	return &rep.Rep{
+		InitData: rep.InitData{LobbyState: rep.LobbyState{Slots: lobbySlots}},
+	}
+}
+
+// TestFilterSlotRoles verifies that the computer players and the observers
+// are counted from the lobby slots and rejected only when requested.
+func TestFilterSlotRoles(t *testing.T) {
+
+	human, computer, open := int64(2), int64(3), int64(0)
+	participant, spectator, referee := int64(0), int64(1), int64(2)
+
+	testCases := []struct {
+		name        string
+		slots       [][2]int64
+		humansOnly  bool
+		noObservers bool
+		expectedOk  bool
+	}{
+		{
+			name:        "humans only",
+			slots:       [][2]int64{{human, participant}, {human, participant}, {open, participant}},
+			humansOnly:  true,
+			noObservers: true,
+			expectedOk:  true,
+		},
+		{
+			name:       "computer rejected",
+			slots:      [][2]int64{{human, participant}, {computer, participant}},
+			humansOnly: true,
+			expectedOk: false,
+		},
+		{
+			name:       "computer allowed",
+			slots:      [][2]int64{{human, participant}, {computer, participant}},
+			expectedOk: true,
+		},
+		{
+			name:        "spectator rejected",
+			slots:       [][2]int64{{human, participant}, {human, participant}, {human, spectator}},
+			noObservers: true,
+			expectedOk:  false,
+		},
+		{
+			name:        "referee rejected",
+			slots:       [][2]int64{{human, participant}, {human, participant}, {human, referee}},
+			noObservers: true,
+			expectedOk:  false,
+		},
+		{
+			name:       "observer allowed with humans only",
+			slots:      [][2]int64{{human, participant}, {human, participant}, {human, spectator}},
+			humansOnly: true,
+			expectedOk: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			replayData := slotRolesTestReplay(testCase.slots)
+			ok, reason := filterSlotRoles(replayData, testCase.humansOnly, testCase.noObservers)
+			if ok != testCase.expectedOk {
+				t.Errorf(
+					"Test Failed! Expected %v, got %v with the reason %q",
+					testCase.expectedOk,
+					ok,
+					reason,
+				)
+			}
+			if !ok && reason == "" {
+				t.Errorf("Test Failed! Expected a reason of the rejection")
+			}
+		})
+	}
+}
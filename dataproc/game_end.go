@@ -0,0 +1,123 @@
+package dataproc
+
+import (
+	"slices"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc/cleanup"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/icza/s2prot/rep"
+	log "github.com/sirupsen/logrus"
+)
+
+// detectGameEnd works out which player left the game first and when
+// from the leave events, the loop at which the game was decided
+// and whether the result followed a team leaving the game or a normal end.
+// The losing side surrendering issues the leave events as well, so a team leaving
+// close to the end of the replay is a normal end. Players whose lobby slot
+// is unknown are not assigned to any team and are skipped.
+func detectGameEnd(replayData *rep.Rep) replay_data.GameEnd {
+
+	log.Debug("Entered detectGameEnd()")
+
+	headerLoops := replayData.Header.Loops()
+	gameEnd := replay_data.GameEnd{
+		EffectiveGameLoops:       headerLoops,
+		EffectiveDurationSeconds: float64(headerLoops) / gameLoopsPerSecond,
+		EndReason:                replay_data.GameEndNormal,
+	}
+
+	// Leave events are issued by the users, observers are not taken into account:
+	userPlayerIDs := map[int64]int64{}
+	userTeamIDs := map[int64]int64{}
+	remainingTeamPlayers := map[int64]int{}
+	slots := replayData.InitData.LobbyState.Slots
+	for playerID, playerDescription := range replayData.TrackerEvts.PIDPlayerDescMap {
+		if playerDescription.SlotID < 0 || playerDescription.SlotID >= int64(len(slots)) {
+			log.WithField("playerID", playerID).
+				Warn("Unknown lobby slot of the player, skipping it in the game end.")
+			continue
+		}
+		slot := &slots[playerDescription.SlotID]
+		teamID := slot.TeamID()
+		slotRole, _ := cleanup.SlotRole(slot)
+		isComputer := slotRole == replay_data.SlotRoleComputer
+		remainingTeamPlayers[teamID]++
+		// Computer players do not have users and never leave the game:
+		if isComputer {
+			continue
+		}
+		userPlayerIDs[playerDescription.UserID] = playerID
+		userTeamIDs[playerDescription.UserID] = teamID
+	}
+
+	normalEndLoop := headerLoops - int64(settings.NormalEndToleranceSeconds*gameLoopsPerSecond)
+	decided := false
+	for _, gameEvent := range replayData.GameEvts {
+		if !slices.Contains(settings.LeaveEventNames, gameEvent.Name) {
+			continue
+		}
+		userID := gameEvent.UserID()
+		playerID, ok := userPlayerIDs[userID]
+		if !ok {
+			continue
+		}
+		// Every player is counted once:
+		delete(userPlayerIDs, userID)
+
+		loop := min(gameEvent.Loop(), headerLoops)
+		if gameEnd.FirstLeaverPlayerID == 0 {
+			gameEnd.FirstLeaverPlayerID = playerID
+			gameEnd.FirstLeaveLoop = loop
+			gameEnd.FirstLeaveSeconds = float64(loop) / gameLoopsPerSecond
+		}
+
+		// The game is decided when the last player of a team leaves:
+		teamID := userTeamIDs[userID]
+		remainingTeamPlayers[teamID]--
+		if remainingTeamPlayers[teamID] == 0 && !decided && len(remainingTeamPlayers) > 1 {
+			decided = true
+			gameEnd.EffectiveGameLoops = loop
+			gameEnd.EffectiveDurationSeconds = float64(loop) / gameLoopsPerSecond
+			switch {
+			case gameEnd.EffectiveDurationSeconds < settings.WalkoverMaxSeconds:
+				gameEnd.EndReason = replay_data.GameEndWalkover
+			case loop >= normalEndLoop:
+				gameEnd.EndReason = replay_data.GameEndNormal
+			default:
+				gameEnd.EndReason = replay_data.GameEndLeave
+			}
+		}
+	}
+
+	log.Debug("Finished detectGameEnd()")
+	return gameEnd
+}
+
+// filterGameEnd rejects the replays whose effective length is shorter
+// than minDurationSeconds and the replays in which a player left the game
+// before earlyLeaveSeconds. Zero disables either of the filters.
+// Returns the reason of the rejection.
+func filterGameEnd(
+	gameEnd replay_data.GameEnd,
+	minDurationSeconds int,
+	earlyLeaveSeconds int,
+) (bool, string) {
+
+	if minDurationSeconds > 0 &&
+		gameEnd.EffectiveDurationSeconds < float64(minDurationSeconds) {
+		log.WithField("effectiveDurationSeconds", gameEnd.EffectiveDurationSeconds).
+			Info("Replay is shorter than the minimum duration.")
+		return false, "game is shorter than the minimum duration"
+	}
+	if earlyLeaveSeconds > 0 && gameEnd.FirstLeaverPlayerID != 0 &&
+		gameEnd.FirstLeaveSeconds < float64(earlyLeaveSeconds) {
+		log.WithFields(log.Fields{
+			"firstLeaverPlayerID": gameEnd.FirstLeaverPlayerID,
+			"firstLeaveSeconds":   gameEnd.FirstLeaveSeconds,
+		}).Info("Player left the game early.")
+		return false, "player left the game early"
+	}
+
+	return true, ""
+}
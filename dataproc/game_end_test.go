@@ -0,0 +1,189 @@
+package dataproc
+
+import (
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/icza/s2prot"
+	"github.com/icza/s2prot/rep"
+)
+
+// gameEndTestReplay creates a 2v2 replay of 22400 game loops (1000 seconds)
+// in which the users leave the game at the given loops.
+func gameEndTestReplay(userLeaveLoops [][2]int64) *rep.Rep {
+
+	slots := []rep.Slot{}
+	playerDescriptions := map[int64]*rep.PlayerDesc{}
+	for i, teamID := range []int64{0, 0, 1, 1} {
+		slots = append(slots, rep.Slot{Struct: s2prot.Struct{
+			"control": int64(2),
+			"teamId":  teamID,
+			"userId":  int64(i),
+		}})
+		playerDescriptions[int64(i+1)] = &rep.PlayerDesc{
+			PlayerID: int64(i + 1),
+			SlotID:   int64(i),
+			UserID:   int64(i),
+		}
+	}
+
+	gameEvents := []s2prot.Event{}
+	for _, userLeaveLoop := range userLeaveLoops {
+		gameEvents = append(gameEvents, s2prot.Event{
+			Struct: s2prot.Struct{
+				"loop":   userLeaveLoop[1],
+				"userid": s2prot.Struct{"userId": userLeaveLoop[0]},
+			},
+			EvtType: &s2prot.EvtType{Name: "GameUserLeave"},
+		})
+	}
+
+	return &rep.Rep{
+		Header:      rep.Header{Struct: s2prot.Struct{"elapsedGameLoops": int64(22400)}},
+		InitData:    rep.InitData{LobbyState: rep.LobbyState{Slots: slots}},
+		TrackerEvts: &rep.TrackerEvts{PIDPlayerDescMap: playerDescriptions},
+		GameEvts:    gameEvents,
+	}
+}
+
+// TestDetectGameEnd verifies that the first leaver is found
+// and that the game is decided when the last player of a team leaves.
+func TestDetectGameEnd(t *testing.T) {
+
+	headerLoops := int64(22400)
+	headerSeconds := float64(headerLoops) / gameLoopsPerSecond
+	// Five seconds before the end of the replay:
+	surrenderLoop := headerLoops - 112
+
+	testCases := []struct {
+		name           string
+		userLeaveLoops [][2]int64
+		expected       replay_data.GameEnd
+	}{
+		{
+			name:           "no leaves",
+			userLeaveLoops: [][2]int64{},
+			expected: replay_data.GameEnd{
+				EffectiveGameLoops:       22400,
+				EffectiveDurationSeconds: headerSeconds,
+				EndReason:                replay_data.GameEndNormal,
+			},
+		},
+		{
+			name:           "team leaves",
+			userLeaveLoops: [][2]int64{{2, 4480}, {0, 11200}, {3, 13440}, {1, 22400}},
+			expected: replay_data.GameEnd{
+				FirstLeaverPlayerID:      3,
+				FirstLeaveLoop:           4480,
+				FirstLeaveSeconds:        200,
+				EffectiveGameLoops:       13440,
+				EffectiveDurationSeconds: 600,
+				EndReason:                replay_data.GameEndLeave,
+			},
+		},
+		{
+			name:           "walkover",
+			userLeaveLoops: [][2]int64{{0, 224}, {1, 448}, {2, 22400}},
+			expected: replay_data.GameEnd{
+				FirstLeaverPlayerID:      1,
+				FirstLeaveLoop:           224,
+				FirstLeaveSeconds:        10,
+				EffectiveGameLoops:       448,
+				EffectiveDurationSeconds: 20,
+				EndReason:                replay_data.GameEndWalkover,
+			},
+		},
+		{
+			name:           "surrender at the last loop",
+			userLeaveLoops: [][2]int64{{2, surrenderLoop}, {3, headerLoops}},
+			expected: replay_data.GameEnd{
+				FirstLeaverPlayerID:      3,
+				FirstLeaveLoop:           surrenderLoop,
+				FirstLeaveSeconds:        float64(surrenderLoop) / gameLoopsPerSecond,
+				EffectiveGameLoops:       22400,
+				EffectiveDurationSeconds: headerSeconds,
+				EndReason:                replay_data.GameEndNormal,
+			},
+		},
+		{
+			name:           "observer and repeated leaves are ignored",
+			userLeaveLoops: [][2]int64{{7, 224}, {1, 2240}, {1, 4480}},
+			expected: replay_data.GameEnd{
+				FirstLeaverPlayerID:      2,
+				FirstLeaveLoop:           2240,
+				FirstLeaveSeconds:        100,
+				EffectiveGameLoops:       22400,
+				EffectiveDurationSeconds: headerSeconds,
+				EndReason:                replay_data.GameEndNormal,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			gameEnd := detectGameEnd(gameEndTestReplay(testCase.userLeaveLoops))
+			if gameEnd != testCase.expected {
+				t.Errorf(
+					"Test Failed! Expected %+v, got %+v",
+					testCase.expected,
+					gameEnd,
+				)
+			}
+		})
+	}
+
+	// Player of an unknown slot is not a team of its own, leaving does not decide the game:
+	replayData := gameEndTestReplay([][2]int64{{5, 224}})
+	replayData.TrackerEvts.PIDPlayerDescMap[6] = &rep.PlayerDesc{
+		PlayerID: 6,
+		SlotID:   9,
+		UserID:   5,
+	}
+	gameEnd := detectGameEnd(replayData)
+	if gameEnd.EndReason != replay_data.GameEndNormal || gameEnd.FirstLeaverPlayerID != 0 {
+		t.Errorf("Test Failed! Expected the player of an unknown slot to be skipped, got %+v", gameEnd)
+	}
+}
+
+// TestFilterGameEnd verifies that the short games and the early leaves
+// are rejected only when the filters are enabled.
+func TestFilterGameEnd(t *testing.T) {
+
+	gameEnd := replay_data.GameEnd{
+		FirstLeaverPlayerID:      1,
+		FirstLeaveSeconds:        20,
+		EffectiveDurationSeconds: 120,
+		EndReason:                replay_data.GameEndLeave,
+	}
+
+	testCases := []struct {
+		name               string
+		minDurationSeconds int
+		earlyLeaveSeconds  int
+		expectedOk         bool
+	}{
+		{name: "filters disabled", expectedOk: true},
+		{name: "long enough", minDurationSeconds: 120, expectedOk: true},
+		{name: "too short", minDurationSeconds: 180, expectedOk: false},
+		{name: "late leave", earlyLeaveSeconds: 20, expectedOk: true},
+		{name: "early leave", earlyLeaveSeconds: 30, expectedOk: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ok, reason := filterGameEnd(
+				gameEnd,
+				testCase.minDurationSeconds,
+				testCase.earlyLeaveSeconds,
+			)
+			if ok != testCase.expectedOk {
+				t.Errorf(
+					"Test Failed! Expected %v, got %v with the reason %q",
+					testCase.expectedOk,
+					ok,
+					reason,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package dataproc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// replayOutputName is the path of the outputs of a replay relative
+// to the output directory or the root of the package, using forward slashes.
+// Outputs that are split into sections are saved in the directory named stem.
+type replayOutputName struct {
+	stem string
+	// archiveExtension is kept in the names of the single file outputs
+	// in the packages, these were always named after the whole replay filename.
+	archiveExtension string
+}
+
+// archivePath returns the name of the zip entry holding the section.
+// Empty sectionFilename means the whole replay output.
+func (outputName replayOutputName) archivePath(sectionFilename string) string {
+	if sectionFilename == "" {
+		return outputName.stem + outputName.archiveExtension + ".json"
+	}
+	return path.Join(outputName.stem, sectionFilename)
+}
+
+// drivePath returns the path of the file holding the section
+// relative to the output directory.
+// Empty sectionFilename means the whole replay output.
+func (outputName replayOutputName) drivePath(sectionFilename string) string {
+	if sectionFilename == "" {
+		return outputName.stem + ".json"
+	}
+	return path.Join(outputName.stem, sectionFilename)
+}
+
+// defaultReplayOutputName names the outputs after the replay file.
+func defaultReplayOutputName(replayFile string) replayOutputName {
+	return replayOutputName{
+		stem:             file_utils.ReplayFileNameWithoutExtension(replayFile),
+		archiveExtension: filepath.Ext(replayFile),
+	}
+}
+
+// createReplayOutputName renders the output template and prepends
+// the Hive style partition directories, such as "year=2021/matchup=P-vs-T".
+func createReplayOutputName(
+	replayFile string,
+	replayData *replay_data.CleanedReplay,
+	outputTemplate utils.OutputTemplate,
+	partitionFields []string,
+) replayOutputName {
+
+	log.Debug("Entered createReplayOutputName()")
+
+	outputName := defaultReplayOutputName(replayFile)
+	if outputTemplate.IsEmpty() && len(partitionFields) == 0 {
+		return outputName
+	}
+
+	fieldValues := outputNameFieldValues(replayFile, replayData)
+
+	if !outputTemplate.IsEmpty() {
+		sanitizedValues := make(map[string]string, len(fieldValues))
+		for field, value := range fieldValues {
+			sanitizedValue := sanitizeOutputNameElement(value)
+			if sanitizedValue == "" {
+				sanitizedValue = "unknown"
+			}
+			sanitizedValues[field] = sanitizedValue
+		}
+		outputName = replayOutputName{stem: outputTemplate.Render(sanitizedValues)}
+	}
+
+	var partitions []string
+	for _, field := range partitionFields {
+		partitionValue := sanitizeOutputNameElement(fieldValues[field])
+		if partitionValue == "" {
+			partitionValue = settings.HivePartitionDefault
+		}
+		partitions = append(partitions, field+"="+partitionValue)
+	}
+	partitions = append(partitions, outputName.stem)
+	outputName.stem = path.Join(partitions...)
+
+	log.Debug("Finished createReplayOutputName()")
+	return outputName
+}
+
+// outputNameFieldValues returns the values of all of the settings.OutputNameFields.
+func outputNameFieldValues(
+	replayFile string,
+	replayData *replay_data.CleanedReplay,
+) map[string]string {
+
+	fieldValues := map[string]string{
+		"map":         replayData.Metadata.MapName,
+		"matchup":     teamMatchup(replayData.ToonPlayerDescMap),
+		"fingerprint": replayData.GameFingerprint,
+		"gameVersion": replayData.Metadata.GameVersion,
+		"replay":      file_utils.ReplayFileNameWithoutExtension(replayFile),
+	}
+	if fieldValues["gameVersion"] == "" {
+		fieldValues["gameVersion"] = replayData.Header.Version
+	}
+	if !replayData.Details.TimeUTC.IsZero() {
+		year, month, day := replayData.Details.TimeUTC.UTC().Date()
+		fieldValues["year"] = fmt.Sprintf("%04d", year)
+		fieldValues["month"] = fmt.Sprintf("%02d", int(month))
+		fieldValues["day"] = fmt.Sprintf("%02d", day)
+	}
+
+	return fieldValues
+}
+
+// sanitizeOutputNameElement replaces the characters that are not allowed
+// in the filenames on the supported operating systems and the path separators,
+// so that the value of a field is always a single path element.
+func sanitizeOutputNameElement(value string) string {
+
+	sanitized := strings.Map(func(character rune) rune {
+		if character < 32 || strings.ContainsRune(`/\:*?"<>|=`, character) {
+			return '_'
+		}
+		return character
+	}, value)
+
+	// Windows does not allow for the names ending with a space or a dot:
+	sanitized = strings.TrimRight(strings.TrimSpace(sanitized), ".")
+	if sanitized == "" && value != "" {
+		return "_"
+	}
+	return sanitized
+}
+
+// OutputNameRegistry keeps track of the output names that were used
+// so that the outputs of different replays never overwrite each other.
+// It is safe for concurrent use.
+type OutputNameRegistry struct {
+	mutex          sync.Mutex
+	takenNames     map[string]string
+	inputDirectory string
+}
+
+// NewOutputNameRegistry returns an initialized OutputNameRegistry.
+// Suffixes resolving the collisions are derived from the paths
+// of the replays relative to the input directory.
+func NewOutputNameRegistry(inputDirectory string) *OutputNameRegistry {
+	return &OutputNameRegistry{
+		takenNames:     make(map[string]string),
+		inputDirectory: inputDirectory,
+	}
+}
+
+// isTaken verifies if the name was claimed in this run. Outputs of the earlier runs
+// are overwritten, so that processing the same input again yields the same names.
+func (registry *OutputNameRegistry) isTaken(outputName replayOutputName) bool {
+	_, taken := registry.takenNames[strings.ToLower(outputName.stem)]
+	return taken
+}
+
+// claim reserves the output name for the replay. If the name was already
+// used by another replay, a suffix derived from the path of the replay is appended.
+// Names are compared case insensitively as some file systems do not distinguish case.
+func (registry *OutputNameRegistry) claim(
+	outputName replayOutputName,
+	replayFile string,
+) replayOutputName {
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	claimedName := outputName
+	if registry.isTaken(claimedName) {
+		relativePath, err := filepath.Rel(registry.inputDirectory, replayFile)
+		if err != nil {
+			relativePath = replayFile
+		}
+		pathChecksum := sha256.Sum256([]byte(filepath.ToSlash(relativePath)))
+		claimedName.stem = outputName.stem + "_" + hex.EncodeToString(pathChecksum[:4])
+
+		for counter := 2; registry.isTaken(claimedName); counter++ {
+			claimedName.stem = fmt.Sprintf(
+				"%s_%s_%d",
+				outputName.stem,
+				hex.EncodeToString(pathChecksum[:4]),
+				counter,
+			)
+		}
+
+		log.WithFields(log.Fields{
+			"replayFile":   replayFile,
+			"outputName":   outputName.stem,
+			"claimedName":  claimedName.stem,
+			"collidesWith": registry.takenNames[strings.ToLower(outputName.stem)],
+		}).Warn("Output name collision detected, appended a suffix.")
+	}
+
+	registry.takenNames[strings.ToLower(claimedName.stem)] = replayFile
+	return claimedName
+}
@@ -0,0 +1,100 @@
+package dataproc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
+)
+
+// TestCreateReplayOutputName verifies the default names,
+// the rendered templates and the partition directories.
+func TestCreateReplayOutputName(t *testing.T) {
+
+	replayFile := filepath.Join("input", "nested", "game.SC2Replay")
+	replayData := replay_data.CleanedReplay{
+		Details:  replay_data.CleanedDetails{TimeUTC: time.Date(2021, 5, 3, 0, 0, 0, 0, time.UTC)},
+		Metadata: replay_data.CleanedMetadata{MapName: "Ever/Dream: LE"},
+		ToonPlayerDescMap: map[string]replay_data.EnhancedToonDescMap{
+			"1-S2-1-1": {AssignedRace: "Terr"},
+			"1-S2-1-2": {AssignedRace: "Prot", TeamID: 1},
+		},
+	}
+
+	outputName := createReplayOutputName(replayFile, &replayData, utils.OutputTemplate{}, nil)
+	if outputName.archivePath("") != "game.SC2Replay.json" ||
+		outputName.drivePath("") != "game.json" ||
+		outputName.archivePath("meta.json") != "game/meta.json" {
+		t.Errorf("Test Failed! Unexpected default names: %+v", outputName)
+	}
+
+	outputTemplate, err := utils.ParseOutputTemplate("{year}/{matchup}/{map}_{replay}.json")
+	if err != nil {
+		t.Fatalf("Test Failed! Could not parse the template: %v", err)
+	}
+	outputName = createReplayOutputName(replayFile, &replayData, outputTemplate, []string{"month", "fingerprint"})
+	expectedStem := "month=05/fingerprint=__HIVE_DEFAULT_PARTITION__/2021/P-vs-T/Ever_Dream_ LE_game"
+	if outputName.stem != expectedStem {
+		t.Errorf("Test Failed! Expected %q, got %q", expectedStem, outputName.stem)
+	}
+	if outputName.archivePath("") != expectedStem+".json" {
+		t.Errorf("Test Failed! Unexpected archive path: %q", outputName.archivePath(""))
+	}
+}
+
+// TestOutputNameRegistryClaim verifies that colliding names receive unique suffixes.
+func TestOutputNameRegistryClaim(t *testing.T) {
+
+	registry := NewOutputNameRegistry("input")
+	firstFile := filepath.Join("input", "a", "game.SC2Replay")
+	secondFile := filepath.Join("input", "b", "game.SC2Replay")
+
+	firstName := registry.claim(defaultReplayOutputName(firstFile), firstFile)
+	secondName := registry.claim(defaultReplayOutputName(secondFile), secondFile)
+	thirdName := registry.claim(replayOutputName{stem: "GAME"}, secondFile)
+
+	if firstName.stem != "game" {
+		t.Errorf("Test Failed! First claim should keep the name, got %q", firstName.stem)
+	}
+	if secondName.stem == firstName.stem || thirdName.stem == secondName.stem {
+		t.Errorf("Test Failed! Names collide: %q, %q, %q",
+			firstName.stem, secondName.stem, thirdName.stem)
+	}
+	if secondName.archiveExtension != ".SC2Replay" {
+		t.Errorf("Test Failed! Claim should keep the archive extension.")
+	}
+}
+
+// TestOutputNameRegistryExistingOutputs verifies that the outputs
+// saved to drive in an earlier run do not cause collisions,
+// so that they are overwritten instead of being duplicated.
+func TestOutputNameRegistryExistingOutputs(t *testing.T) {
+
+	outputDirectory := t.TempDir()
+	err := os.WriteFile(filepath.Join(outputDirectory, "game.json"), []byte("{}"), 0644)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not create the existing output: %v", err)
+	}
+
+	registry := NewOutputNameRegistry("input")
+	replayFile := filepath.Join("input", "game.SC2Replay")
+	outputName := defaultReplayOutputName(replayFile)
+	claimedName := registry.claim(outputName, replayFile)
+	if claimedName.stem != outputName.stem {
+		t.Errorf("Test Failed! Existing output %q should be overwritten, got %q", outputName.stem, claimedName.stem)
+	}
+	if !saveReplaySectionsToDrive(
+		[]replaySection{{filename: "", contents: `{"new":true}`}},
+		claimedName,
+		outputDirectory,
+	) {
+		t.Fatalf("Test Failed! Failed to save the output")
+	}
+	savedBytes, err := os.ReadFile(filepath.Join(outputDirectory, "game.json"))
+	if err != nil || string(savedBytes) != `{"new":true}` {
+		t.Errorf("Test Failed! Expected the existing output to be overwritten, got %q", savedBytes)
+	}
+}
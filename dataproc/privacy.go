@@ -0,0 +1,161 @@
+package dataproc
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// privacyEnabled verifies if any of the anonymization or the coarsening options is set.
+func privacyEnabled(cliFlags utils.CLIFlags) bool {
+	return cliFlags.PerformPlayerAnonymization ||
+		cliFlags.PerformChatAnonymization ||
+		cliFlags.TimePrecision != datastruct.FullTimePrecision ||
+		cliFlags.MMRBucketSize > 0 ||
+		cliFlags.APMRoundTo > 0 ||
+		cliFlags.RemoveRegion
+}
+
+// hideSourceIdentifier hides the value that identifies the source replay,
+// such as the game fingerprint, the checksum or the path of the replay.
+// These values can be matched against the original replays holding the exact
+// values that were anonymized or coarsened. The value is replaced with its keyed
+// HMAC if the anonymizer holds a key, so that it can still be used for grouping,
+// and it is removed otherwise.
+func hideSourceIdentifier(value string, anonymizer Anonymizer) string {
+
+	if value == "" {
+		return ""
+	}
+	hmacAnonymizer, ok := anonymizer.(*HMACAnonymizer)
+	if !ok {
+		return ""
+	}
+
+	return hmacAnonymizer.keyedHash(value)
+}
+
+// hideIndexEntrySource hides the checksum and the path of the source replay
+// in the index entry.
+func hideIndexEntrySource(
+	indexEntry *persistent_data.ReplayIndexEntry,
+	anonymizer Anonymizer,
+) {
+
+	indexEntry.ReplayFile = hideSourceIdentifier(indexEntry.ReplayFile, anonymizer)
+	indexEntry.SourceSHA256 = hideSourceIdentifier(indexEntry.SourceSHA256, anonymizer)
+}
+
+// coarsenReplay generalizes the quasi-identifiers of the replay,
+// the attributes that could identify the players when they are combined.
+// The time of the game is truncated to the timePrecision, MMR is floored
+// to the multiple of mmrBucketSize, APM is rounded to the multiple of apmRoundTo
+// and the region and the realm of the players are removed if removeRegion is set.
+// The toons are left intact, removing the region requires the player anonymization.
+// Zero disables the MMR and APM coarsening.
+func coarsenReplay(
+	replayData *replay_data.CleanedReplay,
+	timePrecision datastruct.TimePrecisionEnum,
+	mmrBucketSize int,
+	apmRoundTo int,
+	removeRegion bool,
+) {
+
+	log.Debug("Entered coarsenReplay()")
+
+	replayData.Details.TimeUTC = truncateTime(replayData.Details.TimeUTC, timePrecision)
+
+	for toon, player := range replayData.ToonPlayerDescMap {
+		if mmrBucketSize > 0 {
+			bucketSize := float64(mmrBucketSize)
+			player.MMR = math.Floor(player.MMR/bucketSize) * bucketSize
+		}
+		if apmRoundTo > 0 {
+			roundTo := float64(apmRoundTo)
+			player.APM = math.Round(player.APM/roundTo) * roundTo
+		}
+		if removeRegion {
+			player.Region = ""
+			player.Realm = ""
+		}
+		replayData.ToonPlayerDescMap[toon] = player
+	}
+
+	log.Debug("Finished coarsenReplay()")
+}
+
+// truncateTime truncates the time to the start of its day or week in UTC.
+func truncateTime(
+	timeUTC time.Time,
+	timePrecision datastruct.TimePrecisionEnum,
+) time.Time {
+
+	if timePrecision == datastruct.FullTimePrecision || timeUTC.IsZero() {
+		return timeUTC
+	}
+
+	year, month, day := timeUTC.UTC().Date()
+	truncatedTime := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if timePrecision == datastruct.WeekTimePrecision {
+		// Weeks start on Monday:
+		daysSinceMonday := (int(truncatedTime.Weekday()) + 6) % 7
+		truncatedTime = truncatedTime.AddDate(0, 0, -daysSinceMonday)
+	}
+
+	return truncatedTime
+}
+
+// quasiIdentifiers returns the values of all of the
+// settings.QuasiIdentifierAttributes of the replay. The values of the players
+// are sorted and joined, so that they do not depend on the order of the players.
+func quasiIdentifiers(replayData *replay_data.CleanedReplay) map[string]string {
+
+	var mmrs, apms, leagues, regions, realms []string
+	for _, player := range replayData.ToonPlayerDescMap {
+		mmrs = append(mmrs, strconv.FormatFloat(player.MMR, 'f', -1, 64))
+		apms = append(apms, strconv.FormatFloat(player.APM, 'f', -1, 64))
+		leagues = append(leagues, player.HighestLeague)
+		regions = append(regions, player.Region)
+		realms = append(realms, player.Realm)
+	}
+
+	gameVersion := replayData.Metadata.GameVersion
+	if gameVersion == "" {
+		gameVersion = replayData.Header.Version
+	}
+
+	return map[string]string{
+		"date":        replayData.Details.TimeUTC.UTC().Format(time.RFC3339),
+		"map":         replayData.Metadata.MapName,
+		"matchup":     teamMatchup(replayData.ToonPlayerDescMap),
+		"gameVersion": gameVersion,
+		"mmr":         sortedJoin(mmrs),
+		"apm":         sortedJoin(apms),
+		"league":      sortedJoin(leagues),
+		"region":      sortedJoin(regions),
+		"realm":       sortedJoin(realms),
+	}
+}
+
+// sortedJoin joins the sorted values with commas, skipping the empty values
+// such as the regions that were removed.
+func sortedJoin(values []string) string {
+
+	nonEmptyValues := []string{}
+	for _, value := range values {
+		if value != "" {
+			nonEmptyValues = append(nonEmptyValues, value)
+		}
+	}
+	sort.Strings(nonEmptyValues)
+
+	return strings.Join(nonEmptyValues, ",")
+}
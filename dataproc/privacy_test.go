@@ -0,0 +1,148 @@
+package dataproc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+)
+
+// TestTruncateTime verifies that the time is truncated to the start
+// of the day or of the week starting on Monday.
+func TestTruncateTime(t *testing.T) {
+
+	// Sunday:
+	gameTime := time.Date(2024, 3, 10, 21, 15, 30, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		timePrecision datastruct.TimePrecisionEnum
+		expected      time.Time
+	}{
+		{name: "full", timePrecision: datastruct.FullTimePrecision, expected: gameTime},
+		{name: "day", timePrecision: datastruct.DayTimePrecision, expected: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{name: "week", timePrecision: datastruct.WeekTimePrecision, expected: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			truncatedTime := truncateTime(gameTime, testCase.timePrecision)
+			if !truncatedTime.Equal(testCase.expected) {
+				t.Errorf("Test Failed! Expected %v, got %v", testCase.expected, truncatedTime)
+			}
+		})
+	}
+}
+
+// TestCoarsenReplay verifies that MMR is bucketed, APM is rounded,
+// the region is removed and that the quasi-identifiers use the coarsened values.
+func TestCoarsenReplay(t *testing.T) {
+
+	replayData := &replay_data.CleanedReplay{
+		Details: replay_data.CleanedDetails{
+			TimeUTC: time.Date(2024, 3, 10, 21, 15, 30, 0, time.UTC),
+		},
+		ToonPlayerDescMap: map[string]replay_data.EnhancedToonDescMap{
+			"1": {MMR: 3499, APM: 187.4, Region: "Europe", Realm: "Europe", AssignedRace: "Zerg", TeamID: 1},
+			"2": {MMR: 0, APM: 92.6, Region: "Europe", Realm: "Europe", AssignedRace: "Protoss"},
+		},
+	}
+
+	coarsenReplay(replayData, datastruct.DayTimePrecision, 500, 10, true)
+
+	expectedPlayers := map[string][2]float64{"1": {3000, 190}, "2": {0, 90}}
+	for toon, expected := range expectedPlayers {
+		player := replayData.ToonPlayerDescMap[toon]
+		if player.MMR != expected[0] || player.APM != expected[1] {
+			t.Errorf(
+				"Test Failed! Expected MMR %v and APM %v, got %v and %v",
+				expected[0],
+				expected[1],
+				player.MMR,
+				player.APM,
+			)
+		}
+		if player.Region != "" || player.Realm != "" {
+			t.Errorf("Test Failed! Expected the region to be removed, got %+v", player)
+		}
+	}
+
+	expectedQuasiIdentifiers := map[string]string{
+		"date":    "2024-03-10T00:00:00Z",
+		"matchup": "P-vs-Z",
+		"mmr":     "0,3000",
+		"apm":     "190,90",
+		"region":  "",
+	}
+	identifiers := quasiIdentifiers(replayData)
+	for attribute, expected := range expectedQuasiIdentifiers {
+		if identifiers[attribute] != expected {
+			t.Errorf(
+				"Test Failed! Expected %q for %s, got %q",
+				expected,
+				attribute,
+				identifiers[attribute],
+			)
+		}
+	}
+}
+
+// TestHideIndexEntrySource verifies that the identifiers of the source replay
+// are replaced with their keyed HMAC if the anonymizer holds a key
+// and that they are removed otherwise.
+func TestHideIndexEntrySource(t *testing.T) {
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	err := os.WriteFile(keyFile, []byte("secret"), 0644)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot write the key file: %v", err)
+	}
+	hmacAnonymizer, err := NewHMACAnonymizer(keyFile)
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	localDBAnonymizer, err := NewLocalDBAnonymizer(
+		filepath.Join(t.TempDir(), "anonymized_toons.jsonl"),
+	)
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	defer localDBAnonymizer.Close()
+
+	indexEntry := func() persistent_data.ReplayIndexEntry {
+		return persistent_data.ReplayIndexEntry{
+			ReplayFile:      "pack/Alice vs Bob.SC2Replay",
+			SourceSHA256:    "checksum",
+			GameFingerprint: "fingerprint",
+		}
+	}
+
+	firstEntry := indexEntry()
+	hideIndexEntrySource(&firstEntry, hmacAnonymizer)
+	secondEntry := indexEntry()
+	hideIndexEntrySource(&secondEntry, hmacAnonymizer)
+	if len(firstEntry.ReplayFile) != 64 || len(firstEntry.SourceSHA256) != 64 {
+		t.Errorf("Test Failed! Expected the keyed HMAC of the source, got %+v", firstEntry)
+	}
+	if firstEntry.ReplayFile != secondEntry.ReplayFile {
+		t.Errorf("Test Failed! Expected the same HMAC of the same replay file")
+	}
+
+	for name, anonymizer := range map[string]Anonymizer{
+		"without anonymizer": nil,
+		"local database":     localDBAnonymizer,
+	} {
+		entry := indexEntry()
+		hideIndexEntrySource(&entry, anonymizer)
+		if entry.ReplayFile != "" || entry.SourceSHA256 != "" {
+			t.Errorf("Test Failed! Expected the source to be removed %s, got %+v", name, entry)
+		}
+		if hideSourceIdentifier(entry.GameFingerprint, anonymizer) != "" {
+			t.Errorf("Test Failed! Expected the fingerprint to be removed %s", name)
+		}
+	}
+}
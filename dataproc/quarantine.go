@@ -0,0 +1,117 @@
+package dataproc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// quarantineSidecarExtension is appended to the name of the quarantined replay
+// to create the name of the sidecar describing the rejection.
+const quarantineSidecarExtension = ".json"
+
+// maxQuarantineReasonLength limits the length of the directory names
+// that are created from the rejection reasons.
+const maxQuarantineReasonLength = 100
+
+// quarantineReplay places the rejected replay in the quarantine directory under
+// <stage>/<reason>/<path relative to the input directory>
+// next to a sidecar JSON describing why the replay was rejected.
+func quarantineReplay(
+	replayFile string,
+	rejectedReplay persistent_data.RejectedReplay,
+	inputDirectory string,
+	quarantineDirectory string,
+	quarantineMode datastruct.QuarantineModeEnum,
+) bool {
+
+	log.Debug("Entered quarantineReplay()")
+
+	quarantinePath := filepath.Join(
+		quarantineDirectory,
+		quarantineDirectoryName(rejectedReplay.Stage),
+		quarantineDirectoryName(rejectedReplay.Reason),
+		quarantineRelativePath(replayFile, inputDirectory),
+	)
+
+	err := file_utils.LinkOrCopyFile(
+		replayFile,
+		quarantinePath,
+		quarantineMode == datastruct.HardLinkQuarantineMode,
+	)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"replayFile":     replayFile,
+			"quarantinePath": quarantinePath,
+			"error":          err,
+		}).Error("Failed to place the replay in the quarantine directory!")
+		return false
+	}
+
+	quarantinedReplay := persistent_data.NewQuarantinedReplay(replayFile, rejectedReplay)
+	sidecarBytes, err := json.MarshalIndent(quarantinedReplay, "", "  ")
+	if err != nil {
+		log.WithField("error", err).Error("Failed to marshal the quarantine sidecar!")
+		return false
+	}
+	err = os.WriteFile(quarantinePath+quarantineSidecarExtension, sidecarBytes, 0777)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"replayFile":     replayFile,
+			"quarantinePath": quarantinePath,
+			"error":          err,
+		}).Error("Failed to save the quarantine sidecar!")
+		return false
+	}
+
+	log.Debug("Finished quarantineReplay()")
+	return true
+}
+
+// quarantineRelativePath returns the path of the replay relative to the input directory,
+// so that the replays with the same names in different directories do not collide.
+// Replays outside of the input directory are placed under their names.
+func quarantineRelativePath(replayFile string, inputDirectory string) string {
+
+	relativePath, err := filepath.Rel(inputDirectory, replayFile)
+	if err != nil || relativePath == ".." ||
+		strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return filepath.Base(replayFile)
+	}
+
+	return relativePath
+}
+
+// quarantineDirectoryName turns a stage or a rejection reason into a directory name
+// by replacing everything but letters and digits with underscores.
+func quarantineDirectoryName(reason string) string {
+
+	directoryName := strings.Map(func(character rune) rune {
+		if unicode.IsLetter(character) || unicode.IsDigit(character) {
+			return character
+		}
+		return '_'
+	}, reason)
+
+	// Collapsing the underscores of the punctuation such as "() failed: ":
+	for strings.Contains(directoryName, "__") {
+		directoryName = strings.ReplaceAll(directoryName, "__", "_")
+	}
+	directoryName = strings.Trim(directoryName, "_")
+
+	if characters := []rune(directoryName); len(characters) > maxQuarantineReasonLength {
+		directoryName = strings.TrimRight(string(characters[:maxQuarantineReasonLength]), "_")
+	}
+	if directoryName == "" {
+		return "unknown"
+	}
+
+	return directoryName
+}
@@ -0,0 +1,102 @@
+package dataproc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+)
+
+// TestQuarantineReplay verifies that the rejected replay is placed under
+// the stage and reason directories next to the sidecar describing the rejection.
+func TestQuarantineReplay(t *testing.T) {
+
+	inputDirectory := t.TempDir()
+	quarantineDirectory := t.TempDir()
+	replayFile := filepath.Join(inputDirectory, "2021", "replay.SC2Replay")
+	err := os.MkdirAll(filepath.Dir(replayFile), 0777)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot create the input directory: %v", err)
+	}
+	err = os.WriteFile(replayFile, []byte("replay"), 0777)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot create the replay: %v", err)
+	}
+
+	rejectedReplay := persistent_data.RejectedReplay{
+		Stage:      persistent_data.RejectionStageValidity,
+		Reason:     "validateReplay() failed: maxMMR",
+		Violations: []string{"maxMMR"},
+		Details:    map[string]string{"maxMMR": "MMR of the players [8100] is outside of the maxMMR 8000"},
+		MapName:    "Map",
+	}
+
+	for _, quarantineMode := range []datastruct.QuarantineModeEnum{
+		datastruct.HardLinkQuarantineMode,
+		datastruct.CopyQuarantineMode,
+	} {
+		ok := quarantineReplay(
+			replayFile,
+			rejectedReplay,
+			inputDirectory,
+			quarantineDirectory,
+			quarantineMode,
+		)
+		if !ok {
+			t.Fatalf("Test Failed! quarantineReplay() failed for mode %v", quarantineMode)
+		}
+	}
+
+	quarantinePath := filepath.Join(
+		quarantineDirectory,
+		"validity",
+		"validateReplay_failed_maxMMR",
+		"2021",
+		"replay.SC2Replay",
+	)
+	replayBytes, err := os.ReadFile(quarantinePath)
+	if err != nil || string(replayBytes) != "replay" {
+		t.Fatalf("Test Failed! Quarantined replay is not available: %v", err)
+	}
+
+	sidecarBytes, err := os.ReadFile(quarantinePath + quarantineSidecarExtension)
+	if err != nil {
+		t.Fatalf("Test Failed! Quarantine sidecar is not available: %v", err)
+	}
+	quarantinedReplay := persistent_data.QuarantinedReplay{}
+	err = json.Unmarshal(sidecarBytes, &quarantinedReplay)
+	if err != nil {
+		t.Fatalf("Test Failed! Cannot unmarshal the sidecar: %v", err)
+	}
+	if quarantinedReplay.ReplayFile != replayFile ||
+		quarantinedReplay.Stage != persistent_data.RejectionStageValidity ||
+		quarantinedReplay.Details["maxMMR"] == "" ||
+		quarantinedReplay.MapName != "Map" {
+		t.Errorf("Test Failed! Unexpected sidecar: %+v", quarantinedReplay)
+	}
+}
+
+// TestQuarantineDirectoryName verifies that the reasons are turned into directory names.
+func TestQuarantineDirectoryName(t *testing.T) {
+
+	testCases := map[string]string{
+		"checkIntegrity() failed: Player lists length mismatch!": "checkIntegrity_failed_Player_lists_length_mismatch",
+		"filter expression did not match":                        "filter_expression_did_not_match",
+		"../..":                                                  "unknown",
+	}
+
+	for reason, expectedName := range testCases {
+		directoryName := quarantineDirectoryName(reason)
+		if directoryName != expectedName {
+			t.Errorf(
+				"Test Failed! Expected %q for %q, got %q",
+				expectedName,
+				reason,
+				directoryName,
+			)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package dataproc
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/icza/s2prot/rep"
+)
+
+// newRejectedReplay describes a replay rejected at the stage of the processing,
+// the information about the replay is read from the raw replay if it is available.
+func newRejectedReplay(
+	stage string,
+	reason string,
+	replayData *rep.Rep,
+) persistent_data.RejectedReplay {
+
+	rejectedReplay := persistent_data.RejectedReplay{
+		Stage:  stage,
+		Reason: reason,
+	}
+	if replayData == nil {
+		return rejectedReplay
+	}
+
+	rejectedReplay.GameVersion = replayData.Metadata.GameVersion()
+	if rejectedReplay.GameVersion == "" {
+		rejectedReplay.GameVersion = replayData.Header.VersionString()
+	}
+	rejectedReplay.MapName = replayData.Metadata.Title()
+	if rejectedReplay.MapName == "" {
+		rejectedReplay.MapName = replayData.Details.Title()
+	}
+	replayTime := replayData.Details.TimeUTC()
+	if !replayTime.IsZero() {
+		rejectedReplay.Date = summaryDate(replayTime)
+	}
+
+	return rejectedReplay
+}
+
+// newRejectedUnreadableReplay describes a replay that could not be read,
+// the error returned by s2prot is kept with the rejected replay.
+func newRejectedUnreadableReplay(
+	reason string,
+	err error,
+) persistent_data.RejectedReplay {
+
+	rejectedReplay := newRejectedReplay(
+		persistent_data.RejectionStageRead,
+		reason,
+		nil,
+	)
+	rejectedReplay.Error = err.Error()
+
+	return rejectedReplay
+}
+
+// newRejectedValidityReplay describes a replay rejected by the validity checks,
+// every violated rule is counted separately in the summaries.
+func newRejectedValidityReplay(
+	violations []validityViolation,
+	replayData *rep.Rep,
+) persistent_data.RejectedReplay {
+
+	rules := []string{}
+	details := make(map[string]string)
+	for _, violation := range violations {
+		rules = append(rules, violation.rule)
+		details[violation.rule] = violation.message
+	}
+
+	rejectedReplay := newRejectedReplay(
+		persistent_data.RejectionStageValidity,
+		"validateReplay() failed: "+strings.Join(rules, ", "),
+		replayData,
+	)
+	rejectedReplay.Violations = rules
+	rejectedReplay.Details = details
+
+	return rejectedReplay
+}
+
+// newRejectedCleanedReplay describes a replay rejected at the stage
+// of the processing that follows the cleanup.
+func newRejectedCleanedReplay(
+	stage string,
+	reason string,
+	replayData *replay_data.CleanedReplay,
+) persistent_data.RejectedReplay {
+
+	gameVersion := replayData.Metadata.GameVersion
+	if gameVersion == "" {
+		gameVersion = replayData.Header.Version
+	}
+
+	return persistent_data.RejectedReplay{
+		Stage:       stage,
+		Reason:      reason,
+		GameVersion: gameVersion,
+		MapName:     replayData.Metadata.MapName,
+		Date:        summaryDate(replayData.Details.TimeUTC),
+	}
+}
+
+// summaryDate formats the date of the replay the way it is counted in the summaries.
+func summaryDate(replayTime time.Time) string {
+	year, month, day := replayTime.Date()
+	return strconv.Itoa(year) + "-" + strconv.Itoa(int(month)) + "-" + strconv.Itoa(day)
+}
@@ -0,0 +1,93 @@
+package dataproc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// gameFingerprint identifies the game that was recorded in the replay.
+// Replays of the same game that were saved by different players share
+// the fingerprint. It must be calculated before the players are anonymized
+// and it is hidden with hideSourceIdentifier if any of the privacy options is set.
+func gameFingerprint(replayData *replay_data.CleanedReplay) string {
+
+	log.Debug("Entered gameFingerprint()")
+
+	fingerprintElements := []string{
+		replayData.Details.TimeUTC.UTC().Format("2006-01-02T15:04:05Z"),
+		fmt.Sprintf("%d", replayData.InitData.GameDescription.MapFileSyncChecksum),
+	}
+	fingerprintElements = append(
+		fingerprintElements,
+		sortedToonKeys(replayData.ToonPlayerDescMap)...,
+	)
+
+	checksum := sha256.Sum256([]byte(strings.Join(fingerprintElements, "|")))
+
+	log.Debug("Finished gameFingerprint()")
+	return hex.EncodeToString(checksum[:])
+}
+
+// createReplayIndexEntry gathers the information that is required
+// to find the processed replay in the output package.
+// The replay file is saved relative to the input directory, so that the index
+// does not depend on where the replays were stored.
+func createReplayIndexEntry(
+	replayFile string,
+	inputDirectory string,
+	replayData *replay_data.CleanedReplay,
+	replaySections []replaySection,
+	outputName replayOutputName,
+	packageFilename string,
+) persistent_data.ReplayIndexEntry {
+
+	log.Debug("Entered createReplayIndexEntry()")
+
+	var entryNames []string
+	var outputSize int64
+	for _, section := range replaySections {
+		entryNames = append(entryNames, outputName.archivePath(section.filename))
+		outputSize += int64(len(section.contents))
+	}
+
+	// Index is still created if the checksum is not available:
+	sourceSHA256, err := file_utils.FileSHA256(replayFile)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"replayFile": replayFile,
+			"error":      err,
+		}).Error("Failed to calculate the checksum of the replay.")
+	}
+
+	gameVersion := replayData.Metadata.GameVersion
+	if gameVersion == "" {
+		gameVersion = replayData.Header.Version
+	}
+
+	indexEntry := persistent_data.ReplayIndexEntry{
+		ReplayFile:      filepath.ToSlash(quarantineRelativePath(replayFile, inputDirectory)),
+		Package:         packageFilename,
+		EntryNames:      entryNames,
+		SourceSHA256:    sourceSHA256,
+		OutputSize:      outputSize,
+		GameFingerprint: replayData.GameFingerprint,
+		Map:             replayData.Metadata.MapName,
+		Matchup:         teamMatchup(replayData.ToonPlayerDescMap),
+		Date:            replayData.Details.TimeUTC,
+		GameVersion:     gameVersion,
+		DurationSeconds: float64(replayData.Header.ElapsedGameLoops) / gameLoopsPerSecond,
+		// Quasi-identifiers are saved after the replay was coarsened:
+		QuasiIdentifiers: quasiIdentifiers(replayData),
+	}
+
+	log.Debug("Finished createReplayIndexEntry()")
+	return indexEntry
+}
@@ -0,0 +1,284 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// HTMLReportFilename is the name of the self-contained HTML report.
+	HTMLReportFilename = "report.html"
+	// MarkdownReportFilename is the name of the Markdown report.
+	MarkdownReportFilename = "report.md"
+	// otherLabel is used for the values that did not fit into the shown rows.
+	otherLabel = "Other"
+)
+
+// Report holds the sections of the dataset report
+// that are rendered both as HTML and Markdown.
+type Report struct {
+	Title         string
+	SchemaVersion string
+	Sources       []string
+	Sections      []reportSection
+}
+
+// reportSection is a single table and chart of the report.
+type reportSection struct {
+	Title       string
+	Description string
+	Rows        []reportRow
+	// Statistics are set only for the numeric distributions:
+	Statistics []reportStatistic
+}
+
+// reportRow is a single value of a section along with its count.
+type reportRow struct {
+	Label string
+	Count int64
+	Share float64
+}
+
+// reportStatistic is a named descriptive statistic of a numeric distribution.
+type reportStatistic struct {
+	Name  string
+	Value float64
+}
+
+// NewReport prepares the report sections from the merged summaries
+// and the counts of the failure reasons of the files that failed to process.
+// Only the maxRows most frequent values of the categorical sections are shown,
+// the remaining ones are counted as "Other".
+func NewReport(
+	title string,
+	datasetSummary persistent_data.DatasetSummary,
+	failureReasons map[string]int64,
+	maxRows int,
+) Report {
+
+	log.Debug("Entered NewReport()")
+
+	summary := datasetSummary.Summary
+	summary.CalculateStatistics()
+
+	sections := []reportSection{
+		countSection("Game Versions", "Number of replays per game version.", summary.GameVersions, maxRows),
+		countSection("Maps", "Number of replays per map.", summary.Maps, maxRows),
+		countSection("Matchups", "Number of replays per matchup, built from the races of each team.", summary.MatchupCount, maxRows),
+		countSection("Races", "Number of players per race.", summary.Races, maxRows),
+		distributionSection("Game Length", "Game length in seconds.", summary.GameLengthSeconds),
+		distributionSection("APM", "Actions per minute of the players.", summary.APM),
+		distributionSection("MMR", "Matchmaking rating of the players of ranked games.", summary.MMR),
+		countSection("Leagues", "Number of players per highest league.", summary.Leagues, maxRows),
+		dateSection("Dates", "Number of replays per month.", summary.Dates),
+		countSection("Servers", "Number of replays per server.", summary.Servers, maxRows),
+		countSection("Units", "Number of units born, summed over all of the replays.", summary.Units, maxRows),
+		countSection("Rejections by Stage", "Number of replays rejected per stage of the processing.", summary.Rejected.Stages, maxRows),
+		countSection("Rejection Reasons", "Number of replays rejected per stage and reason.", stageReasons(summary.Rejected.Reasons), maxRows),
+		countSection("Rejected Game Versions", "Number of rejected replays per game version.", summary.Rejected.GameVersions, maxRows),
+		countSection("Rejected Maps", "Number of rejected replays per map.", summary.Rejected.Maps, maxRows),
+		dateSection("Rejected Dates", "Number of rejected replays per month.", summary.Rejected.Dates),
+		countSection("Failure Reasons", "Number of replays that failed to process per reason, read from the processing logs.", failureReasons, maxRows),
+	}
+
+	log.Debug("Finished NewReport()")
+	return Report{
+		Title:         title,
+		SchemaVersion: datasetSummary.SchemaVersion,
+		Sources:       datasetSummary.Sources,
+		Sections:      sections,
+	}
+}
+
+// SaveReport renders the report as HTML and Markdown
+// and saves both of the files in the output directory.
+func SaveReport(report Report, outputDirectory string) error {
+
+	log.Debug("Entered SaveReport()")
+
+	err := file_utils.GetOrCreateDirectory(outputDirectory)
+	if err != nil {
+		return err
+	}
+
+	htmlReport, err := renderHTML(report)
+	if err != nil {
+		return fmt.Errorf("failed to render the HTML report: %v", err)
+	}
+	renderedReports := map[string]string{
+		HTMLReportFilename:     htmlReport,
+		MarkdownReportFilename: renderMarkdown(report),
+	}
+
+	for filename, renderedReport := range renderedReports {
+		reportPath := filepath.Join(outputDirectory, filename)
+		err = os.WriteFile(reportPath, []byte(renderedReport), 0666)
+		if err != nil {
+			return fmt.Errorf("failed to write the report %s: %v", reportPath, err)
+		}
+		log.WithField("reportPath", reportPath).Info("Saved the report.")
+	}
+
+	log.Debug("Finished SaveReport()")
+	return nil
+}
+
+// countSection creates a section of the counts sorted in descending order.
+func countSection(
+	title string,
+	description string,
+	counts map[string]int64,
+	maxRows int,
+) reportSection {
+
+	rows := make([]reportRow, 0, len(counts))
+	for label, count := range counts {
+		rows = append(rows, reportRow{Label: label, Count: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Label < rows[j].Label
+	})
+
+	if maxRows > 0 && len(rows) > maxRows {
+		otherRow := reportRow{Label: otherLabel}
+		for _, row := range rows[maxRows:] {
+			otherRow.Count += row.Count
+		}
+		rows = append(rows[:maxRows], otherRow)
+	}
+
+	return reportSection{
+		Title:       title,
+		Description: description,
+		Rows:        withShares(rows),
+	}
+}
+
+// dateSection creates a section of the counts per month in chronological order.
+// Dates of the summaries are formatted as year-month-day without padding.
+func dateSection(
+	title string,
+	description string,
+	dates map[string]int64,
+) reportSection {
+
+	monthCounts := make(map[[2]int]int64)
+	for date, count := range dates {
+		dateParts := strings.Split(date, "-")
+		if len(dateParts) < 2 {
+			continue
+		}
+		year, errYear := strconv.Atoi(dateParts[0])
+		month, errMonth := strconv.Atoi(dateParts[1])
+		if errYear != nil || errMonth != nil {
+			log.WithField("date", date).Warn("Skipping a date that could not be parsed.")
+			continue
+		}
+		monthCounts[[2]int{year, month}] += count
+	}
+
+	months := make([][2]int, 0, len(monthCounts))
+	for month := range monthCounts {
+		months = append(months, month)
+	}
+	sort.Slice(months, func(i, j int) bool {
+		if months[i][0] != months[j][0] {
+			return months[i][0] < months[j][0]
+		}
+		return months[i][1] < months[j][1]
+	})
+
+	rows := make([]reportRow, 0, len(months))
+	for _, month := range months {
+		rows = append(rows, reportRow{
+			Label: fmt.Sprintf("%04d-%02d", month[0], month[1]),
+			Count: monthCounts[month],
+		})
+	}
+
+	return reportSection{
+		Title:       title,
+		Description: description,
+		Rows:        withShares(rows),
+	}
+}
+
+// distributionSection creates a section of the histogram
+// and the statistics of a numeric distribution.
+func distributionSection(
+	title string,
+	description string,
+	distribution persistent_data.NumericDistribution,
+) reportSection {
+
+	rows := make([]reportRow, 0, len(distribution.BinCounts))
+	for i, count := range distribution.BinCounts {
+		rows = append(rows, reportRow{
+			Label: persistent_data.BinLabel(distribution.BinEdges, i),
+			Count: count,
+		})
+	}
+
+	statistics := []reportStatistic{
+		{Name: "count", Value: float64(distribution.Count)},
+		{Name: "min", Value: distribution.Min},
+		{Name: "max", Value: distribution.Max},
+		{Name: "mean", Value: distribution.Mean},
+		{Name: "median", Value: distribution.Median},
+	}
+	for _, p := range persistent_data.SummaryPercentiles {
+		name := fmt.Sprintf("p%g", p)
+		statistics = append(statistics, reportStatistic{
+			Name:  name,
+			Value: distribution.Percentiles[name],
+		})
+	}
+
+	return reportSection{
+		Title:       title,
+		Description: description,
+		Rows:        withShares(rows),
+		Statistics:  statistics,
+	}
+}
+
+// stageReasons flattens the reasons of every stage into "stage: reason" keys.
+func stageReasons(reasons map[string]map[string]int64) map[string]int64 {
+
+	flattenedReasons := make(map[string]int64)
+	for stage, stageReasons := range reasons {
+		for reason, count := range stageReasons {
+			flattenedReasons[stage+": "+reason] += count
+		}
+	}
+
+	return flattenedReasons
+}
+
+// withShares sets the share of every row in the total count of the section.
+func withShares(rows []reportRow) []reportRow {
+
+	var total int64
+	for _, row := range rows {
+		total += row.Count
+	}
+	if total == 0 {
+		return rows
+	}
+	for i := range rows {
+		rows[i].Share = float64(rows[i].Count) / float64(total)
+	}
+
+	return rows
+}
@@ -0,0 +1,163 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// Layout of the SVG bar charts in pixels.
+const (
+	barHeight  = 18
+	barSpacing = 4
+	labelWidth = 220
+	chartWidth = 720
+	countWidth = 90
+)
+
+// htmlTemplate is the self-contained page of the report.
+// It does not load any external resources, so it can be opened offline.
+var htmlTemplate = template.Must(template.New("report").
+	Funcs(template.FuncMap{"percent": percent}).
+	Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 960px; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+td.number { text-align: right; }
+svg text { font-size: 12px; font-family: sans-serif; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Schema version: {{.SchemaVersion}}</p>
+{{- if .Sources}}
+<details><summary>Merged summaries ({{len .Sources}})</summary>
+<ul>
+{{- range .Sources}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</details>
+{{- end}}
+{{- range .Sections}}
+<section>
+<h2>{{.Title}}</h2>
+<p>{{.Description}}</p>
+{{- if .Rows}}
+{{.Chart}}
+{{- if .Statistics}}
+<table>
+<tr>{{range .Statistics}}<th>{{.Name}}</th>{{end}}</tr>
+<tr>{{range .Statistics}}<td class="number">{{printf "%.2f" .Value}}</td>{{end}}</tr>
+</table>
+{{- end}}
+<table>
+<tr><th>Value</th><th>Count</th><th>Share</th></tr>
+{{- range .Rows}}
+<tr><td>{{.Label}}</td><td class="number">{{.Count}}</td><td class="number">{{printf "%.2f%%" (percent .Share)}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No data.</p>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// htmlSection extends the section with its rendered chart.
+type htmlSection struct {
+	reportSection
+	Chart template.HTML
+}
+
+// renderHTML renders the report as a self-contained HTML page with inline SVG charts.
+func renderHTML(report Report) (string, error) {
+
+	sections := make([]htmlSection, 0, len(report.Sections))
+	for _, section := range report.Sections {
+		sections = append(sections, htmlSection{
+			reportSection: section,
+			Chart:         template.HTML(barChartSVG(section.Rows)),
+		})
+	}
+
+	var rendered strings.Builder
+	err := htmlTemplate.Execute(&rendered, struct {
+		Report
+		Sections []htmlSection
+	}{
+		Report:   report,
+		Sections: sections,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
+
+// barChartSVG draws a horizontal bar chart of the rows as an inline SVG element.
+// Labels are escaped, as they come from the replays.
+func barChartSVG(rows []reportRow) string {
+
+	var maxCount int64
+	for _, row := range rows {
+		if row.Count > maxCount {
+			maxCount = row.Count
+		}
+	}
+
+	barAreaWidth := chartWidth - labelWidth - countWidth
+	height := len(rows) * (barHeight + barSpacing)
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img">`,
+		chartWidth, height, chartWidth, height,
+	)
+	for i, row := range rows {
+		y := i * (barHeight + barSpacing)
+		barWidth := 0
+		if maxCount > 0 {
+			barWidth = int(float64(barAreaWidth) * float64(row.Count) / float64(maxCount))
+		}
+		fmt.Fprintf(&svg,
+			`<text x="%d" y="%d" text-anchor="end">%s</text>`,
+			labelWidth-6, y+barHeight-5, html.EscapeString(truncateLabel(row.Label)),
+		)
+		fmt.Fprintf(&svg,
+			`<rect x="%d" y="%d" width="%d" height="%d" fill="#4e79a7"><title>%s: %d</title></rect>`,
+			labelWidth, y, barWidth, barHeight, html.EscapeString(row.Label), row.Count,
+		)
+		fmt.Fprintf(&svg,
+			`<text x="%d" y="%d">%d</text>`,
+			labelWidth+barWidth+6, y+barHeight-5, row.Count,
+		)
+	}
+	svg.WriteString(`</svg>`)
+
+	return svg.String()
+}
+
+// truncateLabel shortens the labels that would not fit next to the chart.
+func truncateLabel(label string) string {
+	const maxLabelLength = 32
+	runes := []rune(label)
+	if len(runes) <= maxLabelLength {
+		return label
+	}
+	return string(runes[:maxLabelLength-1]) + "…"
+}
+
+// percent converts a share into a percentage.
+func percent(share float64) float64 {
+	return share * 100
+}
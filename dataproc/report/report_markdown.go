@@ -0,0 +1,60 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderMarkdown renders the report as Markdown tables.
+func renderMarkdown(report Report) string {
+
+	var markdown strings.Builder
+	fmt.Fprintf(&markdown, "# %s\n\n", escapeMarkdown(report.Title))
+	fmt.Fprintf(&markdown, "Schema version: %s\n\n", report.SchemaVersion)
+	if len(report.Sources) > 0 {
+		fmt.Fprintf(&markdown, "Merged summaries: %d\n\n", len(report.Sources))
+	}
+
+	for _, section := range report.Sections {
+		fmt.Fprintf(&markdown, "## %s\n\n%s\n\n", section.Title, section.Description)
+		if len(section.Rows) == 0 {
+			markdown.WriteString("No data.\n\n")
+			continue
+		}
+
+		if len(section.Statistics) > 0 {
+			var names, separators, values []string
+			for _, statistic := range section.Statistics {
+				names = append(names, statistic.Name)
+				separators = append(separators, "---:")
+				values = append(values, fmt.Sprintf("%.2f", statistic.Value))
+			}
+			fmt.Fprintf(&markdown, "| %s |\n", strings.Join(names, " | "))
+			fmt.Fprintf(&markdown, "| %s |\n", strings.Join(separators, " | "))
+			fmt.Fprintf(&markdown, "| %s |\n\n", strings.Join(values, " | "))
+		}
+
+		markdown.WriteString("| Value | Count | Share |\n")
+		markdown.WriteString("| --- | ---: | ---: |\n")
+		for _, row := range section.Rows {
+			fmt.Fprintf(&markdown, "| %s | %d | %.2f%% |\n",
+				escapeMarkdown(row.Label), row.Count, percent(row.Share))
+		}
+		markdown.WriteString("\n")
+	}
+
+	return markdown.String()
+}
+
+// escapeMarkdown escapes the characters that would break the Markdown tables.
+func escapeMarkdown(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		"|", `\|`,
+		"*", `\*`,
+		"_", `\_`,
+		"`", "\\`",
+		"\n", " ",
+	)
+	return replacer.Replace(text)
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+)
+
+// TestSaveReport verifies that both of the reports are created,
+// contain the sections and do not reference any external resources.
+func TestSaveReport(t *testing.T) {
+
+	datasetSummary := persistent_data.NewDatasetSummary(persistent_data.SummaryBins{
+		GameLengthSeconds: []float64{300, 600},
+	})
+	datasetSummary.Summary.Maps = map[string]int64{"<Map>": 3, "Map|2": 2, "Map3": 1}
+	datasetSummary.Summary.Dates = map[string]int64{"2021-5-3": 1, "2020-12-1": 2, "2021-5-9": 1}
+	datasetSummary.Summary.GameLengthSeconds.Add(200)
+	datasetSummary.Summary.GameLengthSeconds.Add(700)
+
+	report := NewReport(
+		"Test Dataset",
+		datasetSummary,
+		map[string]int64{"Failed to stringify the replay.": 2},
+		2,
+	)
+
+	outputDirectory := t.TempDir()
+	err := SaveReport(report, outputDirectory)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not save the report: %v", err)
+	}
+
+	htmlBytes, err := os.ReadFile(filepath.Join(outputDirectory, HTMLReportFilename))
+	if err != nil {
+		t.Fatalf("Test Failed! Could not read the HTML report: %v", err)
+	}
+	htmlReport := string(htmlBytes)
+	if !strings.Contains(htmlReport, "<svg") || strings.Contains(htmlReport, "<Map>") {
+		t.Errorf("Test Failed! HTML report misses charts or is not escaped.")
+	}
+	if strings.Contains(htmlReport, "src=") || strings.Contains(htmlReport, "<link") {
+		t.Errorf("Test Failed! HTML report references external resources.")
+	}
+
+	markdownBytes, err := os.ReadFile(filepath.Join(outputDirectory, MarkdownReportFilename))
+	if err != nil {
+		t.Fatalf("Test Failed! Could not read the Markdown report: %v", err)
+	}
+	markdownReport := string(markdownBytes)
+	expectedLines := []string{
+		"## Maps",
+		"| Map\\|2 | 2 | 33.33% |",
+		"| Other | 1 | 16.67% |",
+		"| 2020-12 | 2 | 50.00% |",
+		"| 2021-05 | 2 | 50.00% |",
+		"| 300 - 600 | 0 | 0.00% |",
+		"| Failed to stringify the replay. | 2 | 100.00% |",
+	}
+	for _, expectedLine := range expectedLines {
+		if !strings.Contains(markdownReport, expectedLine) {
+			t.Errorf("Test Failed! Markdown report misses %q", expectedLine)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc/cleanup"
 	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
 	"github.com/icza/s2prot/rep"
 	log "github.com/sirupsen/logrus"
 )
@@ -55,6 +56,9 @@ func redifineReplayStructure(
 		return replay_data.CleanedReplay{}, false
 	}
 
+	observers := cleanup.CleanObservers(replayData)
+	gameEnd := detectGameEnd(replayData)
+
 	messageEventsStructs := cleanup.CleanMessageEvents(replayData)
 	gameEventsStructs := cleanup.CleanGameEvents(replayData)
 	trackerEventsStructs := cleanup.CleanTrackerEvents(replayData)
@@ -63,6 +67,7 @@ func redifineReplayStructure(
 	justTrackerEvtsErr := replayData.TrackerEvtsErr
 	justGameEvtsErr := replayData.GameEvtsErr
 	cleanedReplay := replay_data.CleanedReplay{
+		SchemaVersion:     settings.OutputSchemaVersion,
 		Header:            cleanHeader,
 		InitData:          cleanInitData,
 		Details:           cleanDetails,
@@ -71,6 +76,8 @@ func redifineReplayStructure(
 		GameEvents:        gameEventsStructs,
 		TrackerEvents:     trackerEventsStructs,
 		ToonPlayerDescMap: enhancedToonDescMap,
+		Observers:         observers,
+		GameEnd:           gameEnd,
 		GameEvtsErr:       justGameEvtsErr,
 		MessageEvtsErr:    justMessageEvtsErr,
 		TrackerEvtsErr:    justTrackerEvtsErr,
@@ -0,0 +1,62 @@
+package dataproc
+
+import (
+	"archive/zip"
+	"time"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// saveReplaySectionsToArchive saves all of the sections of a replay into the zip writer.
+func saveReplaySectionsToArchive(
+	sections []replaySection,
+	outputName replayOutputName,
+	compressionMethod uint16,
+	modified time.Time,
+	writer *zip.Writer,
+) bool {
+
+	log.Debug("Entered saveReplaySectionsToArchive()")
+
+	for _, section := range sections {
+		savedSuccess := utils.SaveBytesToArchive(
+			[]byte(section.contents),
+			outputName.archivePath(section.filename),
+			compressionMethod,
+			modified,
+			writer,
+		)
+		if !savedSuccess {
+			return false
+		}
+	}
+
+	log.Debug("Finished saveReplaySectionsToArchive()")
+	return true
+}
+
+// saveReplaySectionsToDrive saves all of the sections of a replay into the output directory.
+func saveReplaySectionsToDrive(
+	sections []replaySection,
+	outputName replayOutputName,
+	absolutePathOutputDirectory string,
+) bool {
+
+	log.Debug("Entered saveReplaySectionsToDrive()")
+
+	for _, section := range sections {
+		savedSuccess := file_utils.SaveBytesToDrive(
+			[]byte(section.contents),
+			outputName.drivePath(section.filename),
+			absolutePathOutputDirectory,
+		)
+		if !savedSuccess {
+			return false
+		}
+	}
+
+	log.Debug("Finished saveReplaySectionsToDrive()")
+	return true
+}
@@ -0,0 +1,237 @@
+package schema_generation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/schema_utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// schemaIDPrefix is used to create unique identifiers of the generated documents.
+const schemaIDPrefix = "https://github.com/Kaszanas/SC2InfoExtractorGo/schemas/"
+
+// GenerateSchemaFiles creates JSON Schema documents describing all of the outputs
+// of the tool in the current schema version and saves them in the output directory.
+func GenerateSchemaFiles(outputDirectory string) error {
+
+	log.Debug("Entered GenerateSchemaFiles()")
+
+	err := file_utils.GetOrCreateDirectory(outputDirectory)
+	if err != nil {
+		return err
+	}
+
+	schemas := GenerateSchemas()
+	filenames := make([]string, 0, len(schemas))
+	for filename := range schemas {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		schemaBytes, err := json.MarshalIndent(schemas[filename], "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal schema %s: %v", filename, err)
+		}
+
+		schemaPath := filepath.Join(outputDirectory, filename)
+		err = os.WriteFile(schemaPath, schemaBytes, 0666)
+		if err != nil {
+			return fmt.Errorf("failed to write schema %s: %v", schemaPath, err)
+		}
+		log.WithField("schemaPath", schemaPath).Info("Saved JSON Schema.")
+	}
+
+	log.Debug("Finished GenerateSchemaFiles()")
+	return nil
+}
+
+// GenerateSchemas returns the JSON Schema documents mapped to their filenames.
+func GenerateSchemas() map[string]*schema_utils.JSONSchema {
+
+	trackerEventSchema := eventSchema(
+		settings.CommonTrackerEventFields,
+		settings.KnownTrackerEventShapes,
+	)
+	gameEventSchema := eventSchema(
+		settings.CommonGameEventFields,
+		settings.KnownGameEventShapes,
+	)
+	messageEventSchema := eventSchema(
+		settings.CommonMessageEventFields,
+		settings.KnownMessageEventShapes,
+	)
+
+	cleanedReplaySchema := documentSchema(
+		replay_data.CleanedReplay{},
+		"cleaned_replay",
+		"Replay output in the single layout",
+	)
+	cleanedReplaySchema.Properties["trackerEvents"].Items = trackerEventSchema
+	cleanedReplaySchema.Properties["gameEvents"].Items = gameEventSchema
+	cleanedReplaySchema.Properties["messageEvents"].Items = messageEventSchema
+
+	return map[string]*schema_utils.JSONSchema{
+		"cleaned_replay.schema.json": cleanedReplaySchema,
+		"replay_meta.schema.json": documentSchema(
+			replay_data.CleanedReplayMeta{},
+			"replay_meta",
+			"Replay metadata, meta.json in the split layout or metadata only output",
+		),
+		"tracker_events.schema.json": eventStreamSchema(
+			trackerEventSchema,
+			"tracker_events",
+			"Tracker events, tracker_events.json in the split layout",
+		),
+		"game_events.schema.json": eventStreamSchema(
+			gameEventSchema,
+			"game_events",
+			"Game events, game_events.json in the split layout",
+		),
+		"message_events.schema.json": eventStreamSchema(
+			messageEventSchema,
+			"message_events",
+			"Message events, message_events.json in the split layout",
+		),
+		"package_summary.schema.json": documentSchema(
+			persistent_data.PackageSummary{},
+			"package_summary",
+			"Package summary",
+		),
+		"timeseries.schema.json": documentSchema(
+			replay_data.TimeSeriesSidecar{},
+			"timeseries",
+			"Sidecar describing the exported time series, timeseries.json",
+		),
+		"package_index.schema.json": documentSchema(
+			persistent_data.PackageIndex{},
+			"package_index",
+			"Package index",
+		),
+		"dataset_index.schema.json": documentSchema(
+			persistent_data.DatasetIndex{},
+			"dataset_index",
+			"Dataset index merging the indexes of all of the packages",
+		),
+		"dataset_summary.schema.json": documentSchema(
+			persistent_data.DatasetSummary{},
+			"dataset_summary",
+			"Dataset summary merging the summaries of the packages",
+		),
+		"k_anonymity_report.schema.json": documentSchema(
+			persistent_data.KAnonymityReport{},
+			"k_anonymity_report",
+			"Report of the games with rare combinations of the quasi-identifiers",
+		),
+		"quarantined_replay.schema.json": documentSchema(
+			persistent_data.QuarantinedReplay{},
+			"quarantined_replay",
+			"Sidecar describing a replay in the quarantine directory",
+		),
+	}
+}
+
+// documentSchema creates a versioned top level document describing the value.
+func documentSchema(
+	value any,
+	name string,
+	title string,
+) *schema_utils.JSONSchema {
+
+	schema := schema_utils.NewDocumentSchema(
+		value,
+		schemaIDPrefix+settings.OutputSchemaVersion+"/"+name+".schema.json",
+		title,
+	)
+	schema.Description = "Schema version " + settings.OutputSchemaVersion
+
+	if versionSchema, ok := schema.Properties["schemaVersion"]; ok {
+		versionSchema.Const = settings.OutputSchemaVersion
+	}
+
+	return schema
+}
+
+// eventStreamSchema creates a versioned top level document describing an array of events.
+func eventStreamSchema(
+	eventSchema *schema_utils.JSONSchema,
+	name string,
+	title string,
+) *schema_utils.JSONSchema {
+
+	return &schema_utils.JSONSchema{
+		Schema:      schema_utils.JSONSchemaDraft,
+		ID:          schemaIDPrefix + settings.OutputSchemaVersion + "/" + name + ".schema.json",
+		Title:       title,
+		Description: "Schema version " + settings.OutputSchemaVersion,
+		Type:        []string{"array", "null"},
+		Items:       eventSchema,
+	}
+}
+
+// eventSchema creates a schema that matches any of the known event shapes
+// or any other event that contains the common fields.
+func eventSchema(
+	commonFields map[string]string,
+	knownShapes map[string]map[string]string,
+) *schema_utils.JSONSchema {
+
+	eventNames := make([]string, 0, len(knownShapes))
+	for eventName := range knownShapes {
+		eventNames = append(eventNames, eventName)
+	}
+	sort.Strings(eventNames)
+
+	var shapes []*schema_utils.JSONSchema
+	for _, eventName := range eventNames {
+		shape := eventShapeSchema(commonFields)
+		shape.Title = eventName
+		shape.Properties["evtTypeName"] = &schema_utils.JSONSchema{
+			Type:  "string",
+			Const: eventName,
+		}
+		// Fields specific to the event type can be missing or null in older game versions:
+		for fieldName, fieldType := range knownShapes[eventName] {
+			shape.Properties[fieldName] = &schema_utils.JSONSchema{
+				Type: []string{fieldType, "null"},
+			}
+		}
+		shapes = append(shapes, shape)
+	}
+
+	// Events that are not described in detail still contain the common fields:
+	otherEvents := eventShapeSchema(commonFields)
+	otherEvents.Title = "Other"
+	otherEvents.Properties["evtTypeName"] = &schema_utils.JSONSchema{
+		Type: "string",
+	}
+	otherEvents.Description = "Event of a type that is not one of the known shapes."
+	shapes = append(shapes, otherEvents)
+
+	return &schema_utils.JSONSchema{AnyOf: shapes}
+}
+
+// eventShapeSchema creates an object schema requiring all of the common fields.
+func eventShapeSchema(commonFields map[string]string) *schema_utils.JSONSchema {
+
+	shape := &schema_utils.JSONSchema{
+		Type:                 "object",
+		Properties:           make(map[string]*schema_utils.JSONSchema),
+		AdditionalProperties: true,
+	}
+	for fieldName, fieldType := range commonFields {
+		shape.Properties[fieldName] = &schema_utils.JSONSchema{Type: fieldType}
+		shape.Required = append(shape.Required, fieldName)
+	}
+	sort.Strings(shape.Required)
+
+	return shape
+}
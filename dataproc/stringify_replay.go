@@ -3,6 +3,7 @@ package dataproc
 import (
 	"encoding/json"
 
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
 	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
 	log "github.com/sirupsen/logrus"
 )
@@ -21,3 +22,92 @@ func stringifyReplay(replayData *replay_data.CleanedReplay) (bool, string) {
 	log.Debug("Finished stringifyReplay()")
 	return true, string(replayDataString)
 }
+
+// Filenames of the sections that are saved when the replay is split:
+const (
+	metaSectionFilename          = "meta.json"
+	trackerEventsSectionFilename = "tracker_events.json"
+	gameEventsSectionFilename    = "game_events.json"
+	messageEventsSectionFilename = "message_events.json"
+)
+
+// replaySection is a single JSON output file of a replay.
+// An empty filename means that the section holds the whole replay output.
+type replaySection struct {
+	filename string
+	contents string
+}
+
+// sectionToStringify pairs the filename of a section with the value that is marshaled into it.
+type sectionToStringify struct {
+	filename string
+	value    any
+}
+
+// stringifyReplaySections performs marshaling of CleanedReplay
+// into the sections that are required by the chosen output layout.
+// If metadataOnly is set, the event streams are not marshaled at all.
+func stringifyReplaySections(
+	replayData *replay_data.CleanedReplay,
+	outputLayout datastruct.OutputLayoutEnum,
+	metadataOnly bool,
+) (bool, []replaySection) {
+
+	log.Debug("Entered stringifyReplaySections()")
+
+	if outputLayout == datastruct.SingleFileLayout {
+		var stringifyOk bool
+		var replayString string
+		if metadataOnly {
+			replayMeta := replayData.Meta()
+			stringifyOk, replayString = stringifyValue(&replayMeta)
+		} else {
+			stringifyOk, replayString = stringifyReplay(replayData)
+		}
+		if !stringifyOk {
+			return false, nil
+		}
+		return true, []replaySection{{filename: "", contents: replayString}}
+	}
+
+	sectionsToStringify := []sectionToStringify{
+		{filename: metaSectionFilename, value: replayData.Meta()},
+	}
+	if !metadataOnly {
+		sectionsToStringify = append(sectionsToStringify,
+			sectionToStringify{trackerEventsSectionFilename, replayData.TrackerEvents},
+			sectionToStringify{gameEventsSectionFilename, replayData.GameEvents},
+			sectionToStringify{messageEventsSectionFilename, replayData.MessageEvents},
+		)
+	}
+
+	var sections []replaySection
+	for _, section := range sectionsToStringify {
+		stringifyOk, sectionString := stringifyValue(section.value)
+		if !stringifyOk {
+			log.WithField("section", section.filename).
+				Error("Failed to stringify the replay section.")
+			return false, nil
+		}
+		sections = append(sections, replaySection{
+			filename: section.filename,
+			contents: sectionString,
+		})
+	}
+
+	log.Debug("Finished stringifyReplaySections()")
+	return true, sections
+}
+
+// stringifyValue performs marshaling of any value that is a part of the replay output.
+func stringifyValue(value any) (bool, string) {
+
+	valueString, marshalErr := json.MarshalIndent(value, "", "  ")
+	if marshalErr != nil {
+		log.WithField("error", marshalErr).
+			Error("Error while marshaling the string representation of a replay section.")
+		return false, ""
+	}
+
+	return true, string(valueString)
+}
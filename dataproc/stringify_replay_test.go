@@ -0,0 +1,56 @@
+package dataproc
+
+import (
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+)
+
+// TestStringifyReplaySections tests if the replay is split into
+// the sections that are expected for every output layout.
+func TestStringifyReplaySections(t *testing.T) {
+
+	testCases := []struct {
+		outputLayout      datastruct.OutputLayoutEnum
+		metadataOnly      bool
+		expectedFilenames []string
+	}{
+		{datastruct.SingleFileLayout, false, []string{""}},
+		{datastruct.SingleFileLayout, true, []string{""}},
+		{
+			datastruct.SplitSectionsLayout,
+			false,
+			[]string{
+				metaSectionFilename,
+				trackerEventsSectionFilename,
+				gameEventsSectionFilename,
+				messageEventsSectionFilename,
+			},
+		},
+		{datastruct.SplitSectionsLayout, true, []string{metaSectionFilename}},
+	}
+
+	for _, testCase := range testCases {
+		replayData := replay_data.CleanedReplay{}
+		stringifyOk, sections := stringifyReplaySections(
+			&replayData,
+			testCase.outputLayout,
+			testCase.metadataOnly,
+		)
+		if !stringifyOk {
+			t.Fatalf("Test Failed! stringifyReplaySections() returned false.")
+		}
+
+		if len(sections) != len(testCase.expectedFilenames) {
+			t.Fatalf("Expected %v sections, got %v",
+				len(testCase.expectedFilenames), len(sections))
+		}
+		for index, section := range sections {
+			if section.filename != testCase.expectedFilenames[index] {
+				t.Errorf("Expected %v, got %v",
+					testCase.expectedFilenames[index], section.filename)
+			}
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package dataproc
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	log "github.com/sirupsen/logrus"
+)
+
+// packageFilenameRegexp matches the names of the packages created by the tool.
+var packageFilenameRegexp = regexp.MustCompile(`^package_(\d+)\.zip$`)
+
+// nonReplayOutputRegexp matches the JSON files that can be found next to the
+// replay outputs but do not hold any replay, the summaries, the indexes,
+// the k-anonymity report and the JSON Schemas.
+var nonReplayOutputRegexp = regexp.MustCompile(
+	`^(package_summary_\d+|package_index_\d+|dataset_summary|dataset_index|k_anonymity_report|.+\.schema)\.json$`,
+)
+
+// outputSource is a zip package or a directory holding the replay outputs,
+// that is summarized into a single package summary.
+type outputSource struct {
+	path          string
+	summaryNumber int
+}
+
+// SummarizeOutputs rebuilds the package summaries from the existing outputs
+// without parsing the replays again, and merges them into the dataset summary.
+// Inputs are zip packages or directories that are searched recursively for
+// the packages. Directories without any packages are summarized as the outputs
+// that were saved directly to the drive. Both of the output layouts are supported.
+func SummarizeOutputs(
+	inputs []string,
+	outputDirectory string,
+	numberOfThreads int,
+	bins persistent_data.SummaryBins,
+) error {
+
+	log.Debug("Entered SummarizeOutputs()")
+
+	sources, err := findOutputSources(inputs)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no outputs were found in %v", inputs)
+	}
+
+	var channel = make(chan outputSource, numberOfThreads+1)
+	var wg sync.WaitGroup
+	var errorsMutex sync.Mutex
+	var summarizeErrors []error
+	wg.Add(numberOfThreads)
+
+	// Spin up workers summarizing one source at a time:
+	for i := 0; i < numberOfThreads; i++ {
+		go func() {
+			defer wg.Done()
+			for source := range channel {
+				err := summarizeOutputSource(source, outputDirectory, bins)
+				if err != nil {
+					errorsMutex.Lock()
+					summarizeErrors = append(summarizeErrors, err)
+					errorsMutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	maxSummaryNumber := 0
+	for _, source := range sources {
+		if source.summaryNumber > maxSummaryNumber {
+			maxSummaryNumber = source.summaryNumber
+		}
+		channel <- source
+	}
+	close(channel)
+	wg.Wait()
+
+	if len(summarizeErrors) > 0 {
+		return fmt.Errorf("failed to summarize %d of the outputs: %v",
+			len(summarizeErrors), summarizeErrors[0])
+	}
+
+	err = persistent_data.MergePackageSummaries(
+		outputDirectory,
+		maxSummaryNumber+1,
+		bins,
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Finished SummarizeOutputs()")
+	return nil
+}
+
+// findOutputSources finds the packages and directories of the outputs.
+// Summaries keep the numbers of the packages if all of them are named
+// package_N.zip, otherwise the sources are numbered in the order of their paths.
+func findOutputSources(inputs []string) ([]outputSource, error) {
+
+	log.Debug("Entered findOutputSources()")
+
+	var sourcePaths []string
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to access the input %s: %v", input, err)
+		}
+		if !info.IsDir() {
+			sourcePaths = append(sourcePaths, input)
+			continue
+		}
+
+		var packagePaths []string
+		err = filepath.WalkDir(input, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".zip") {
+				packagePaths = append(packagePaths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search the input %s: %v", input, err)
+		}
+		if len(packagePaths) == 0 {
+			packagePaths = []string{input}
+		}
+		sourcePaths = append(sourcePaths, packagePaths...)
+	}
+	sort.Strings(sourcePaths)
+
+	sources := make([]outputSource, 0, len(sourcePaths))
+	usedNumbers := make(map[int]bool)
+	keepPackageNumbers := true
+	for _, sourcePath := range sourcePaths {
+		match := packageFilenameRegexp.FindStringSubmatch(filepath.Base(sourcePath))
+		if match == nil {
+			keepPackageNumbers = false
+			break
+		}
+		packageNumber, _ := strconv.Atoi(match[1])
+		if usedNumbers[packageNumber] {
+			keepPackageNumbers = false
+			break
+		}
+		usedNumbers[packageNumber] = true
+		sources = append(sources, outputSource{
+			path:          sourcePath,
+			summaryNumber: packageNumber,
+		})
+	}
+	if !keepPackageNumbers {
+		sources = sources[:0]
+		for i, sourcePath := range sourcePaths {
+			sources = append(sources, outputSource{
+				path:          sourcePath,
+				summaryNumber: i,
+			})
+		}
+	}
+
+	for _, source := range sources {
+		log.WithFields(log.Fields{
+			"source":        source.path,
+			"summaryNumber": source.summaryNumber,
+		}).Info("Found the outputs to summarize.")
+	}
+
+	log.Debug("Finished findOutputSources()")
+	return sources, nil
+}
+
+// summarizeOutputSource summarizes all of the replays of a single source
+// and saves the package summary in the output directory.
+func summarizeOutputSource(
+	source outputSource,
+	outputDirectory string,
+	bins persistent_data.SummaryBins,
+) error {
+
+	log.Debug("Entered summarizeOutputSource()")
+
+	var outputs fs.FS
+	info, err := os.Stat(source.path)
+	if err != nil {
+		return fmt.Errorf("failed to access %s: %v", source.path, err)
+	}
+	if info.IsDir() {
+		outputs = os.DirFS(source.path)
+	} else {
+		zipReader, err := zip.OpenReader(source.path)
+		if err != nil {
+			return fmt.Errorf("failed to open the package %s: %v", source.path, err)
+		}
+		defer zipReader.Close()
+		outputs = zipReader
+	}
+
+	packageSummary, err := summarizeReplayOutputs(outputs, bins)
+	if err != nil {
+		return fmt.Errorf("failed to summarize %s: %v", source.path, err)
+	}
+
+	err = persistent_data.CreatePackageSummaryFile(
+		outputDirectory,
+		packageSummary,
+		source.summaryNumber,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save the summary of %s: %v", source.path, err)
+	}
+
+	log.Debug("Finished summarizeOutputSource()")
+	return nil
+}
+
+// summarizeReplayOutputs reads every replay output found within the outputs
+// and adds its summary to the package summary.
+// Outputs that cannot be read are logged and skipped.
+func summarizeReplayOutputs(
+	outputs fs.FS,
+	bins persistent_data.SummaryBins,
+) (persistent_data.PackageSummary, error) {
+
+	log.Debug("Entered summarizeReplayOutputs()")
+
+	var replayOutputs []string
+	err := fs.WalkDir(outputs, ".", func(outputPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !isReplayOutput(outputPath) {
+			return nil
+		}
+		replayOutputs = append(replayOutputs, outputPath)
+		return nil
+	})
+	if err != nil {
+		return persistent_data.PackageSummary{}, err
+	}
+	sort.Strings(replayOutputs)
+
+	packageSummary := persistent_data.NewPackageSummary(bins)
+	for _, replayOutput := range replayOutputs {
+		replayData, err := readReplayOutput(outputs, replayOutput)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":        err,
+				"replayOutput": replayOutput,
+			}).Warn("Failed to read the replay output, skipping.")
+			continue
+		}
+
+		summarizeOk, replaySummary := summarizeReplay(&replayData, bins)
+		if !summarizeOk {
+			log.WithField("replayOutput", replayOutput).
+				Warn("Failed to summarize the replay output, skipping.")
+			continue
+		}
+		persistent_data.AddReplaySummToPackageSumm(&replaySummary, &packageSummary)
+	}
+
+	log.Debug("Finished summarizeReplayOutputs()")
+	return packageSummary, nil
+}
+
+// isReplayOutput returns true for the whole replay outputs of the single file
+// layout and the meta sections of the split layout.
+func isReplayOutput(outputPath string) bool {
+
+	filename := path.Base(outputPath)
+	switch filename {
+	case metaSectionFilename:
+		return true
+	case trackerEventsSectionFilename,
+		gameEventsSectionFilename,
+		messageEventsSectionFilename,
+		timeSeriesSidecarSectionFilename:
+		return false
+	}
+
+	return strings.HasSuffix(filename, ".json") &&
+		!nonReplayOutputRegexp.MatchString(filename)
+}
+
+// readReplayOutput unmarshals the replay output. Meta sections of the split layout
+// are completed with the tracker events saved next to them, if they exist.
+// Other events are not required by the summaries. Other JSON files, such as
+// the quarantine sidecars, are rejected as they do not hold the replay header.
+func readReplayOutput(
+	outputs fs.FS,
+	replayOutput string,
+) (replay_data.CleanedReplay, error) {
+
+	var replayData replay_data.CleanedReplay
+	err := readJSONOutput(outputs, replayOutput, &replayData)
+	if err != nil {
+		return replay_data.CleanedReplay{}, err
+	}
+	if replayData.Header.Version == "" {
+		return replay_data.CleanedReplay{}, fmt.Errorf("%s is not a replay output, it has no header", replayOutput)
+	}
+
+	if path.Base(replayOutput) == metaSectionFilename {
+		trackerEventsPath := path.Join(path.Dir(replayOutput), trackerEventsSectionFilename)
+		err = readJSONOutput(outputs, trackerEventsPath, &replayData.TrackerEvents)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return replay_data.CleanedReplay{}, err
+		}
+	}
+
+	backfillTeamIDs(&replayData)
+	return replayData, nil
+}
+
+// readJSONOutput unmarshals a single JSON file of the outputs into the value.
+func readJSONOutput(outputs fs.FS, outputPath string, value any) error {
+
+	outputBytes, err := fs.ReadFile(outputs, outputPath)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(outputBytes, value)
+}
+
+// backfillTeamIDs assigns the players of 1v1 replays to separate teams
+// for the outputs that were created before the team IDs were saved.
+// Team games of such outputs cannot be split into teams.
+func backfillTeamIDs(replayData *replay_data.CleanedReplay) {
+
+	majorVersion := strings.SplitN(replayData.SchemaVersion, ".", 2)[0]
+	if version, err := strconv.Atoi(majorVersion); err == nil && version >= 2 {
+		return
+	}
+	if len(replayData.ToonPlayerDescMap) != 2 {
+		return
+	}
+
+	teamID := int64(0)
+	for _, toon := range sortedToonKeys(replayData.ToonPlayerDescMap) {
+		player := replayData.ToonPlayerDescMap[toon]
+		player.TeamID = teamID
+		replayData.ToonPlayerDescMap[toon] = player
+		teamID++
+	}
+}
@@ -0,0 +1,97 @@
+package dataproc
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+)
+
+// TestSummarizeOutputs verifies that the replays of both of the output layouts
+// are summarized and that the numbers of the packages are kept.
+func TestSummarizeOutputs(t *testing.T) {
+
+	inputDirectory := t.TempDir()
+	outputDirectory := t.TempDir()
+
+	replayOutput := `{
+		"schemaVersion": "1.0.0",
+		"header": {"elapsedGameLoops": 13440, "version": "5.0.11.81102"},
+		"metadata": {"mapName": "Ever Dream LE", "gameVersion": "5.0.11"},
+		"ToonPlayerDescMap": {
+			"1-S2-1-1": {"race": "Terr", "APM": 100},
+			"1-S2-1-2": {"race": "Zerg", "APM": 200}
+		},
+		"trackerEvents": [{"evtTypeName": "UnitBorn", "unitTypeName": "SCV"}]
+	}`
+	trackerEvents := `[{"evtTypeName": "UnitBorn", "unitTypeName": "Drone"}]`
+	entries := map[string]string{
+		"single.SC2Replay.json":     replayOutput,
+		"split/meta.json":           replayOutput,
+		"split/tracker_events.json": trackerEvents,
+		"split/timeseries.json":     `{}`,
+		"package_summary_3.json":    `{}`,
+		// Files that are not replay outputs:
+		"k_anonymity_report.json":         `{"schemaVersion": "2.8.0", "k": 5}`,
+		"cleaned_replay.schema.json":      `{"$schema": "https://json-schema.org/draft/2020-12/schema"}`,
+		"quarantined.SC2Replay.json":      `{"schemaVersion": "2.8.0", "stage": "read"}`,
+		"quarantine/other.SC2Replay.json": `{"schemaVersion": "2.8.0", "mapName": "Ever Dream LE"}`,
+	}
+
+	packageFile, err := os.Create(filepath.Join(inputDirectory, "package_3.zip"))
+	if err != nil {
+		t.Fatalf("Test Failed! Could not create the package: %v", err)
+	}
+	writer := zip.NewWriter(packageFile)
+	for name, contents := range entries {
+		entryWriter, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Test Failed! Could not create the entry: %v", err)
+		}
+		_, err = entryWriter.Write([]byte(contents))
+		if err != nil {
+			t.Fatalf("Test Failed! Could not write the entry: %v", err)
+		}
+	}
+	writer.Close()
+	packageFile.Close()
+
+	err = SummarizeOutputs(
+		[]string{inputDirectory},
+		outputDirectory,
+		2,
+		persistent_data.SummaryBins{},
+	)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not summarize the outputs: %v", err)
+	}
+
+	summaryBytes, err := os.ReadFile(filepath.Join(outputDirectory, "package_summary_3.json"))
+	if err != nil {
+		t.Fatalf("Test Failed! Package number was not kept: %v", err)
+	}
+	var packageSummary persistent_data.PackageSummary
+	err = json.Unmarshal(summaryBytes, &packageSummary)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not unmarshal the package summary: %v", err)
+	}
+
+	summary := packageSummary.Summary
+	if summary.Maps["Ever Dream LE"] != 2 || len(summary.Maps) != 1 {
+		t.Errorf("Test Failed! Expected 2 replays, got %v", summary.Maps)
+	}
+	if summary.Units["SCV"] != 1 || summary.Units["Drone"] != 1 {
+		t.Errorf("Test Failed! Unexpected units: %v", summary.Units)
+	}
+	if summary.MatchupCount["T-vs-Z"] != 2 {
+		t.Errorf("Test Failed! Team IDs were not filled for the old outputs: %v", summary.MatchupCount)
+	}
+
+	_, err = os.Stat(filepath.Join(outputDirectory, persistent_data.DatasetSummaryFilename))
+	if err != nil {
+		t.Errorf("Test Failed! Dataset summary was not created: %v", err)
+	}
+}
@@ -8,13 +8,17 @@ import (
 
 // summarizeReplay accesses information from within a replay
 // and creates histograms, counters etc. in order to visualize the replay contents.
-func summarizeReplay(replayData *replay_data.CleanedReplay) (bool, persistent_data.ReplaySummary) {
+// The game times are counted in the bins of the game length distribution.
+func summarizeReplay(
+	replayData *replay_data.CleanedReplay,
+	bins persistent_data.SummaryBins,
+) (bool, persistent_data.ReplaySummary) {
 
 	log.Debug("Entered summarizeReplay()")
 
 	initSummary := persistent_data.NewReplaySummary()
 
-	generateReplaySummary(replayData, &initSummary)
+	generateReplaySummary(replayData, bins, &initSummary)
 
 	log.Debug("Finished summarizeReplay()")
 	return true, initSummary
@@ -1,7 +1,7 @@
 package dataproc
 
 import (
-	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -15,6 +15,7 @@ import (
 // and extracts information for visualization purposes.
 func generateReplaySummary(
 	replayData *replay_data.CleanedReplay,
+	bins persistent_data.SummaryBins,
 	summaryStruct *persistent_data.ReplaySummary,
 ) {
 
@@ -33,27 +34,65 @@ func generateReplaySummary(
 
 	// REVIEW: This seems to be left as legacy:
 	// replayMetadata := replayData.Metadata
-	// GameDuration histogram:
-	replayDuration := fmt.Sprintf("%f", float64(replayData.Header.ElapsedGameLoops)/22.4)
+	// GameDuration histogram, the game times are counted in the bins
+	// of the game length distribution:
+	gameLengthSeconds := float64(replayData.Header.ElapsedGameLoops) / gameLoopsPerSecond
+	replayDuration := persistent_data.BinLabel(
+		bins.GameLengthSeconds,
+		persistent_data.BinIndex(bins.GameLengthSeconds, gameLengthSeconds),
+	)
 
 	incrementIfKeyExists(replayDuration, summaryStruct.Summary.GameTimes)
 	log.Info("Finished incrementing summaryStruct.Summary.GameTimes")
 
+	// Numeric distributions of the game length, APM and MMR:
+	summaryStruct.Summary.GameLengthSeconds.Add(gameLengthSeconds)
+	for _, toon := range sortedToonKeys(replayData.ToonPlayerDescMap) {
+		player := replayData.ToonPlayerDescMap[toon]
+		summaryStruct.Summary.APM.Add(player.APM)
+		// MMR is not available for unranked games and is saved as 0:
+		if player.MMR > 0 {
+			summaryStruct.Summary.MMR.Add(player.MMR)
+		}
+	}
+	log.Info("Finished adding numeric distributions")
+
+	// Skill of the players:
+	for _, toon := range sortedToonKeys(replayData.ToonPlayerDescMap) {
+		player := replayData.ToonPlayerDescMap[toon]
+		if player.HighestLeague != "" {
+			incrementIfKeyExists(player.HighestLeague, summaryStruct.Summary.Leagues)
+		}
+		if player.AssignedRace == "" {
+			continue
+		}
+		addToDistributionMap(player.AssignedRace, player.APM, summaryStruct.Summary.APMByRace)
+		if player.MMR > 0 {
+			addToDistributionMap(player.AssignedRace, player.MMR, summaryStruct.Summary.MMRByRace)
+		}
+	}
+	leaguePairing := teamLeaguePairing(replayData.ToonPlayerDescMap)
+	if leaguePairing != "" {
+		incrementIfKeyExists(leaguePairing, summaryStruct.Summary.LeaguePairings)
+	}
+	log.Info("Finished adding skill information")
+
 	// MapsUsed histogram:
 	replayMap := replayData.Metadata.MapName
 	incrementIfKeyExists(replayMap, summaryStruct.Summary.Maps)
 	log.Info("Finished incrementing summaryStruct.Summary.Maps")
 
 	// Races used histogram:
-	for _, player := range replayData.ToonPlayerDescMap {
-		playerRace := player.AssignedRace
+	toons := sortedToonKeys(replayData.ToonPlayerDescMap)
+	for _, toon := range toons {
+		playerRace := replayData.ToonPlayerDescMap[toon].AssignedRace
 		incrementIfKeyExists(playerRace, summaryStruct.Summary.Races)
 	}
 	log.Info("Finished incrementing summaryStruct.Summary.Races")
 
 	// Dates of replays histogram:
-	replayYear, replayMonth, replayDay := replayData.Details.TimeUTC.Date()
-	dateString := strconv.Itoa(replayYear) + "-" + strconv.Itoa(int(replayMonth)) + "-" + strconv.Itoa(replayDay)
+	replayYear, replayMonth, _ := replayData.Details.TimeUTC.Date()
+	dateString := summaryDate(replayData.Details.TimeUTC)
 	incrementIfKeyExists(dateString, summaryStruct.Summary.Dates)
 	log.Info("Finished incrementing summaryStruct.Summary.Dates")
 
@@ -74,10 +113,13 @@ func generateReplaySummary(
 	// Server information histogram:
 	// TODO: Verify if this can be accessed differently:
 	singleLoop := false
-	for _, player := range replayData.ToonPlayerDescMap {
-		// This information is required only once per game:
-		if !singleLoop {
-			incrementIfKeyExists(player.Region, summaryStruct.Summary.Servers)
+	for _, toon := range toons {
+		// This information is required only once per game,
+		// the region is empty if it was removed for the privacy:
+		if !singleLoop && replayData.ToonPlayerDescMap[toon].Region != "" {
+			incrementIfKeyExists(
+				replayData.ToonPlayerDescMap[toon].Region,
+				summaryStruct.Summary.Servers)
 		}
 		singleLoop = true
 	}
@@ -101,78 +143,104 @@ func generateReplaySummary(
 	}
 	log.Info("Finished incrementing summaryStruct.Summary.Units")
 
-	var matchupString string
-	for _, player := range replayData.ToonPlayerDescMap {
-		matchupString += player.AssignedRace
-	}
 	// Incrementing both the count of matchup and the game time that the matchup had:
-	if !checkMatchupIncrementCount(matchupString, summaryStruct, replayDuration) {
-		log.Error("Failed to increment matchup information!")
+	matchup := teamMatchup(replayData.ToonPlayerDescMap)
+	if matchup != "" {
+		incrementIfKeyExists(matchup, summaryStruct.Summary.MatchupCount)
+		incrementNestedGameTimeIfKeyExists(
+			matchup,
+			replayDuration,
+			summaryStruct.Summary.MatchupGameTimes.GameTimes)
+		log.Info("Finished incrementing matchup information")
+	} else {
+		log.Error("Failed to increment matchup information, no races were found!")
 	}
 
 	log.Debug("Finished generateReplaySummary()")
 }
 
-// checkMatchup verifies the matchup string, increments the value of a counter
-// for the matching matchup and returns a boolean that specifies if a matchup was matched.
-func checkMatchupIncrementCount(
-	matchupString string,
-	summaryStruct *persistent_data.ReplaySummary,
-	gameTimeString string) bool {
+// teamMatchup returns a canonical matchup of the teams, for example "PT-vs-ZZ".
+// Race initials are sorted within every team and the teams are sorted,
+// so the key does not depend on the order of the players.
+// Players without an assigned race are skipped.
+func teamMatchup(
+	toonPlayerDescMap map[string]replay_data.EnhancedToonDescMap,
+) string {
 
-	log.Debug("Entered checkMatchup()")
+	return teamKey(
+		toonPlayerDescMap,
+		func(player replay_data.EnhancedToonDescMap) string {
+			if player.AssignedRace == "" {
+				return ""
+			}
+			return player.AssignedRace[:1]
+		},
+		"",
+	)
+}
 
-	if matchupString == "TerrTerr" {
-		log.Info("Found matchup to be TvT")
-		incrementIfKeyExists("TvT", summaryStruct.Summary.MatchupCount)
-		incrementIfKeyExists(
-			gameTimeString,
-			summaryStruct.Summary.MatchupGameTimes.TvTMatchup)
-		return true
-	}
-	if matchupString == "ProtProt" {
-		log.Debug("Found matchup to be PvP")
-		incrementIfKeyExists("PvP", summaryStruct.Summary.MatchupCount)
-		incrementIfKeyExists(
-			gameTimeString,
-			summaryStruct.Summary.MatchupGameTimes.PvPMatchup)
-		return true
-	}
-	if matchupString == "ZergZerg" {
-		log.Debug("Found matchup to be ZvZ")
-		incrementIfKeyExists("ZvZ", summaryStruct.Summary.MatchupCount)
-		incrementIfKeyExists(
-			gameTimeString,
-			summaryStruct.Summary.MatchupGameTimes.ZvZMatchup)
-		return true
-	}
-	if strings.Contains(matchupString, "Prot") && strings.Contains(matchupString, "Terr") {
-		log.Debug("Found matchup to be PvT")
-		incrementIfKeyExists("PvT", summaryStruct.Summary.MatchupCount)
-		incrementIfKeyExists(
-			gameTimeString,
-			summaryStruct.Summary.MatchupGameTimes.PvTMatchup)
-		return true
-	}
-	if strings.ContainsAny(matchupString, "Zerg") && strings.Contains(matchupString, "Terr") {
-		log.Debug("Found matchup to be ZvT")
-		incrementIfKeyExists("ZvT", summaryStruct.Summary.MatchupCount)
-		incrementIfKeyExists(
-			gameTimeString,
-			summaryStruct.Summary.MatchupGameTimes.TvZMatchup)
-		return true
+// teamLeaguePairing returns a canonical pairing of the highest leagues
+// of the teams, for example "Diamond-vs-Master" or "Gold,Platinum-vs-Diamond,Diamond".
+// Players without league information are skipped.
+func teamLeaguePairing(
+	toonPlayerDescMap map[string]replay_data.EnhancedToonDescMap,
+) string {
+
+	return teamKey(
+		toonPlayerDescMap,
+		func(player replay_data.EnhancedToonDescMap) string {
+			return player.HighestLeague
+		},
+		",",
+	)
+}
+
+// teamKey groups the values of the players by their teams,
+// sorts the values within every team and sorts the teams,
+// so the key does not depend on the order of the players.
+// Empty values are skipped.
+func teamKey(
+	toonPlayerDescMap map[string]replay_data.EnhancedToonDescMap,
+	playerValue func(player replay_data.EnhancedToonDescMap) string,
+	valueSeparator string,
+) string {
+
+	log.Debug("Entered teamKey()")
+
+	teamValues := make(map[int64][]string)
+	for _, player := range toonPlayerDescMap {
+		value := playerValue(player)
+		if value == "" {
+			continue
+		}
+		teamValues[player.TeamID] = append(teamValues[player.TeamID], value)
 	}
-	if strings.ContainsAny(matchupString, "Zerg") && strings.Contains(matchupString, "Prot") {
-		log.Debug("Found matchup to be ZvP")
-		incrementIfKeyExists("ZvP", summaryStruct.Summary.MatchupCount)
-		incrementIfKeyExists(
-			gameTimeString,
-			summaryStruct.Summary.MatchupGameTimes.PvZMatchup)
-		return true
+
+	teams := []string{}
+	for _, values := range teamValues {
+		sort.Strings(values)
+		teams = append(teams, strings.Join(values, valueSeparator))
 	}
+	sort.Strings(teams)
+
+	log.Debug("Finished teamKey()")
+	return strings.Join(teams, "-vs-")
+}
 
-	log.Info("Failed checkMatchup(), no matchup was found!")
-	return false
+// addToDistributionMap adds the value to the numeric distribution of the key.
+// Bin edges are set when the distribution is merged into the package summary.
+func addToDistributionMap(
+	key string,
+	value float64,
+	distributions map[string]persistent_data.NumericDistribution,
+) {
+
+	distribution, ok := distributions[key]
+	if !ok {
+		distribution = persistent_data.NewNumericDistribution([]float64{})
+	}
+	distribution.Add(value)
+	distributions[key] = distribution
 }
 
 // incrementIfKeyExists verifies if a key exists in a map and increments
@@ -201,10 +269,10 @@ func incrementNestedGameTimeIfKeyExists(
 
 	if keyDateMap, ok := mapToCheck[key]; ok {
 		if val, ok := keyDateMap[gameTime]; ok {
-			keyDateMap[key] = val + 1
+			keyDateMap[gameTime] = val + 1
 			log.Debug("Finished incrementNestedGameTimeIfKeyExists(), value incremented")
 		} else {
-			keyDateMap[key] = 1
+			keyDateMap[gameTime] = 1
 			log.Debug("Finished incrementNestedGameTimeIfKeyExists(), new value added")
 		}
 	} else {
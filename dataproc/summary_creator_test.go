@@ -0,0 +1,88 @@
+package dataproc
+
+import (
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+)
+
+// TestIncrementNestedGameTimeIfKeyExists verifies that the game times
+// are counted within the nested map of the key.
+func TestIncrementNestedGameTimeIfKeyExists(t *testing.T) {
+
+	mapToCheck := make(map[string]map[string]int64)
+
+	incrementNestedGameTimeIfKeyExists("2021-5", "600", mapToCheck)
+	incrementNestedGameTimeIfKeyExists("2021-5", "600", mapToCheck)
+	incrementNestedGameTimeIfKeyExists("2021-5", "300", mapToCheck)
+
+	nestedMap := mapToCheck["2021-5"]
+	if len(nestedMap) != 2 || nestedMap["600"] != 2 || nestedMap["300"] != 1 {
+		t.Errorf("Test Failed! Unexpected nested map: %v", nestedMap)
+	}
+}
+
+// TestTeamMatchup verifies that the matchups are built from the races of the teams
+// regardless of the order of the players.
+func TestTeamMatchup(t *testing.T) {
+
+	testCases := []struct {
+		players  map[string]replay_data.EnhancedToonDescMap
+		expected string
+	}{
+		{
+			map[string]replay_data.EnhancedToonDescMap{
+				"1": {AssignedRace: "Zerg", TeamID: 0},
+				"2": {AssignedRace: "Terr", TeamID: 1},
+			},
+			"T-vs-Z",
+		},
+		{
+			map[string]replay_data.EnhancedToonDescMap{
+				"1": {AssignedRace: "Zerg", TeamID: 1},
+				"2": {AssignedRace: "Terr", TeamID: 0},
+				"3": {AssignedRace: "Zerg", TeamID: 1},
+				"4": {AssignedRace: "Prot", TeamID: 0},
+			},
+			"PT-vs-ZZ",
+		},
+		{
+			map[string]replay_data.EnhancedToonDescMap{
+				"1": {AssignedRace: "Prot", TeamID: 0},
+				"2": {AssignedRace: "Prot", TeamID: 0},
+				"3": {AssignedRace: "Prot", TeamID: 0},
+				"4": {AssignedRace: "Zerg", TeamID: 1},
+				"5": {AssignedRace: "Terr", TeamID: 1},
+				"6": {AssignedRace: "", TeamID: 1},
+			},
+			"PPP-vs-TZ",
+		},
+		{
+			map[string]replay_data.EnhancedToonDescMap{},
+			"",
+		},
+	}
+
+	for _, testCase := range testCases {
+		matchup := teamMatchup(testCase.players)
+		if matchup != testCase.expected {
+			t.Errorf("Test Failed! Expected %q, got %q", testCase.expected, matchup)
+		}
+	}
+}
+
+// TestTeamLeaguePairing verifies that the league pairings are built from the teams.
+func TestTeamLeaguePairing(t *testing.T) {
+
+	players := map[string]replay_data.EnhancedToonDescMap{
+		"1": {HighestLeague: "Platinum", TeamID: 1},
+		"2": {HighestLeague: "Diamond", TeamID: 0},
+		"3": {HighestLeague: "Gold", TeamID: 1},
+		"4": {HighestLeague: "Diamond", TeamID: 0},
+	}
+
+	pairing := teamLeaguePairing(players)
+	if pairing != "Diamond,Diamond-vs-Gold,Platinum" {
+		t.Errorf("Test Failed! Unexpected league pairing %q", pairing)
+	}
+}
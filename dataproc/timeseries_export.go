@@ -0,0 +1,198 @@
+package dataproc
+
+import (
+	"sort"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/npy_utils"
+	"github.com/icza/s2prot"
+	log "github.com/sirupsen/logrus"
+)
+
+// Filenames of the sections holding the exported time series:
+const (
+	timeSeriesArraysSectionFilename  = "timeseries.npz"
+	timeSeriesSidecarSectionFilename = "timeseries.json"
+)
+
+// gameLoopsPerSecond is the number of game loops in a second of a game on faster speed.
+const gameLoopsPerSecond = 22.4
+
+// timeSeriesSections resamples the PlayerStats tracker events of the replay
+// onto a grid of game loops and returns the sections holding the .npz arrays
+// and the JSON sidecar describing them.
+func timeSeriesSections(
+	replayData *replay_data.CleanedReplay,
+	intervalLoops int64,
+) (bool, []replaySection) {
+
+	log.Debug("Entered timeSeriesSections()")
+
+	players := timeSeriesPlayers(replayData)
+	playerIDs := make([]int64, len(players))
+	for i, player := range players {
+		playerIDs[i] = player.PlayerID
+	}
+
+	features := settings.TimeSeriesPlayerStatsFeatures
+	loops, stats := resamplePlayerStats(
+		replayData.TrackerEvents,
+		playerIDs,
+		features,
+		intervalLoops,
+		int64(replayData.Header.ElapsedGameLoops),
+	)
+
+	arrayInfos := []replay_data.TimeSeriesArrayInfo{
+		{Name: "loops", DType: "int64", Shape: []int{len(loops)}},
+		{Name: "player_ids", DType: "int64", Shape: []int{len(playerIDs)}},
+		{Name: "stats", DType: "float32", Shape: []int{len(playerIDs), len(loops), len(features)}},
+	}
+
+	loopsBytes, err := npy_utils.EncodeInt64(loops, arrayInfos[0].Shape)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to encode the loops array.")
+		return false, nil
+	}
+	playerIDsBytes, err := npy_utils.EncodeInt64(playerIDs, arrayInfos[1].Shape)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to encode the player_ids array.")
+		return false, nil
+	}
+	statsBytes, err := npy_utils.EncodeFloat32(stats, arrayInfos[2].Shape)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to encode the stats array.")
+		return false, nil
+	}
+
+	npzBytes, err := npy_utils.EncodeNpz([]npy_utils.NpyArray{
+		{Name: arrayInfos[0].Name, Bytes: loopsBytes},
+		{Name: arrayInfos[1].Name, Bytes: playerIDsBytes},
+		{Name: arrayInfos[2].Name, Bytes: statsBytes},
+	})
+	if err != nil {
+		log.WithField("error", err).Error("Failed to encode the npz archive.")
+		return false, nil
+	}
+
+	sidecar := replay_data.TimeSeriesSidecar{
+		SchemaVersion:      settings.OutputSchemaVersion,
+		IntervalLoops:      intervalLoops,
+		GameLoopsPerSecond: gameLoopsPerSecond,
+		NumberOfSteps:      len(loops),
+		Features:           features,
+		Arrays:             arrayInfos,
+		Players:            players,
+	}
+	stringifyOk, sidecarString := stringifyValue(&sidecar)
+	if !stringifyOk {
+		return false, nil
+	}
+
+	log.Debug("Finished timeSeriesSections()")
+	return true, []replaySection{
+		{filename: timeSeriesArraysSectionFilename, contents: string(npzBytes)},
+		{filename: timeSeriesSidecarSectionFilename, contents: sidecarString},
+	}
+}
+
+// timeSeriesPlayers returns the labels of the players ordered by their IDs.
+func timeSeriesPlayers(
+	replayData *replay_data.CleanedReplay,
+) []replay_data.TimeSeriesPlayerLabel {
+
+	players := []replay_data.TimeSeriesPlayerLabel{}
+	for _, player := range replayData.ToonPlayerDescMap {
+		players = append(players, replay_data.TimeSeriesPlayerLabel{
+			PlayerID: player.PlayerID,
+			Race:     player.AssignedRace,
+			Result:   player.Result,
+			MMR:      player.MMR,
+			APM:      player.APM,
+		})
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].PlayerID < players[j].PlayerID
+	})
+
+	return players
+}
+
+// resamplePlayerStats creates a grid of game loops spaced by intervalLoops
+// that spans the whole game. For every player and grid point the features
+// are taken from the latest PlayerStats event that is not later than the grid point.
+// Grid points before the first event of a player are filled with zeros.
+// The returned stats are ordered as (player, step, feature).
+func resamplePlayerStats(
+	trackerEvents []s2prot.Struct,
+	playerIDs []int64,
+	features []string,
+	intervalLoops int64,
+	elapsedGameLoops int64,
+) ([]int64, []float32) {
+
+	log.Debug("Entered resamplePlayerStats()")
+
+	numberOfSteps := int(elapsedGameLoops/intervalLoops) + 1
+	loops := make([]int64, numberOfSteps)
+	for step := range loops {
+		loops[step] = int64(step) * intervalLoops
+	}
+
+	playerIndexes := make(map[int64]int, len(playerIDs))
+	for i, playerID := range playerIDs {
+		playerIndexes[playerID] = i
+	}
+
+	numberOfFeatures := len(features)
+	stats := make([]float32, len(playerIDs)*numberOfSteps*numberOfFeatures)
+
+	// Tracker events are ordered by the game loop:
+	for _, event := range trackerEvents {
+		if event["evtTypeName"] != "PlayerStats" {
+			continue
+		}
+		playerID, okPlayerID := numberToFloat(event["playerId"])
+		eventLoop, okLoop := numberToFloat(event["loop"])
+		eventStats, okStats := event["stats"].(s2prot.Struct)
+		if !okPlayerID || !okLoop || !okStats {
+			continue
+		}
+		playerIndex, ok := playerIndexes[int64(playerID)]
+		if !ok {
+			continue
+		}
+
+		// First grid point that is not earlier than the event:
+		firstStep := int((int64(eventLoop) + intervalLoops - 1) / intervalLoops)
+		values := make([]float32, numberOfFeatures)
+		for featureIndex, feature := range features {
+			value, _ := numberToFloat(eventStats[feature])
+			values[featureIndex] = float32(value)
+		}
+		// Event values are held until they are overwritten by the next event of the player:
+		for step := firstStep; step < numberOfSteps; step++ {
+			offset := (playerIndex*numberOfSteps + step) * numberOfFeatures
+			copy(stats[offset:offset+numberOfFeatures], values)
+		}
+	}
+
+	log.Debug("Finished resamplePlayerStats()")
+	return loops, stats
+}
+
+// numberToFloat converts the numeric values that are held in the events to float64.
+func numberToFloat(value any) (float64, bool) {
+	switch number := value.(type) {
+	case int64:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case float64:
+		return number, true
+	case uint64:
+		return float64(number), true
+	}
+	return 0, false
+}
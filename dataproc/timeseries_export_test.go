@@ -0,0 +1,49 @@
+package dataproc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/icza/s2prot"
+)
+
+// TestResamplePlayerStats verifies that the PlayerStats events are held
+// on the grid until the next event of the same player.
+func TestResamplePlayerStats(t *testing.T) {
+
+	playerStats := func(loop int64, playerID int64, minerals int64) s2prot.Struct {
+		return s2prot.Struct{
+			"evtTypeName": "PlayerStats",
+			"loop":        loop,
+			"playerId":    playerID,
+			"stats":       s2prot.Struct{"scoreValueMineralsCurrent": minerals},
+		}
+	}
+	trackerEvents := []s2prot.Struct{
+		playerStats(1, 1, 50),
+		playerStats(1, 2, 60),
+		{"evtTypeName": "UnitBorn", "loop": int64(5)},
+		playerStats(160, 1, 100),
+		playerStats(330, 2, 200),
+	}
+
+	loops, stats := resamplePlayerStats(
+		trackerEvents,
+		[]int64{1, 2},
+		[]string{"scoreValueMineralsCurrent"},
+		160,
+		480,
+	)
+
+	expectedLoops := []int64{0, 160, 320, 480}
+	if !reflect.DeepEqual(loops, expectedLoops) {
+		t.Fatalf("Test Failed! Expected loops %v, got %v", expectedLoops, loops)
+	}
+	expectedStats := []float32{
+		0, 100, 100, 100,
+		0, 60, 60, 200,
+	}
+	if !reflect.DeepEqual(stats, expectedStats) {
+		t.Fatalf("Test Failed! Expected stats %v, got %v", expectedStats, stats)
+	}
+}
@@ -1,6 +1,9 @@
 package dataproc
 
 import (
+	"sort"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/replay_data"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -17,3 +20,19 @@ func contains(s []string, str string) bool {
 	log.Debug("Slice does not contain supplied string, returning false")
 	return false
 }
+
+// sortedToonKeys returns the toons of the players in a stable order.
+// Iterating over the keys instead of the map keeps the outputs
+// that depend on the order of the players reproducible.
+func sortedToonKeys(
+	toonPlayerDescMap map[string]replay_data.EnhancedToonDescMap,
+) []string {
+
+	toons := make([]string, 0, len(toonPlayerDescMap))
+	for toon := range toonPlayerDescMap {
+		toons = append(toons, toon)
+	}
+	sort.Strings(toons)
+
+	return toons
+}
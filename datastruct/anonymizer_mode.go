@@ -0,0 +1,32 @@
+package datastruct
+
+// AnonymizerModeEnum is an enum type which holds the available ways
+// of deriving the anonymized IDs of the players.
+type AnonymizerModeEnum int
+
+// Anonymizer modes:
+const (
+	// GRPCAnonymizerMode requests the IDs from the anonymization server
+	// https://github.com/Kaszanas/SC2AnonServerPy
+	GRPCAnonymizerMode AnonymizerModeEnum = iota
+	// HMACAnonymizerMode derives the IDs from a keyed HMAC of the toon,
+	// the same key always yields the same IDs.
+	HMACAnonymizerMode
+	// LocalDBAnonymizerMode assigns incrementing IDs
+	// that are stored in a local database file.
+	LocalDBAnonymizerMode
+)
+
+// anonymizerModeNames maps the names that are accepted in the CLI to the anonymizer modes.
+var anonymizerModeNames = map[string]AnonymizerModeEnum{
+	"grpc":     GRPCAnonymizerMode,
+	"hmac":     HMACAnonymizerMode,
+	"local_db": LocalDBAnonymizerMode,
+}
+
+// AnonymizerModeFromString returns the anonymizer mode corresponding to the name
+// provided in the CLI and a boolean specifying if the name is known.
+func AnonymizerModeFromString(anonymizerModeName string) (AnonymizerModeEnum, bool) {
+	anonymizerMode, ok := anonymizerModeNames[anonymizerModeName]
+	return anonymizerMode, ok
+}
@@ -0,0 +1,30 @@
+package datastruct
+
+// ChatAnonymizationModeEnum is an enum type which holds the available ways
+// of anonymizing the chat messages.
+type ChatAnonymizationModeEnum int
+
+// Chat anonymization modes:
+const (
+	// DropChatAnonymizationMode removes the chat messages from the message events.
+	DropChatAnonymizationMode ChatAnonymizationModeEnum = iota
+	// RedactTextChatAnonymizationMode keeps the chat messages with their loop,
+	// sender and recipient, replacing only the text of the messages.
+	RedactTextChatAnonymizationMode
+)
+
+// chatAnonymizationModeNames maps the names that are accepted in the CLI
+// to the chat anonymization modes.
+var chatAnonymizationModeNames = map[string]ChatAnonymizationModeEnum{
+	"drop":        DropChatAnonymizationMode,
+	"redact_text": RedactTextChatAnonymizationMode,
+}
+
+// ChatAnonymizationModeFromString returns the chat anonymization mode corresponding
+// to the name provided in the CLI and a boolean specifying if the name is known.
+func ChatAnonymizationModeFromString(
+	chatAnonymizationModeName string,
+) (ChatAnonymizationModeEnum, bool) {
+	chatAnonymizationMode, ok := chatAnonymizationModeNames[chatAnonymizationModeName]
+	return chatAnonymizationMode, ok
+}
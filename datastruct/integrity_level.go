@@ -0,0 +1,36 @@
+package datastruct
+
+// IntegrityLevelEnum is an enum type which holds the available levels
+// of the integrity checks that are performed for every replay.
+type IntegrityLevelEnum int
+
+// Integrity levels:
+const (
+	// BasicIntegrityLevel compares the redundant fields of the header,
+	// details, metadata and the init data of the replay.
+	BasicIntegrityLevel IntegrityLevelEnum = iota
+	// DeepIntegrityLevel additionally verifies the consistency of the event streams.
+	DeepIntegrityLevel
+)
+
+// integrityLevelNames maps the names that are accepted in the CLI to the integrity levels.
+var integrityLevelNames = map[string]IntegrityLevelEnum{
+	"basic": BasicIntegrityLevel,
+	"deep":  DeepIntegrityLevel,
+}
+
+// IntegrityLevelFromString returns the integrity level corresponding to the name
+// provided in the CLI and a boolean specifying if the name is known.
+func IntegrityLevelFromString(integrityLevelName string) (IntegrityLevelEnum, bool) {
+	integrityLevel, ok := integrityLevelNames[integrityLevelName]
+	return integrityLevel, ok
+}
+
+// EventErrorsPolicy specifies which of the event streams reject the replay
+// if s2prot reported errors while decoding them. Errors of the streams
+// that are accepted are kept in the output as gameEventsErr, messageEventsErr and trackerEvtsErr.
+type EventErrorsPolicy struct {
+	RejectGameEvtsErr    bool
+	RejectMessageEvtsErr bool
+	RejectTrackerEvtsErr bool
+}
@@ -0,0 +1,27 @@
+package datastruct
+
+// OutputLayoutEnum is an enum type which holds the available layouts
+// of the files that are written for every processed replay.
+type OutputLayoutEnum int
+
+// Output layouts:
+const (
+	// SingleFileLayout writes the whole replay into a single JSON file.
+	SingleFileLayout OutputLayoutEnum = iota
+	// SplitSectionsLayout writes every replay into a directory
+	// holding separate JSON files for the metadata and the event streams.
+	SplitSectionsLayout
+)
+
+// outputLayoutNames maps the names that are accepted in the CLI to the layouts.
+var outputLayoutNames = map[string]OutputLayoutEnum{
+	"single": SingleFileLayout,
+	"split":  SplitSectionsLayout,
+}
+
+// OutputLayoutFromString returns the layout corresponding to the name
+// provided in the CLI and a boolean specifying if the name is known.
+func OutputLayoutFromString(layoutName string) (OutputLayoutEnum, bool) {
+	layout, ok := outputLayoutNames[layoutName]
+	return layout, ok
+}
@@ -0,0 +1,150 @@
+package persistent_data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	log "github.com/sirupsen/logrus"
+)
+
+// DatasetSummaryFilename is the name of the summary merging all of the package summaries.
+const DatasetSummaryFilename = "dataset_summary.json"
+
+// DatasetSummary contains statistics of the whole dataset,
+// merged from the summaries of the packages.
+type DatasetSummary struct {
+	SchemaVersion string   `json:"schemaVersion"`
+	Sources       []string `json:"sources"`
+	Summary       Summary
+}
+
+// NewDatasetSummary returns an initialized DatasetSummary
+// with the numeric distributions using the supplied histogram bins.
+func NewDatasetSummary(bins SummaryBins) DatasetSummary {
+	return DatasetSummary{
+		SchemaVersion: settings.OutputSchemaVersion,
+		Sources:       []string{},
+		Summary:       NewPackageSummary(bins).Summary,
+	}
+}
+
+// PackageSummaryFilename returns the name of the summary file of a package.
+func PackageSummaryFilename(fileNumber int) string {
+	return fmt.Sprintf("package_summary_%v.json", fileNumber)
+}
+
+// MergeSummaryFiles reads the package or dataset summaries
+// and merges them into a single DatasetSummary.
+func MergeSummaryFiles(
+	summaryFiles []string,
+	bins SummaryBins,
+) (DatasetSummary, error) {
+
+	log.Debug("Entered MergeSummaryFiles()")
+
+	datasetSummary := NewDatasetSummary(bins)
+	for _, summaryFile := range summaryFiles {
+		summaryBytes, err := os.ReadFile(summaryFile)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":       err,
+				"summaryFile": summaryFile,
+			}).Error("Failed to read the summary file!")
+			return DatasetSummary{}, err
+		}
+
+		// Package and dataset summaries share the layout of the fields that are merged:
+		var fileSummary DatasetSummary
+		err = json.Unmarshal(summaryBytes, &fileSummary)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":       err,
+				"summaryFile": summaryFile,
+			}).Error("Failed to unmarshal the summary file!")
+			return DatasetSummary{}, fmt.Errorf("failed to unmarshal %s: %v", summaryFile, err)
+		}
+		if fileSummary.SchemaVersion != settings.OutputSchemaVersion {
+			log.WithFields(log.Fields{
+				"summaryFile":   summaryFile,
+				"schemaVersion": fileSummary.SchemaVersion,
+			}).Warn("Summary was created with a different schema version, fields missing in it are skipped.")
+		}
+
+		mergeSummary(&fileSummary.Summary, &datasetSummary.Summary)
+		datasetSummary.Sources = append(datasetSummary.Sources, summaryFile)
+	}
+
+	log.Debug("Finished MergeSummaryFiles()")
+	return datasetSummary, nil
+}
+
+// CreateDatasetSummaryFile calculates the statistics of the dataset summary
+// and saves it under the supplied path.
+func CreateDatasetSummaryFile(
+	datasetSummaryPath string,
+	datasetSummary DatasetSummary,
+) error {
+
+	log.Debug("Entered CreateDatasetSummaryFile()")
+
+	datasetSummary.Summary.CalculateStatistics()
+	err := saveJSONFile(datasetSummaryPath, datasetSummary)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to save the dataset summary file!")
+		return err
+	}
+
+	log.Debug("Finished CreateDatasetSummaryFile()")
+	return nil
+}
+
+// MergePackageSummaries merges the summaries of the packages created by a run
+// and saves them as the dataset summary in the output directory.
+// Summaries of the packages that failed to be saved are skipped.
+func MergePackageSummaries(
+	absolutePathOutputDirectory string,
+	numberOfPackages int,
+	bins SummaryBins,
+) error {
+
+	log.Debug("Entered MergePackageSummaries()")
+
+	var summaryFiles []string
+	for fileNumber := 0; fileNumber < numberOfPackages; fileNumber++ {
+		summaryFile := filepath.Join(
+			absolutePathOutputDirectory,
+			PackageSummaryFilename(fileNumber),
+		)
+		if _, err := os.Stat(summaryFile); err != nil {
+			log.WithFields(log.Fields{
+				"error":       err,
+				"summaryFile": summaryFile,
+			}).Warn("Package summary is not available, skipping.")
+			continue
+		}
+		summaryFiles = append(summaryFiles, summaryFile)
+	}
+
+	datasetSummary, err := MergeSummaryFiles(summaryFiles, bins)
+	if err != nil {
+		return err
+	}
+	// Sources of a single run are kept relative to the output directory:
+	for i, summaryFile := range datasetSummary.Sources {
+		datasetSummary.Sources[i] = filepath.Base(summaryFile)
+	}
+
+	err = CreateDatasetSummaryFile(
+		filepath.Join(absolutePathOutputDirectory, DatasetSummaryFilename),
+		datasetSummary,
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Finished MergePackageSummaries()")
+	return nil
+}
@@ -0,0 +1,61 @@
+package persistent_data
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMergePackageSummaries verifies that the counts and distributions
+// of the package summaries are merged and that missing packages are skipped.
+func TestMergePackageSummaries(t *testing.T) {
+
+	outputDirectory := t.TempDir()
+	bins := SummaryBins{
+		GameLengthSeconds: []float64{600},
+		APM:               []float64{100},
+		MMR:               []float64{3000},
+	}
+
+	for _, fileNumber := range []int{0, 2} {
+		packageSummary := NewPackageSummary(bins)
+		packageSummary.Summary.Maps["Map"] = 2
+		packageSummary.Summary.DatesGameTimes.GameTimes["2020-1"] = map[string]int64{"< 180": 1}
+		packageSummary.Summary.GameLengthSeconds.Add(float64(300 * (fileNumber + 1)))
+		err := CreatePackageSummaryFile(outputDirectory, packageSummary, fileNumber)
+		if err != nil {
+			t.Fatalf("Test Failed! Could not create the package summary: %v", err)
+		}
+	}
+
+	err := MergePackageSummaries(outputDirectory, 3, bins)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not merge the package summaries: %v", err)
+	}
+
+	datasetSummaryBytes, err := os.ReadFile(filepath.Join(outputDirectory, DatasetSummaryFilename))
+	if err != nil {
+		t.Fatalf("Test Failed! Could not read the dataset summary: %v", err)
+	}
+	var datasetSummary DatasetSummary
+	err = json.Unmarshal(datasetSummaryBytes, &datasetSummary)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not unmarshal the dataset summary: %v", err)
+	}
+
+	if len(datasetSummary.Sources) != 2 {
+		t.Fatalf("Test Failed! Expected 2 sources, got %v", datasetSummary.Sources)
+	}
+	if datasetSummary.Summary.Maps["Map"] != 4 {
+		t.Errorf("Test Failed! Expected 4 map occurrences, got %d",
+			datasetSummary.Summary.Maps["Map"])
+	}
+	if datasetSummary.Summary.DatesGameTimes.GameTimes["2020-1"]["< 180"] != 2 {
+		t.Errorf("Test Failed! Nested game times were not merged.")
+	}
+	gameLength := datasetSummary.Summary.GameLengthSeconds
+	if gameLength.Count != 2 || gameLength.BinCounts[0] != 1 || gameLength.BinCounts[1] != 1 {
+		t.Errorf("Test Failed! Game length distribution was not merged: %+v", gameLength)
+	}
+}
@@ -0,0 +1,200 @@
+package persistent_data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	log "github.com/sirupsen/logrus"
+)
+
+// KAnonymityReportFilename is the name of the k-anonymity report
+// created next to the dataset index.
+const KAnonymityReportFilename = "k_anonymity_report.json"
+
+// KAnonymityReport describes how many games share every combination
+// of the chosen quasi-identifiers. A game is at risk of re-identification
+// if fewer than K games share its combination.
+type KAnonymityReport struct {
+	SchemaVersion string   `json:"schemaVersion"`
+	Attributes    []string `json:"attributes"`
+	K             int      `json:"k"`
+	// Replays of the same game are counted once:
+	NGames              int `json:"nGames"`
+	NEquivalenceClasses int `json:"nEquivalenceClasses"`
+	NRareGames          int `json:"nRareGames"`
+	// ClassSizes counts the combinations by the number of the games sharing them:
+	ClassSizes map[string]int64 `json:"classSizes"`
+	RareGames  []RareGame       `json:"rareGames"`
+}
+
+// RareGame is a game whose combination of the quasi-identifiers
+// is shared by fewer than KAnonymityReport.K games. The game fingerprint
+// and the replay files are omitted if they were omitted from the index,
+// the entries of the replays in the packages are listed instead.
+type RareGame struct {
+	GameFingerprint string            `json:"gameFingerprint,omitempty"`
+	ReplayFiles     []string          `json:"replayFiles,omitempty"`
+	Packages        []string          `json:"packages"`
+	Entries         []string          `json:"entries"`
+	Combination     map[string]string `json:"combination"`
+	ClassSize       int               `json:"classSize"`
+	// key identifies the game in the report:
+	key string
+}
+
+// NewKAnonymityReport groups the games of the dataset index by the values
+// of the attributes and lists the games in the groups smaller than k.
+func NewKAnonymityReport(
+	datasetIndex DatasetIndex,
+	attributes []string,
+	k int,
+) KAnonymityReport {
+
+	log.Debug("Entered NewKAnonymityReport()")
+
+	// Replays of the same game share the fingerprint:
+	games := make(map[string]*RareGame)
+	gameFingerprints := []string{}
+	for _, replay := range datasetIndex.Replays {
+		entry := replay.Package
+		if len(replay.EntryNames) > 0 {
+			entry = replay.Package + "/" + replay.EntryNames[0]
+		}
+		// Replays without the fingerprint are counted as separate games:
+		replayKey := replay.ReplayFile
+		if replayKey == "" {
+			replayKey = entry
+		}
+		fingerprint := replay.GameFingerprint
+		if fingerprint == "" {
+			fingerprint = replayKey
+		}
+		game, ok := games[fingerprint]
+		if !ok {
+			combination := make(map[string]string)
+			for _, attribute := range attributes {
+				combination[attribute] = replay.QuasiIdentifiers[attribute]
+			}
+			game = &RareGame{
+				GameFingerprint: replay.GameFingerprint,
+				Combination:     combination,
+				key:             replayKey,
+			}
+			games[fingerprint] = game
+			gameFingerprints = append(gameFingerprints, fingerprint)
+		}
+		if replay.ReplayFile != "" {
+			game.ReplayFiles = append(game.ReplayFiles, replay.ReplayFile)
+		}
+		game.Packages = append(game.Packages, replay.Package)
+		game.Entries = append(game.Entries, entry)
+	}
+
+	equivalenceClasses := make(map[string][]*RareGame)
+	for _, fingerprint := range gameFingerprints {
+		game := games[fingerprint]
+		key := combinationKey(game.Combination, attributes)
+		equivalenceClasses[key] = append(equivalenceClasses[key], game)
+	}
+
+	report := KAnonymityReport{
+		SchemaVersion:       settings.OutputSchemaVersion,
+		Attributes:          attributes,
+		K:                   k,
+		NGames:              len(games),
+		NEquivalenceClasses: len(equivalenceClasses),
+		ClassSizes:          make(map[string]int64),
+		RareGames:           []RareGame{},
+	}
+	for _, classGames := range equivalenceClasses {
+		report.ClassSizes[strconv.Itoa(len(classGames))]++
+		if len(classGames) >= k {
+			continue
+		}
+		for _, game := range classGames {
+			game.ClassSize = len(classGames)
+			report.RareGames = append(report.RareGames, *game)
+		}
+	}
+	report.NRareGames = len(report.RareGames)
+
+	// The rarest games are listed first:
+	sort.Slice(report.RareGames, func(i, j int) bool {
+		if report.RareGames[i].ClassSize != report.RareGames[j].ClassSize {
+			return report.RareGames[i].ClassSize < report.RareGames[j].ClassSize
+		}
+		return report.RareGames[i].key < report.RareGames[j].key
+	})
+
+	log.Debug("Finished NewKAnonymityReport()")
+	return report
+}
+
+// combinationKey joins the values of the attributes in the order of the attributes.
+func combinationKey(combination map[string]string, attributes []string) string {
+
+	values := make([]string, 0, len(attributes))
+	for _, attribute := range attributes {
+		values = append(values, strconv.Quote(combination[attribute]))
+	}
+
+	return strings.Join(values, "|")
+}
+
+// CreateKAnonymityReportFile reads the dataset index and saves the k-anonymity report
+// over the attributes under the supplied path.
+func CreateKAnonymityReportFile(
+	datasetIndexPath string,
+	reportPath string,
+	attributes []string,
+	k int,
+) error {
+
+	log.Debug("Entered CreateKAnonymityReportFile()")
+
+	datasetIndexBytes, err := os.ReadFile(datasetIndexPath)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":            err,
+			"datasetIndexPath": datasetIndexPath,
+		}).Error("Failed to read the dataset index file!")
+		return err
+	}
+	var datasetIndex DatasetIndex
+	err = json.Unmarshal(datasetIndexBytes, &datasetIndex)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %v", datasetIndexPath, err)
+	}
+	if datasetIndex.SchemaVersion != settings.OutputSchemaVersion {
+		log.WithFields(log.Fields{
+			"datasetIndexPath": datasetIndexPath,
+			"schemaVersion":    datasetIndex.SchemaVersion,
+		}).Warn("Dataset index was created with a different schema version, missing attributes are empty.")
+	}
+
+	report := NewKAnonymityReport(datasetIndex, attributes, k)
+	err = os.MkdirAll(filepath.Dir(reportPath), 0755)
+	if err != nil {
+		return err
+	}
+	err = saveJSONFile(reportPath, report)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to save the k-anonymity report!")
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"nGames":     report.NGames,
+		"nRareGames": report.NRareGames,
+		"k":          k,
+	}).Info("Created the k-anonymity report.")
+
+	log.Debug("Finished CreateKAnonymityReportFile()")
+	return nil
+}
@@ -0,0 +1,83 @@
+package persistent_data
+
+import (
+	"testing"
+)
+
+// TestNewKAnonymityReport verifies that the replays of the same game are counted once
+// and that only the games in the combinations shared by fewer than k games are flagged.
+func TestNewKAnonymityReport(t *testing.T) {
+
+	replay := func(replayFile string, fingerprint string, mapName string, mmr string) ReplayIndexEntry {
+		return ReplayIndexEntry{
+			ReplayFile:       replayFile,
+			Package:          "package_0.zip",
+			GameFingerprint:  fingerprint,
+			QuasiIdentifiers: map[string]string{"map": mapName, "mmr": mmr, "region": "Europe"},
+		}
+	}
+	datasetIndex := NewDatasetIndex()
+	datasetIndex.Replays = []ReplayIndexEntry{
+		replay("a.SC2Replay", "game-a", "Ladder Map", "3000,3100"),
+		replay("b.SC2Replay", "game-b", "Ladder Map", "3000,3100"),
+		// The same game saved by the other player:
+		replay("b2.SC2Replay", "game-b", "Ladder Map", "3000,3100"),
+		replay("c.SC2Replay", "game-c", "Ladder Map", "5000,5100"),
+		replay("d.SC2Replay", "game-d", "Custom Map", "3000,3100"),
+	}
+
+	report := NewKAnonymityReport(datasetIndex, []string{"map", "mmr"}, 2)
+
+	if report.NGames != 4 || report.NEquivalenceClasses != 3 {
+		t.Errorf(
+			"Test Failed! Expected 4 games in 3 classes, got %d games in %d classes",
+			report.NGames,
+			report.NEquivalenceClasses,
+		)
+	}
+	if report.ClassSizes["1"] != 2 || report.ClassSizes["2"] != 1 {
+		t.Errorf("Test Failed! Unexpected class sizes: %v", report.ClassSizes)
+	}
+	if report.NRareGames != 2 {
+		t.Fatalf("Test Failed! Expected 2 rare games, got %d", report.NRareGames)
+	}
+	for i, expectedFile := range []string{"c.SC2Replay", "d.SC2Replay"} {
+		rareGame := report.RareGames[i]
+		if rareGame.ReplayFiles[0] != expectedFile || rareGame.ClassSize != 1 {
+			t.Errorf("Test Failed! Unexpected rare game: %+v", rareGame)
+		}
+		if _, ok := rareGame.Combination["region"]; ok {
+			t.Errorf("Test Failed! Only the chosen attributes are expected: %v", rareGame.Combination)
+		}
+	}
+}
+
+// TestNewKAnonymityReportHiddenSource verifies that the replays without
+// the fingerprint and the replay file are counted as separate games
+// and listed by their entries in the packages.
+func TestNewKAnonymityReportHiddenSource(t *testing.T) {
+
+	datasetIndex := NewDatasetIndex()
+	for _, entryName := range []string{"b.json", "a.json"} {
+		datasetIndex.Replays = append(datasetIndex.Replays, ReplayIndexEntry{
+			Package:          "package_0.zip",
+			EntryNames:       []string{entryName},
+			QuasiIdentifiers: map[string]string{"map": "Ladder Map"},
+		})
+	}
+
+	report := NewKAnonymityReport(datasetIndex, []string{"map"}, 3)
+
+	if report.NGames != 2 || report.NRareGames != 2 {
+		t.Fatalf("Test Failed! Expected 2 rare games, got %+v", report)
+	}
+	for i, expectedEntry := range []string{"package_0.zip/a.json", "package_0.zip/b.json"} {
+		rareGame := report.RareGames[i]
+		if len(rareGame.Entries) != 1 || rareGame.Entries[0] != expectedEntry {
+			t.Errorf("Test Failed! Expected the entry %s, got %+v", expectedEntry, rareGame)
+		}
+		if rareGame.GameFingerprint != "" || len(rareGame.ReplayFiles) != 0 {
+			t.Errorf("Test Failed! Expected the source to be omitted, got %+v", rareGame)
+		}
+	}
+}
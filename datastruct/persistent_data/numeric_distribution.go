@@ -0,0 +1,216 @@
+package persistent_data
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SummaryPercentiles are the percentiles that are calculated for every numeric distribution.
+var SummaryPercentiles = []float64{5, 25, 50, 75, 95}
+
+// NumericDistribution describes a numeric variable with the counts of its histogram bins,
+// its count, sum, min and max, which can be merged across the packages without keeping
+// the values. The mean is exact, the median and the percentiles are estimated
+// from the histogram. The statistics are calculated with CalculateStatistics
+// before the summary is saved.
+type NumericDistribution struct {
+	// BinEdges are the ascending edges of the histogram bins.
+	BinEdges []float64 `json:"binEdges"`
+	// BinCounts has one bin more than there are edges: BinCounts[0] counts
+	// the values below the first edge, BinCounts[i] the values in
+	// [BinEdges[i-1], BinEdges[i]) and the last bin the values not below the last edge.
+	BinCounts   []int64            `json:"binCounts"`
+	Count       int64              `json:"count"`
+	Sum         float64            `json:"sum"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Mean        float64            `json:"mean"`
+	Median      float64            `json:"median"`
+	Percentiles map[string]float64 `json:"percentiles"`
+	// values are the values added to the distribution of a single replay,
+	// they are binned with the edges of the package summary when the replay
+	// summary is merged. They are never saved:
+	values []float64
+}
+
+// SummaryBins holds the histogram bin edges of the numeric distributions of a summary.
+type SummaryBins struct {
+	GameLengthSeconds []float64
+	APM               []float64
+	MMR               []float64
+}
+
+// NewNumericDistribution returns an initialized NumericDistribution.
+func NewNumericDistribution(binEdges []float64) NumericDistribution {
+	return NumericDistribution{
+		BinEdges:    binEdges,
+		BinCounts:   make([]int64, len(binEdges)+1),
+		Percentiles: make(map[string]float64),
+	}
+}
+
+// BinIndex returns the index of the histogram bin holding the value.
+func BinIndex(binEdges []float64, value float64) int {
+	// Index of the first edge that is greater than the value:
+	return sort.Search(len(binEdges), func(i int) bool {
+		return binEdges[i] > value
+	})
+}
+
+// BinLabel returns a readable label of the i-th histogram bin, such as "60 - 180".
+func BinLabel(binEdges []float64, i int) string {
+	if len(binEdges) == 0 {
+		return "all"
+	}
+	if i == 0 {
+		return fmt.Sprintf("< %g", binEdges[0])
+	}
+	if i == len(binEdges) {
+		return fmt.Sprintf(">= %g", binEdges[len(binEdges)-1])
+	}
+	return fmt.Sprintf("%g - %g", binEdges[i-1], binEdges[i])
+}
+
+// Add adds a single value to the distribution.
+func (distribution *NumericDistribution) Add(value float64) {
+	distribution.addToBins(value, 1)
+	distribution.values = append(distribution.values, value)
+}
+
+// addToBins counts the value n times in the histogram and the statistics.
+func (distribution *NumericDistribution) addToBins(value float64, n int64) {
+
+	if len(distribution.BinCounts) != len(distribution.BinEdges)+1 {
+		distribution.BinCounts = make([]int64, len(distribution.BinEdges)+1)
+	}
+	if distribution.Count == 0 || value < distribution.Min {
+		distribution.Min = value
+	}
+	if distribution.Count == 0 || value > distribution.Max {
+		distribution.Max = value
+	}
+	distribution.BinCounts[BinIndex(distribution.BinEdges, value)] += n
+	distribution.Count += n
+	distribution.Sum += value * float64(n)
+}
+
+// Merge adds the other distribution to this one. Bin edges of the receiving
+// distribution are kept. The values of a replay summary are binned exactly,
+// the histograms of the saved summaries are added if their bin edges match
+// and approximated by the midpoints of the bins otherwise.
+func (distribution *NumericDistribution) Merge(other *NumericDistribution) {
+
+	if len(other.values) > 0 {
+		for _, value := range other.values {
+			distribution.addToBins(value, 1)
+		}
+		return
+	}
+	if other.Count == 0 {
+		return
+	}
+
+	if slices.Equal(distribution.BinEdges, other.BinEdges) &&
+		len(other.BinCounts) == len(other.BinEdges)+1 {
+		if len(distribution.BinCounts) != len(distribution.BinEdges)+1 {
+			distribution.BinCounts = make([]int64, len(distribution.BinEdges)+1)
+		}
+		if distribution.Count == 0 || other.Min < distribution.Min {
+			distribution.Min = other.Min
+		}
+		if distribution.Count == 0 || other.Max > distribution.Max {
+			distribution.Max = other.Max
+		}
+		for i, count := range other.BinCounts {
+			distribution.BinCounts[i] += count
+		}
+		distribution.Count += other.Count
+		distribution.Sum += other.Sum
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"binEdges":      distribution.BinEdges,
+		"otherBinEdges": other.BinEdges,
+	}).Warn("Merging a distribution with different bin edges, the values are approximated by the midpoints of the bins.")
+
+	// Sum is kept exact, min and max are added as values of their own bins:
+	sum := distribution.Sum + other.Sum
+	for i, count := range other.BinCounts {
+		if count == 0 {
+			continue
+		}
+		lower, upper := other.binBounds(i)
+		distribution.addToBins((lower+upper)/2, count)
+	}
+	distribution.addToBins(other.Min, 0)
+	distribution.addToBins(other.Max, 0)
+	distribution.Sum = sum
+}
+
+// binBounds returns the bounds of the i-th histogram bin,
+// the outer bins are bounded by the min and the max.
+func (distribution *NumericDistribution) binBounds(i int) (float64, float64) {
+
+	lower := distribution.Min
+	upper := distribution.Max
+	if i > 0 {
+		lower = math.Max(lower, distribution.BinEdges[i-1])
+	}
+	if i < len(distribution.BinEdges) {
+		upper = math.Min(upper, distribution.BinEdges[i])
+	}
+	if upper < lower {
+		upper = lower
+	}
+
+	return lower, upper
+}
+
+// CalculateStatistics calculates the mean and estimates the median
+// and the percentiles from the histogram.
+func (distribution *NumericDistribution) CalculateStatistics() {
+
+	if len(distribution.BinCounts) != len(distribution.BinEdges)+1 {
+		distribution.BinCounts = make([]int64, len(distribution.BinEdges)+1)
+	}
+	distribution.Percentiles = make(map[string]float64)
+	if distribution.Count == 0 {
+		distribution.Min = 0
+		distribution.Max = 0
+		distribution.Mean = 0
+		distribution.Median = 0
+		return
+	}
+
+	distribution.Mean = distribution.Sum / float64(distribution.Count)
+	distribution.Median = distribution.percentile(50)
+	for _, p := range SummaryPercentiles {
+		distribution.Percentiles[fmt.Sprintf("p%g", p)] = distribution.percentile(p)
+	}
+}
+
+// percentile estimates the p-th percentile assuming that the values
+// are spread uniformly within every histogram bin.
+func (distribution *NumericDistribution) percentile(p float64) float64 {
+
+	rank := p / 100 * float64(distribution.Count)
+	var cumulativeCount int64
+	for i, count := range distribution.BinCounts {
+		if count == 0 {
+			continue
+		}
+		if float64(cumulativeCount+count) >= rank {
+			lower, upper := distribution.binBounds(i)
+			fraction := (rank - float64(cumulativeCount)) / float64(count)
+			return lower + fraction*(upper-lower)
+		}
+		cumulativeCount += count
+	}
+
+	return distribution.Max
+}
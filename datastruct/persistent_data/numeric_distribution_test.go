@@ -0,0 +1,94 @@
+package persistent_data
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestNumericDistributionMerge verifies that the values of a replay summary
+// are binned with the edges of the receiving distribution.
+func TestNumericDistributionMerge(t *testing.T) {
+
+	first := NewNumericDistribution([]float64{10, 20})
+	replay := NewNumericDistribution(nil)
+	for _, value := range []float64{25, 5, 15, 10, 30} {
+		replay.Add(value)
+	}
+
+	first.Merge(&replay)
+	first.CalculateStatistics()
+
+	if first.Count != 5 || first.Min != 5 || first.Max != 30 || first.Mean != 17 {
+		t.Errorf("Test Failed! Unexpected statistics: %+v", first)
+	}
+	// Values equal to an edge belong to the bin starting at the edge:
+	expectedBinCounts := []int64{1, 2, 2}
+	if !reflect.DeepEqual(first.BinCounts, expectedBinCounts) {
+		t.Errorf("Test Failed! Expected bin counts %v, got %v", expectedBinCounts, first.BinCounts)
+	}
+	// Percentiles are interpolated within the bins bounded by the min and the max:
+	if first.Median != 17.5 || first.Percentiles["p95"] != 28.75 {
+		t.Errorf("Test Failed! Unexpected percentiles: %v, median %f", first.Percentiles, first.Median)
+	}
+}
+
+// TestNumericDistributionMergeSaved verifies that the saved distributions
+// do not hold the values and are merged by adding their histograms.
+func TestNumericDistributionMergeSaved(t *testing.T) {
+
+	binEdges := []float64{10, 20}
+	packageDistribution := NewNumericDistribution(binEdges)
+	replay := NewNumericDistribution(nil)
+	for _, value := range []float64{25, 5, 15} {
+		replay.Add(value)
+	}
+	packageDistribution.Merge(&replay)
+
+	distributionBytes, err := json.Marshal(packageDistribution)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not marshal the distribution: %v", err)
+	}
+	if strings.Contains(string(distributionBytes), "values") {
+		t.Errorf("Test Failed! Saved distribution holds the values: %s", distributionBytes)
+	}
+	var savedDistribution NumericDistribution
+	err = json.Unmarshal(distributionBytes, &savedDistribution)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not unmarshal the distribution: %v", err)
+	}
+
+	datasetDistribution := NewNumericDistribution(binEdges)
+	datasetDistribution.Merge(&savedDistribution)
+	datasetDistribution.Merge(&savedDistribution)
+	datasetDistribution.CalculateStatistics()
+
+	expectedBinCounts := []int64{2, 2, 2}
+	if !reflect.DeepEqual(datasetDistribution.BinCounts, expectedBinCounts) {
+		t.Errorf("Test Failed! Expected bin counts %v, got %v", expectedBinCounts, datasetDistribution.BinCounts)
+	}
+	if datasetDistribution.Count != 6 || datasetDistribution.Sum != 90 ||
+		datasetDistribution.Min != 5 || datasetDistribution.Max != 25 {
+		t.Errorf("Test Failed! Unexpected statistics: %+v", datasetDistribution)
+	}
+
+	// Distributions with different edges are approximated:
+	otherDistribution := NewNumericDistribution([]float64{15})
+	otherDistribution.Merge(&savedDistribution)
+	if otherDistribution.Count != 3 || otherDistribution.Sum != 45 ||
+		otherDistribution.Min != 5 || otherDistribution.Max != 25 {
+		t.Errorf("Test Failed! Unexpected approximated statistics: %+v", otherDistribution)
+	}
+}
+
+// TestNumericDistributionEmpty verifies that an empty distribution has zero statistics.
+func TestNumericDistributionEmpty(t *testing.T) {
+
+	distribution := NewNumericDistribution([]float64{1})
+	distribution.CalculateStatistics()
+
+	if distribution.Count != 0 || distribution.Mean != 0 || len(distribution.Percentiles) != 0 {
+		t.Errorf("Test Failed! Unexpected statistics: %+v", distribution)
+	}
+}
@@ -0,0 +1,179 @@
+package persistent_data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// DatasetIndexFilename is the name of the top level index
+// that merges the indexes of all of the packages.
+const DatasetIndexFilename = "dataset_index.json"
+
+// PackageInfo describes a single output package.
+type PackageInfo struct {
+	Filename    string `json:"filename"`
+	SHA256      string `json:"sha256"`
+	Size        int64  `json:"size"`
+	ReplayCount int    `json:"replayCount"`
+}
+
+// ReplayIndexEntry holds the information that allows to find
+// a replay in the output packages without unpacking them.
+// ReplayFile, SourceSHA256 and GameFingerprint identify the source replay,
+// they are replaced with their keyed HMAC or omitted if any of the privacy options is set.
+type ReplayIndexEntry struct {
+	ReplayFile      string    `json:"replayFile,omitempty"`
+	Package         string    `json:"package"`
+	EntryNames      []string  `json:"entryNames"`
+	SourceSHA256    string    `json:"sourceSHA256,omitempty"`
+	OutputSize      int64     `json:"outputSize"`
+	GameFingerprint string    `json:"gameFingerprint,omitempty"`
+	Map             string    `json:"map"`
+	Matchup         string    `json:"matchup"`
+	Date            time.Time `json:"date"`
+	GameVersion     string    `json:"gameVersion"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	// QuasiIdentifiers holds the values of the settings.QuasiIdentifierAttributes
+	// used by the k-anonymity report:
+	QuasiIdentifiers map[string]string `json:"quasiIdentifiers"`
+}
+
+// PackageIndex lists all of the replays that were saved into a single package.
+type PackageIndex struct {
+	SchemaVersion string             `json:"schemaVersion"`
+	Package       PackageInfo        `json:"package"`
+	Replays       []ReplayIndexEntry `json:"replays"`
+}
+
+// DatasetIndex merges the indexes of all of the packages.
+type DatasetIndex struct {
+	SchemaVersion string             `json:"schemaVersion"`
+	Packages      []PackageInfo      `json:"packages"`
+	Replays       []ReplayIndexEntry `json:"replays"`
+}
+
+// NewPackageIndex returns an initialized PackageIndex
+func NewPackageIndex() PackageIndex {
+	return PackageIndex{
+		SchemaVersion: settings.OutputSchemaVersion,
+		Replays:       []ReplayIndexEntry{},
+	}
+}
+
+// NewDatasetIndex returns an initialized DatasetIndex
+func NewDatasetIndex() DatasetIndex {
+	return DatasetIndex{
+		SchemaVersion: settings.OutputSchemaVersion,
+		Packages:      []PackageInfo{},
+		Replays:       []ReplayIndexEntry{},
+	}
+}
+
+// PackageIndexFilename returns the name of the index file of a package.
+func PackageIndexFilename(fileNumber int) string {
+	return fmt.Sprintf("package_index_%v.json", fileNumber)
+}
+
+// CreatePackageIndexFile receives packageIndex and fileNumber
+// then saves the package index file onto the drive.
+func CreatePackageIndexFile(
+	absolutePathOutputDirectory string,
+	packageIndex PackageIndex,
+	fileNumber int,
+) error {
+
+	log.Debug("Entered CreatePackageIndexFile()")
+
+	packageIndexPath := filepath.Join(
+		absolutePathOutputDirectory,
+		PackageIndexFilename(fileNumber),
+	)
+	err := saveJSONFile(packageIndexPath, packageIndex)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to save the package index file!")
+		return err
+	}
+
+	log.Debug("Finished CreatePackageIndexFile()")
+	return nil
+}
+
+// CreateDatasetIndexFile reads the index files of all of the packages
+// and merges them into a single index saved in the output directory.
+func CreateDatasetIndexFile(
+	absolutePathOutputDirectory string,
+	numberOfPackages int,
+) error {
+
+	log.Debug("Entered CreateDatasetIndexFile()")
+
+	datasetIndex := NewDatasetIndex()
+	for fileNumber := 0; fileNumber < numberOfPackages; fileNumber++ {
+		packageIndexPath := filepath.Join(
+			absolutePathOutputDirectory,
+			PackageIndexFilename(fileNumber),
+		)
+		packageIndexBytes, err := os.ReadFile(packageIndexPath)
+		if err != nil {
+			// Package could have failed to be saved, the rest of the packages
+			// are still indexed:
+			log.WithFields(log.Fields{
+				"error":            err,
+				"packageIndexPath": packageIndexPath,
+			}).Warn("Failed to read the package index file, skipping.")
+			continue
+		}
+
+		var packageIndex PackageIndex
+		err = json.Unmarshal(packageIndexBytes, &packageIndex)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":            err,
+				"packageIndexPath": packageIndexPath,
+			}).Error("Failed to unmarshal the package index file!")
+			return fmt.Errorf("failed to unmarshal %s: %v", packageIndexPath, err)
+		}
+
+		datasetIndex.Packages = append(datasetIndex.Packages, packageIndex.Package)
+		datasetIndex.Replays = append(datasetIndex.Replays, packageIndex.Replays...)
+	}
+
+	datasetIndexPath := filepath.Join(absolutePathOutputDirectory, DatasetIndexFilename)
+	err := saveJSONFile(datasetIndexPath, datasetIndex)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to save the dataset index file!")
+		return err
+	}
+
+	log.Debug("Finished CreateDatasetIndexFile()")
+	return nil
+}
+
+// saveJSONFile marshals the value and saves it under the supplied path.
+func saveJSONFile(filePath string, value any) error {
+
+	file, err := file_utils.CreateTruncateFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %v", filePath, err)
+	}
+
+	_, err = file.Write(valueBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", filePath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package persistent_data
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCreateDatasetIndexFile verifies that the indexes of the packages
+// are merged in the order of the packages and that missing packages are skipped.
+func TestCreateDatasetIndexFile(t *testing.T) {
+
+	outputDirectory := t.TempDir()
+
+	for _, fileNumber := range []int{0, 2} {
+		packageIndex := NewPackageIndex()
+		packageIndex.Package = PackageInfo{
+			Filename:    PackageIndexFilename(fileNumber),
+			ReplayCount: 1,
+		}
+		packageIndex.Replays = append(packageIndex.Replays, ReplayIndexEntry{
+			ReplayFile: PackageIndexFilename(fileNumber),
+		})
+		err := CreatePackageIndexFile(outputDirectory, packageIndex, fileNumber)
+		if err != nil {
+			t.Fatalf("Test Failed! Could not create the package index: %v", err)
+		}
+	}
+
+	err := CreateDatasetIndexFile(outputDirectory, 3)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not create the dataset index: %v", err)
+	}
+
+	datasetIndexBytes, err := os.ReadFile(filepath.Join(outputDirectory, DatasetIndexFilename))
+	if err != nil {
+		t.Fatalf("Test Failed! Could not read the dataset index: %v", err)
+	}
+	var datasetIndex DatasetIndex
+	err = json.Unmarshal(datasetIndexBytes, &datasetIndex)
+	if err != nil {
+		t.Fatalf("Test Failed! Could not unmarshal the dataset index: %v", err)
+	}
+
+	if len(datasetIndex.Packages) != 2 || len(datasetIndex.Replays) != 2 {
+		t.Fatalf("Test Failed! Expected 2 packages and 2 replays, got %d and %d",
+			len(datasetIndex.Packages), len(datasetIndex.Replays))
+	}
+	if datasetIndex.Replays[1].ReplayFile != PackageIndexFilename(2) {
+		t.Errorf("Test Failed! Replays are not in the order of the packages.")
+	}
+}
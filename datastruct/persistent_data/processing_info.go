@@ -104,3 +104,37 @@ func SaveProcessingInfoToFile(
 
 	log.Debug("Finished SaveProcessingInfo()")
 }
+
+// ReadProcessingInfoFile reads the processing info file
+// that was saved by SaveProcessingInfoToFile.
+func ReadProcessingInfoFile(processingInfoPath string) (ProcessingInfo, error) {
+
+	log.Debug("Entered ReadProcessingInfoFile()")
+
+	processingInfoBytes, err := os.ReadFile(processingInfoPath)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to read the processing info file!")
+		return ProcessingInfo{}, err
+	}
+
+	processingInfo := NewProcessingInfo()
+	err = json.Unmarshal(processingInfoBytes, &processingInfo)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to unmarshal the processing info file!")
+		return ProcessingInfo{}, fmt.Errorf("failed to unmarshal %s: %v", processingInfoPath, err)
+	}
+
+	log.Debug("Finished ReadProcessingInfoFile()")
+	return processingInfo, nil
+}
+
+// FailureReasonCounts returns the number of failed files per failure reason.
+func (processingInfo *ProcessingInfo) FailureReasonCounts() map[string]int64 {
+
+	failureReasonCounts := make(map[string]int64)
+	for _, failedFile := range processingInfo.FailedToProcess {
+		failureReasonCounts[failedFile["reason"]]++
+	}
+
+	return failureReasonCounts
+}
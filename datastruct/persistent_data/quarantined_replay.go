@@ -0,0 +1,48 @@
+package persistent_data
+
+import (
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+)
+
+// QuarantinedReplay is the sidecar that is saved next to a replay
+// copied into the quarantine directory, describing why it was rejected.
+type QuarantinedReplay struct {
+	SchemaVersion string            `json:"schemaVersion"`
+	ReplayFile    string            `json:"replayFile"`
+	Stage         string            `json:"stage"`
+	Reason        string            `json:"reason"`
+	Violations    []string          `json:"violations"`
+	Error         string            `json:"error"`
+	Details       map[string]string `json:"details"`
+	GameVersion   string            `json:"gameVersion"`
+	MapName       string            `json:"mapName"`
+	Date          string            `json:"date"`
+}
+
+// NewQuarantinedReplay describes the rejected replay file in the quarantine directory.
+func NewQuarantinedReplay(
+	replayFile string,
+	rejectedReplay RejectedReplay,
+) QuarantinedReplay {
+
+	quarantinedReplay := QuarantinedReplay{
+		SchemaVersion: settings.OutputSchemaVersion,
+		ReplayFile:    replayFile,
+		Stage:         rejectedReplay.Stage,
+		Reason:        rejectedReplay.Reason,
+		Violations:    rejectedReplay.Violations,
+		Error:         rejectedReplay.Error,
+		Details:       rejectedReplay.Details,
+		GameVersion:   rejectedReplay.GameVersion,
+		MapName:       rejectedReplay.MapName,
+		Date:          rejectedReplay.Date,
+	}
+	if quarantinedReplay.Violations == nil {
+		quarantinedReplay.Violations = []string{}
+	}
+	if quarantinedReplay.Details == nil {
+		quarantinedReplay.Details = make(map[string]string)
+	}
+
+	return quarantinedReplay
+}
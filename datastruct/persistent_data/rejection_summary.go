@@ -0,0 +1,107 @@
+package persistent_data
+
+// Stages of the processing that can reject a replay.
+const (
+	RejectionStageRead          = "read"
+	RejectionStageIntegrity     = "integrity"
+	RejectionStageValidity      = "validity"
+	RejectionStageFiltering     = "filtering"
+	RejectionStageCleanup       = "cleanup"
+	RejectionStageSummary       = "summary"
+	RejectionStageAnonymization = "anonymization"
+	RejectionStageStringify     = "stringify"
+	RejectionStageTimeSeries    = "timeseries"
+)
+
+// RejectedReplay describes a replay that was rejected by one of the stages
+// of the processing. Information about the replay is empty if it could not be read.
+type RejectedReplay struct {
+	Stage  string
+	Reason string
+	// Violations hold the individual rules that the replay violated,
+	// every one of them is counted as a reason of the stage:
+	Violations []string
+	// Error holds the error returned by the operation that failed,
+	// it is empty if the replay was rejected by one of the checks:
+	Error string
+	// Details hold the values of the replay that caused the rejection,
+	// such as the descriptions of the violated rules:
+	Details     map[string]string
+	GameVersion string
+	MapName     string
+	Date        string
+}
+
+// RejectionSummary counts the replays that were rejected during the processing,
+// so that the selection bias of the dataset can be reported.
+type RejectionSummary struct {
+	Stages map[string]int64 `json:"stages"`
+	// Reasons holds the counts of the reasons within every stage,
+	// a replay violating multiple validity rules is counted for each of them:
+	Reasons      map[string]map[string]int64 `json:"reasons"`
+	GameVersions map[string]int64            `json:"gameVersions"`
+	Maps         map[string]int64            `json:"maps"`
+	Dates        map[string]int64            `json:"dates"`
+}
+
+// NewRejectionSummary returns a RejectionSummary with initialized fields.
+func NewRejectionSummary() RejectionSummary {
+	return RejectionSummary{
+		Stages:       make(map[string]int64),
+		Reasons:      make(map[string]map[string]int64),
+		GameVersions: make(map[string]int64),
+		Maps:         make(map[string]int64),
+		Dates:        make(map[string]int64),
+	}
+}
+
+// AddRejectedReplay counts the rejected replay.
+func (rejectionSummary *RejectionSummary) AddRejectedReplay(
+	rejectedReplay RejectedReplay,
+) {
+
+	rejectionSummary.Stages[rejectedReplay.Stage]++
+	stageReasons, ok := rejectionSummary.Reasons[rejectedReplay.Stage]
+	if !ok {
+		stageReasons = make(map[string]int64)
+		rejectionSummary.Reasons[rejectedReplay.Stage] = stageReasons
+	}
+	if len(rejectedReplay.Violations) == 0 {
+		stageReasons[rejectedReplay.Reason]++
+	}
+	for _, violation := range rejectedReplay.Violations {
+		stageReasons[violation]++
+	}
+
+	if rejectedReplay.GameVersion != "" {
+		rejectionSummary.GameVersions[rejectedReplay.GameVersion]++
+	}
+	if rejectedReplay.MapName != "" {
+		rejectionSummary.Maps[rejectedReplay.MapName]++
+	}
+	if rejectedReplay.Date != "" {
+		rejectionSummary.Dates[rejectedReplay.Date]++
+	}
+}
+
+// AddRejectedReplayToPackageSumm counts the rejected replay in the package summary.
+func AddRejectedReplayToPackageSumm(
+	rejectedReplay RejectedReplay,
+	packageSummary *PackageSummary,
+) {
+	packageSummary.Summary.Rejected.AddRejectedReplay(rejectedReplay)
+}
+
+// mergeRejectionSummary adds the counts of the source rejection summary
+// to the destination rejection summary.
+func mergeRejectionSummary(
+	source *RejectionSummary,
+	destination *RejectionSummary,
+) {
+
+	collapseMapToMap(&source.Stages, &destination.Stages)
+	collapseNestedMapToMap(source.Reasons, destination.Reasons)
+	collapseMapToMap(&source.GameVersions, &destination.GameVersions)
+	collapseMapToMap(&source.Maps, &destination.Maps)
+	collapseMapToMap(&source.Dates, &destination.Dates)
+}
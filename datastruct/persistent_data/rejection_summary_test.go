@@ -0,0 +1,72 @@
+package persistent_data
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRejectionSummaryMerge verifies that the rejected replays are counted
+// by stage and reason and merged into the package summary.
+func TestRejectionSummaryMerge(t *testing.T) {
+
+	replaySummary := NewReplaySummary()
+	replaySummary.Summary.Rejected.AddRejectedReplay(RejectedReplay{
+		Stage:  RejectionStageRead,
+		Reason: "rep.NewFromFile() failed",
+	})
+
+	packageSummary := NewPackageSummary(SummaryBins{})
+	AddRejectedReplayToPackageSumm(RejectedReplay{
+		Stage:       RejectionStageIntegrity,
+		Reason:      "checkIntegrity() failed",
+		GameVersion: "5.0.11",
+		MapName:     "Map",
+		Date:        "2021-5-3",
+	}, &packageSummary)
+	AddRejectedReplayToPackageSumm(RejectedReplay{
+		Stage:       RejectionStageIntegrity,
+		Reason:      "checkIntegrity() failed",
+		GameVersion: "5.0.11",
+	}, &packageSummary)
+	AddReplaySummToPackageSumm(&replaySummary, &packageSummary)
+
+	rejected := packageSummary.Summary.Rejected
+	if rejected.Stages[RejectionStageIntegrity] != 2 || rejected.Stages[RejectionStageRead] != 1 {
+		t.Errorf("Test Failed! Unexpected stages: %v", rejected.Stages)
+	}
+	if rejected.Reasons[RejectionStageIntegrity]["checkIntegrity() failed"] != 2 {
+		t.Errorf("Test Failed! Unexpected reasons: %v", rejected.Reasons)
+	}
+	if rejected.GameVersions["5.0.11"] != 2 || len(rejected.Maps) != 1 || len(rejected.Dates) != 1 {
+		t.Errorf("Test Failed! Unexpected replay information: %+v", rejected)
+	}
+}
+
+// TestRejectionSummaryViolations verifies that every violated rule
+// of a rejected replay is counted as a separate reason.
+func TestRejectionSummaryViolations(t *testing.T) {
+
+	rejectionSummary := NewRejectionSummary()
+	rejectionSummary.AddRejectedReplay(RejectedReplay{
+		Stage:      RejectionStageValidity,
+		Reason:     "maxMMR: ...; minAPM: ...",
+		Violations: []string{"maxMMR", "minAPM"},
+	})
+	rejectionSummary.AddRejectedReplay(RejectedReplay{
+		Stage:      RejectionStageValidity,
+		Reason:     "minAPM: ...",
+		Violations: []string{"minAPM"},
+	})
+
+	if rejectionSummary.Stages[RejectionStageValidity] != 2 {
+		t.Errorf("Test Failed! Unexpected stages: %v", rejectionSummary.Stages)
+	}
+	expectedReasons := map[string]int64{"maxMMR": 1, "minAPM": 2}
+	if !reflect.DeepEqual(rejectionSummary.Reasons[RejectionStageValidity], expectedReasons) {
+		t.Errorf(
+			"Test Failed! Expected reasons %v, got %v",
+			expectedReasons,
+			rejectionSummary.Reasons[RejectionStageValidity],
+		)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
 	"github.com/Kaszanas/SC2InfoExtractorGo/utils/file_utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -12,7 +13,8 @@ import (
 // PackageSummary is a structure contains statistics
 // calculated from replay information that belong to a whole ZIP archive.
 type PackageSummary struct {
-	Summary Summary
+	SchemaVersion string `json:"schemaVersion"`
+	Summary       Summary
 }
 
 // ReplaySummary contains information calculated from a single replay
@@ -21,8 +23,18 @@ type ReplaySummary struct {
 }
 
 // NewPackageSummary returns an initialized PackageSummary
-func NewPackageSummary() PackageSummary {
-	return PackageSummary{Summary: NewSummary()}
+// with the numeric distributions using the supplied histogram bins.
+func NewPackageSummary(bins SummaryBins) PackageSummary {
+
+	summary := NewSummary()
+	summary.GameLengthSeconds = NewNumericDistribution(bins.GameLengthSeconds)
+	summary.APM = NewNumericDistribution(bins.APM)
+	summary.MMR = NewNumericDistribution(bins.MMR)
+
+	return PackageSummary{
+		SchemaVersion: settings.OutputSchemaVersion,
+		Summary:       summary,
+	}
 }
 
 // NewReplaySummary returns an initialized ReplaySummary
@@ -33,62 +45,81 @@ func NewReplaySummary() ReplaySummary {
 // Summary is an abstract type used by both ReplaySummary
 // and PackageSummary and contains fields that are used as descriptive statistics
 type Summary struct {
-	GameVersions     map[string]int64 `json:"gameVersions"`
-	GameTimes        map[string]int64 `json:"gameTimes"`
-	Maps             map[string]int64 `json:"maps"`
-	MapsGameTimes    GameTimes        `json:"mapGameTimes"`
-	Races            map[string]int64 `json:"races"`
-	Units            map[string]int64 `json:"units"`
-	OtherUnits       map[string]int64 `json:"otherUnits"`
-	Dates            map[string]int64 `json:"dates"`
-	DatesGameTimes   GameTimes        `json:"datesGameTimes"`
-	Servers          map[string]int64 `json:"servers"`
+	GameVersions   map[string]int64 `json:"gameVersions"`
+	GameTimes      map[string]int64 `json:"gameTimes"`
+	Maps           map[string]int64 `json:"maps"`
+	MapsGameTimes  GameTimes        `json:"mapGameTimes"`
+	Races          map[string]int64 `json:"races"`
+	Units          map[string]int64 `json:"units"`
+	OtherUnits     map[string]int64 `json:"otherUnits"`
+	Dates          map[string]int64 `json:"dates"`
+	DatesGameTimes GameTimes        `json:"datesGameTimes"`
+	Servers        map[string]int64 `json:"servers"`
+	// Matchups are keyed by the races of each team, for example "PT-vs-ZZ":
 	MatchupCount     map[string]int64 `json:"matchupCount"`
-	MatchupGameTimes MatchupGameTimes `json:"matchupGameTimes"`
+	MatchupGameTimes GameTimes        `json:"matchupGameTimes"`
+	// Numeric distributions of the game length in seconds,
+	// APM and MMR of the players:
+	GameLengthSeconds NumericDistribution `json:"gameLengthSeconds"`
+	APM               NumericDistribution `json:"APM"`
+	MMR               NumericDistribution `json:"MMR"`
+	// Skill of the players, the distributions per race use the bins
+	// of the APM and MMR distributions:
+	Leagues        map[string]int64               `json:"leagues"`
+	LeaguePairings map[string]int64               `json:"leaguePairings"`
+	APMByRace      map[string]NumericDistribution `json:"APMByRace"`
+	MMRByRace      map[string]NumericDistribution `json:"MMRByRace"`
+	// Replays that were rejected during the processing:
+	Rejected RejectionSummary `json:"rejected"`
+	// ScrubbedFields counts the identifying values replaced during the anonymization
+	// by the path of the field, adding up the replay_data.PIIAudit of the replays:
+	ScrubbedFields map[string]int64 `json:"scrubbedFields"`
 }
 
 // NewSummary returns a Summary structure with initialized fiends
 func NewSummary() Summary {
 
 	return Summary{
-		GameVersions:     make(map[string]int64),
-		GameTimes:        make(map[string]int64),
-		Maps:             make(map[string]int64),
-		MapsGameTimes:    NewGameTimes(),
-		Races:            make(map[string]int64),
-		Units:            make(map[string]int64),
-		OtherUnits:       make(map[string]int64),
-		Dates:            make(map[string]int64),
-		DatesGameTimes:   NewGameTimes(),
-		Servers:          make(map[string]int64),
-		MatchupCount:     make(map[string]int64),
-		MatchupGameTimes: NewMatchupGameTimes(),
+		GameVersions:      make(map[string]int64),
+		GameTimes:         make(map[string]int64),
+		Maps:              make(map[string]int64),
+		MapsGameTimes:     NewGameTimes(),
+		Races:             make(map[string]int64),
+		Units:             make(map[string]int64),
+		OtherUnits:        make(map[string]int64),
+		Dates:             make(map[string]int64),
+		DatesGameTimes:    NewGameTimes(),
+		Servers:           make(map[string]int64),
+		MatchupCount:      make(map[string]int64),
+		MatchupGameTimes:  NewGameTimes(),
+		GameLengthSeconds: NewNumericDistribution([]float64{}),
+		APM:               NewNumericDistribution([]float64{}),
+		MMR:               NewNumericDistribution([]float64{}),
+		Leagues:           make(map[string]int64),
+		LeaguePairings:    make(map[string]int64),
+		APMByRace:         make(map[string]NumericDistribution),
+		MMRByRace:         make(map[string]NumericDistribution),
+		Rejected:          NewRejectionSummary(),
+		ScrubbedFields:    make(map[string]int64),
 	}
 }
 
-// MatchupHistograms aggregates the data that is required
-// to prepare histograms of Matchup vs Game Length
-type MatchupGameTimes struct {
-	PvPMatchup map[string]int64 `json:"PvPMatchupGameTimes"`
-	TvTMatchup map[string]int64 `json:"TvTMatchupGameTimes"`
-	ZvZMatchup map[string]int64 `json:"ZvZMatchupGameTimes"`
-	PvZMatchup map[string]int64 `json:"PvZMatchupGameTimes"`
-	PvTMatchup map[string]int64 `json:"PvTMatchupGameTimes"`
-	TvZMatchup map[string]int64 `json:"TvZMatchupGameTimes"`
+// CalculateStatistics calculates the statistics of all of the numeric distributions.
+func (summary *Summary) CalculateStatistics() {
+	summary.GameLengthSeconds.CalculateStatistics()
+	summary.APM.CalculateStatistics()
+	summary.MMR.CalculateStatistics()
+	calculateDistributionMapStatistics(summary.APMByRace)
+	calculateDistributionMapStatistics(summary.MMRByRace)
 }
 
-// NewMatchupHistograms returns a structure with initialized fields.
-func NewMatchupGameTimes() MatchupGameTimes {
-
-	return MatchupGameTimes{
-		PvPMatchup: make(map[string]int64),
-		TvTMatchup: make(map[string]int64),
-		ZvZMatchup: make(map[string]int64),
-		PvZMatchup: make(map[string]int64),
-		PvTMatchup: make(map[string]int64),
-		TvZMatchup: make(map[string]int64),
+// calculateDistributionMapStatistics calculates the statistics
+// of every numeric distribution within the map.
+func calculateDistributionMapStatistics(distributions map[string]NumericDistribution) {
+	for key, distribution := range distributions {
+		distribution.CalculateStatistics()
+		distributions[key] = distribution
 	}
-
 }
 
 type GameTimes struct {
@@ -110,14 +141,17 @@ func CreatePackageSummaryFile(
 ) error {
 	log.Debug("Entered CreatePackageSummaryFile()")
 
-	packageSummaryFilename := fmt.Sprintf("package_summary_%v.json", fileNumber)
-	packageAbsolutePath := filepath.Join(absolutePathOutputDirectory, packageSummaryFilename)
+	packageAbsolutePath := filepath.Join(
+		absolutePathOutputDirectory,
+		PackageSummaryFilename(fileNumber),
+	)
 	packageSummaryFile, err := file_utils.CreateTruncateFile(packageAbsolutePath)
 	if err != nil {
 		log.Error("Failed to create the package summary file!")
 		return err
 	}
 
+	packageSummaryStruct.Summary.CalculateStatistics()
 	packageSummaryBytes, err := json.Marshal(packageSummaryStruct)
 	if err != nil {
 		log.WithField("error", err).
@@ -150,99 +184,164 @@ func AddReplaySummToPackageSumm(
 
 	log.Debug("Entered AddReplaySummToPackageSumm()")
 
-	// Adding GameVersion information to PackageSummary:
+	mergeSummary(&replaySummary.Summary, &packageSummary.Summary)
+
+	log.Debug("Finished AddReplaySummToPackageSumm()")
+}
+
+// mergeSummary adds all of the counts and distributions of the source summary
+// to the destination summary.
+func mergeSummary(source *Summary, destination *Summary) {
+
+	log.Debug("Entered mergeSummary()")
+
+	// Adding GameVersion information to the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.GameVersions,
-		&packageSummary.Summary.GameVersions)
+		&source.GameVersions,
+		&destination.GameVersions)
 	log.Info("Finished collapsing GameVersions")
 
-	// Adding GameTimes information to PackageSummary:
+	// Adding GameTimes information to the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.GameTimes,
-		&packageSummary.Summary.GameTimes)
+		&source.GameTimes,
+		&destination.GameTimes)
 	log.Info("Finished collapsing GameTimes")
 
-	// Adding Maps information to PackageSummary:
+	// Adding Maps information to the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.Maps,
-		&packageSummary.Summary.Maps)
+		&source.Maps,
+		&destination.Maps)
 	log.Info("Finished collapsing Maps")
 
-	// Adding Races information to PackageSummary:
+	// Adding Races information to the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.Races,
-		&packageSummary.Summary.Races)
+		&source.Races,
+		&destination.Races)
 	log.Info("Finished collapsing Races")
 
-	// Adding Units information to PackageSummary:
+	// Adding Units information to the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.Units,
-		&packageSummary.Summary.Units)
+		&source.Units,
+		&destination.Units)
 	log.Info("Finished collapsing Units")
 	collapseMapToMap(
-		&replaySummary.Summary.OtherUnits,
-		&packageSummary.Summary.OtherUnits)
+		&source.OtherUnits,
+		&destination.OtherUnits)
 	log.Info("Finished collapsing OtherUnits")
 
-	// Adding Dates information to PackageSummary:
+	// Adding Dates information to the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.Dates,
-		&packageSummary.Summary.Dates)
+		&source.Dates,
+		&destination.Dates)
 	log.Info("Finished collapsing Dates")
 
 	// Creating nested structures for game times by dates:
-	for key, replayGameTimeMap := range replaySummary.Summary.DatesGameTimes.GameTimes {
-		if packageSummaryMap, ok := packageSummary.Summary.DatesGameTimes.GameTimes[key]; ok {
-			collapseMapToMap(&replayGameTimeMap, &packageSummaryMap)
-			packageSummary.Summary.DatesGameTimes.GameTimes[key] = packageSummaryMap
-		} else {
-			packageSummary.Summary.DatesGameTimes.GameTimes[key] = replayGameTimeMap
-		}
-	}
+	collapseGameTimes(&source.DatesGameTimes, &destination.DatesGameTimes)
 
 	// Creating nested structures for game times by maps:
-	for key, replayGameTimeMap := range replaySummary.Summary.MapsGameTimes.GameTimes {
-		if packageSummaryMap, ok := packageSummary.Summary.MapsGameTimes.GameTimes[key]; ok {
-			collapseMapToMap(&replayGameTimeMap, &packageSummaryMap)
-			packageSummary.Summary.MapsGameTimes.GameTimes[key] = packageSummaryMap
-		} else {
-			packageSummary.Summary.MapsGameTimes.GameTimes[key] = replayGameTimeMap
-		}
-	}
+	collapseGameTimes(&source.MapsGameTimes, &destination.MapsGameTimes)
 
-	// Adding Servers information to PackageSummary:
+	// Adding Servers information to the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.Servers,
-		&packageSummary.Summary.Servers)
+		&source.Servers,
+		&destination.Servers)
 	log.Info("Finished collapsing Servers")
 
-	// Adding matchup count information to the PackageSummary:
+	// Adding matchup count information to the the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.MatchupCount,
-		&packageSummary.Summary.MatchupCount)
+		&source.MatchupCount,
+		&destination.MatchupCount)
 
 	// Collapsing all of the matchup game times:
+	collapseGameTimes(&source.MatchupGameTimes, &destination.MatchupGameTimes)
+
+	log.Info("Finished collapsing matchup information")
+
+	// Merging the numeric distributions:
+	destination.GameLengthSeconds.Merge(&source.GameLengthSeconds)
+	destination.APM.Merge(&source.APM)
+	destination.MMR.Merge(&source.MMR)
+	log.Info("Finished merging numeric distributions")
+
+	// Adding the skill information of the players to the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.MatchupGameTimes.PvPMatchup,
-		&packageSummary.Summary.MatchupGameTimes.PvPMatchup)
-	collapseMapToMap(
-		&replaySummary.Summary.MatchupGameTimes.PvTMatchup,
-		&packageSummary.Summary.MatchupGameTimes.PvTMatchup)
-	collapseMapToMap(
-		&replaySummary.Summary.MatchupGameTimes.PvZMatchup,
-		&packageSummary.Summary.MatchupGameTimes.PvZMatchup)
-	collapseMapToMap(
-		&replaySummary.Summary.MatchupGameTimes.TvTMatchup,
-		&packageSummary.Summary.MatchupGameTimes.TvTMatchup)
+		&source.Leagues,
+		&destination.Leagues)
 	collapseMapToMap(
-		&replaySummary.Summary.MatchupGameTimes.TvZMatchup,
-		&packageSummary.Summary.MatchupGameTimes.TvZMatchup)
+		&source.LeaguePairings,
+		&destination.LeaguePairings)
+	mergeDistributionMap(
+		source.APMByRace,
+		destination.APMByRace,
+		destination.APM.BinEdges)
+	mergeDistributionMap(
+		source.MMRByRace,
+		destination.MMRByRace,
+		destination.MMR.BinEdges)
+	log.Info("Finished merging skill information")
+
+	// Adding the rejected replays to the destination:
+	mergeRejectionSummary(&source.Rejected, &destination.Rejected)
+	log.Info("Finished merging rejected replays")
+
+	// Adding the audit of the anonymization to the destination:
 	collapseMapToMap(
-		&replaySummary.Summary.MatchupGameTimes.ZvZMatchup,
-		&packageSummary.Summary.MatchupGameTimes.ZvZMatchup)
+		&source.ScrubbedFields,
+		&destination.ScrubbedFields)
+	log.Info("Finished collapsing ScrubbedFields")
+	log.Debug("Finished mergeSummary()")
+}
 
-	log.Info("Finished collapsing matchup information")
-	log.Debug("Finished AddReplaySummToPackageSumm()")
+// collapseGameTimes adds the game times of every key of one GameTimes to another.
+func collapseGameTimes(
+	gameTimesToCollapse *GameTimes,
+	collapseInto *GameTimes,
+) {
+
+	log.Debug("Entered collapseGameTimes()")
+
+	collapseNestedMapToMap(gameTimesToCollapse.GameTimes, collapseInto.GameTimes)
+
+	log.Debug("Finished collapseGameTimes()")
+}
+
+// collapseNestedMapToMap adds the nested maps of every key of one map to another.
+func collapseNestedMapToMap(
+	mapToCollapse map[string]map[string]int64,
+	collapseInto map[string]map[string]int64,
+) {
+
+	for key, nestedMap := range mapToCollapse {
+		collapseIntoMap, ok := collapseInto[key]
+		if !ok {
+			collapseIntoMap = make(map[string]int64)
+			collapseInto[key] = collapseIntoMap
+		}
+		collapseMapToMap(&nestedMap, &collapseIntoMap)
+	}
+}
+
+// mergeDistributionMap merges the numeric distributions of every key of one map
+// into another. Distributions of the keys that are missing in the destination
+// are created with the supplied bin edges.
+func mergeDistributionMap(
+	distributionsToMerge map[string]NumericDistribution,
+	mergeInto map[string]NumericDistribution,
+	binEdges []float64,
+) {
+
+	log.Debug("Entered mergeDistributionMap()")
+
+	for key, distribution := range distributionsToMerge {
+		mergeIntoDistribution, ok := mergeInto[key]
+		if !ok {
+			mergeIntoDistribution = NewNumericDistribution(binEdges)
+		}
+		mergeIntoDistribution.Merge(&distribution)
+		mergeInto[key] = mergeIntoDistribution
+	}
+
+	log.Debug("Finished mergeDistributionMap()")
 }
 
 // collapseMapToMap adds the keys and values of one map to another.
@@ -69,15 +69,9 @@ func TestAddReplaySummToPackageSumm(t *testing.T) {
 			Dates:          map[string]int64{"2017-01-01": 1},
 			DatesGameTimes: GameTimes{map[string]map[string]int64{"2017-01-01": {"2": 1}}},
 			Servers:        map[string]int64{"EU": 1},
-			MatchupCount:   map[string]int64{"TvT": 1},
-			MatchupGameTimes: MatchupGameTimes{
-				PvPMatchup: map[string]int64{},
-				TvTMatchup: map[string]int64{"2": 1},
-				ZvZMatchup: map[string]int64{},
-				PvZMatchup: map[string]int64{},
-				PvTMatchup: map[string]int64{},
-				TvZMatchup: map[string]int64{},
-			},
+			MatchupCount:   map[string]int64{"T-vs-T": 1},
+			MatchupGameTimes: GameTimes{
+				map[string]map[string]int64{"T-vs-T": {"2": 1}}},
 		}}
 
 	packageSummary := PackageSummary{
@@ -93,15 +87,9 @@ func TestAddReplaySummToPackageSumm(t *testing.T) {
 			Dates:          map[string]int64{"2017-01-01": 1},
 			DatesGameTimes: GameTimes{map[string]map[string]int64{"2017-01-01": {"2": 1}}},
 			Servers:        map[string]int64{"EU": 1},
-			MatchupCount:   map[string]int64{"PvT": 1},
-			MatchupGameTimes: MatchupGameTimes{
-				PvPMatchup: map[string]int64{},
-				TvTMatchup: map[string]int64{},
-				ZvZMatchup: map[string]int64{},
-				PvZMatchup: map[string]int64{},
-				PvTMatchup: map[string]int64{"2": 1},
-				TvZMatchup: map[string]int64{},
-			},
+			MatchupCount:   map[string]int64{"P-vs-T": 1},
+			MatchupGameTimes: GameTimes{
+				map[string]map[string]int64{"P-vs-T": {"2": 1}}},
 		}}
 
 	AddReplaySummToPackageSumm(&replaySummary, &packageSummary)
@@ -118,6 +106,10 @@ func TestAddReplaySummToPackageSumm(t *testing.T) {
 		t.Errorf("Expected 2, got %v", len(packageSummary.Summary.MatchupCount))
 	}
 
+	if len(packageSummary.Summary.MatchupGameTimes.GameTimes) != 2 {
+		t.Errorf("Expected 2, got %v", len(packageSummary.Summary.MatchupGameTimes.GameTimes))
+	}
+
 }
 
 // mapsAreEqual is a helper function that compares two maps to see if they are the same.
@@ -134,3 +126,36 @@ func mapsAreEqual(map1, map2 map[string]int64) bool {
 
 	return true
 }
+
+// TestMergeDistributionMap verifies that the distributions missing
+// in the destination are created with the supplied bin edges.
+func TestMergeDistributionMap(t *testing.T) {
+
+	source := map[string]NumericDistribution{
+		"Terr": NewNumericDistribution(nil),
+		"Zerg": NewNumericDistribution(nil),
+	}
+	for race, values := range map[string][]float64{"Terr": {100, 200}, "Zerg": {50}} {
+		distribution := source[race]
+		for _, value := range values {
+			distribution.Add(value)
+		}
+		source[race] = distribution
+	}
+	terranDistribution := NewNumericDistribution([]float64{150})
+	terranDistribution.Add(300)
+	destination := map[string]NumericDistribution{
+		"Terr": terranDistribution,
+	}
+
+	mergeDistributionMap(source, destination, []float64{150})
+	calculateDistributionMapStatistics(destination)
+
+	if destination["Terr"].Count != 3 || destination["Zerg"].Count != 1 {
+		t.Fatalf("Test Failed! Unexpected counts: %+v", destination)
+	}
+	zergBins := destination["Zerg"].BinCounts
+	if len(zergBins) != 2 || zergBins[0] != 1 {
+		t.Errorf("Test Failed! Unexpected bins of the new distribution: %v", zergBins)
+	}
+}
@@ -0,0 +1,28 @@
+package datastruct
+
+// QuarantineModeEnum is an enum type which holds the available ways
+// of placing the rejected replays in the quarantine directory.
+type QuarantineModeEnum int
+
+// Quarantine modes:
+const (
+	// HardLinkQuarantineMode hard links the rejected replays,
+	// falling back to copying if the link cannot be created,
+	// for example if the quarantine directory is on a different drive.
+	HardLinkQuarantineMode QuarantineModeEnum = iota
+	// CopyQuarantineMode copies the rejected replays.
+	CopyQuarantineMode
+)
+
+// quarantineModeNames maps the names that are accepted in the CLI to the quarantine modes.
+var quarantineModeNames = map[string]QuarantineModeEnum{
+	"link": HardLinkQuarantineMode,
+	"copy": CopyQuarantineMode,
+}
+
+// QuarantineModeFromString returns the quarantine mode corresponding to the name
+// provided in the CLI and a boolean specifying if the name is known.
+func QuarantineModeFromString(quarantineModeName string) (QuarantineModeEnum, bool) {
+	quarantineMode, ok := quarantineModeNames[quarantineModeName]
+	return quarantineMode, ok
+}
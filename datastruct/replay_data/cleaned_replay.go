@@ -6,6 +6,7 @@ import (
 
 // CleanedReplay is a structure holding cleaned data derived from s2prot.Rep
 type CleanedReplay struct {
+	SchemaVersion     string                         `json:"schemaVersion"`
 	Header            CleanedHeader                  `json:"header"`
 	InitData          CleanedInitData                `json:"initData"`
 	Details           CleanedDetails                 `json:"details"`
@@ -14,9 +15,14 @@ type CleanedReplay struct {
 	GameEvents        []map[string]any               `json:"gameEvents"`
 	TrackerEvents     []s2prot.Struct                `json:"trackerEvents"`
 	ToonPlayerDescMap map[string]EnhancedToonDescMap `json:"ToonPlayerDescMap"` //map[string]*rep.PlayerDesc
+	Observers         []ObserverDesc                 `json:"observers"`
+	GameEnd           GameEnd                        `json:"gameEnd"`
+	PIIAudit          PIIAudit                       `json:"piiAudit"`
 	GameEvtsErr       bool                           `json:"gameEventsErr"`
 	MessageEvtsErr    bool                           `json:"messageEventsErr"`
 	TrackerEvtsErr    bool                           `json:"trackerEvtsErr"`
+	// GameFingerprint identifies the game and is saved only in the package index.
+	GameFingerprint string `json:"-"`
 }
 
 // EnhancedToonDescMap is a structure that provides
@@ -32,6 +38,7 @@ type EnhancedToonDescMap struct {
 	StartLocY           int64           `json:"startLocY"`
 	AssignedRace        string          `json:"race"`
 	SelectedRace        string          `json:"selectedRace"`
+	TeamID              int64           `json:"teamID"`
 	APM                 float64         `json:"APM"`
 	MMR                 float64         `json:"MMR"`
 	Result              string          `json:"result"`
@@ -42,4 +49,95 @@ type EnhancedToonDescMap struct {
 	ClanTag             string          `json:"clanTag"`
 	Handicap            int64           `json:"handicap"`
 	Color               PlayerListColor `json:"color"`
+	// SlotRole is SlotRoleHuman or SlotRoleComputer:
+	SlotRole string `json:"slotRole"`
+	// AIDifficulty is the difficulty of the computer players as shown in the lobby,
+	// it is empty for the human players:
+	AIDifficulty string `json:"aiDifficulty"`
+}
+
+// Roles of the occupied slots of the lobby:
+const (
+	SlotRoleHuman    = "human"
+	SlotRoleComputer = "computer"
+	SlotRoleObserver = "observer"
+	// SlotRoleReferee is an observer that can talk to the players:
+	SlotRoleReferee = "referee"
+)
+
+// ObserverDesc describes an observer or a referee of the game,
+// they are not a part of the ToonPlayerDescMap.
+type ObserverDesc struct {
+	Name   string `json:"nickname"`
+	Toon   string `json:"toon"`
+	UserID int64  `json:"userID"`
+	// Role is SlotRoleObserver or SlotRoleReferee:
+	Role string `json:"role"`
+}
+
+// GameEnd describes how the game ended,
+// it is derived from the leave events of the players.
+type GameEnd struct {
+	// FirstLeaverPlayerID is 0 if none of the players left the game:
+	FirstLeaverPlayerID int64   `json:"firstLeaverPlayerID"`
+	FirstLeaveLoop      int64   `json:"firstLeaveLoop"`
+	FirstLeaveSeconds   float64 `json:"firstLeaveSeconds"`
+	// EffectiveGameLoops is the loop at which the game was decided:
+	EffectiveGameLoops       int64   `json:"effectiveGameLoops"`
+	EffectiveDurationSeconds float64 `json:"effectiveDurationSeconds"`
+	// EndReason is one of GameEndNormal, GameEndLeave or GameEndWalkover:
+	EndReason string `json:"endReason"`
+}
+
+// Reasons of the end of the game:
+const (
+	// GameEndNormal means that no team left the game before the replay ended,
+	// a team leaving within settings.NormalEndToleranceSeconds of the end,
+	// such as the losing side surrendering, ends the game normally:
+	GameEndNormal = "normal"
+	// GameEndLeave means that the result followed a team leaving the game
+	// earlier than settings.NormalEndToleranceSeconds before the end:
+	GameEndLeave = "leave"
+	// GameEndWalkover is GameEndLeave within settings.WalkoverMaxSeconds:
+	GameEndWalkover = "walkover"
+)
+
+// PIIAudit counts the values of the identifying fields that were replaced
+// during the anonymization, keyed by the path of the field,
+// for example "gameEvents.GameUserJoin.name".
+type PIIAudit map[string]int64
+
+// CleanedReplayMeta is a structure holding all of the CleanedReplay
+// information apart from the event streams.
+type CleanedReplayMeta struct {
+	SchemaVersion     string                         `json:"schemaVersion"`
+	Header            CleanedHeader                  `json:"header"`
+	InitData          CleanedInitData                `json:"initData"`
+	Details           CleanedDetails                 `json:"details"`
+	Metadata          CleanedMetadata                `json:"metadata"`
+	ToonPlayerDescMap map[string]EnhancedToonDescMap `json:"ToonPlayerDescMap"`
+	Observers         []ObserverDesc                 `json:"observers"`
+	GameEnd           GameEnd                        `json:"gameEnd"`
+	PIIAudit          PIIAudit                       `json:"piiAudit"`
+	GameEvtsErr       bool                           `json:"gameEventsErr"`
+	MessageEvtsErr    bool                           `json:"messageEventsErr"`
+	TrackerEvtsErr    bool                           `json:"trackerEvtsErr"`
+}
+
+// Meta returns the part of the CleanedReplay that does not contain the event streams.
+func (replay *CleanedReplay) Meta() CleanedReplayMeta {
+	return CleanedReplayMeta{
+		SchemaVersion:     replay.SchemaVersion,
+		Header:            replay.Header,
+		InitData:          replay.InitData,
+		Details:           replay.Details,
+		Metadata:          replay.Metadata,
+		ToonPlayerDescMap: replay.ToonPlayerDescMap,
+		Observers:         replay.Observers,
+		GameEnd:           replay.GameEnd,
+		PIIAudit:          replay.PIIAudit,
+		GameEvtsErr:       replay.GameEvtsErr,
+		MessageEvtsErr:    replay.MessageEvtsErr,
+		TrackerEvtsErr:    replay.TrackerEvtsErr,
+	}
 }
@@ -0,0 +1,30 @@
+package replay_data
+
+// TimeSeriesSidecar describes the arrays of the per player time series
+// that are exported from the PlayerStats tracker events of a replay.
+type TimeSeriesSidecar struct {
+	SchemaVersion      string                  `json:"schemaVersion"`
+	IntervalLoops      int64                   `json:"intervalLoops"`
+	GameLoopsPerSecond float64                 `json:"gameLoopsPerSecond"`
+	NumberOfSteps      int                     `json:"numberOfSteps"`
+	Features           []string                `json:"features"`
+	Arrays             []TimeSeriesArrayInfo   `json:"arrays"`
+	Players            []TimeSeriesPlayerLabel `json:"players"`
+}
+
+// TimeSeriesArrayInfo describes a single array saved in the .npz archive.
+type TimeSeriesArrayInfo struct {
+	Name  string `json:"name"`
+	DType string `json:"dtype"`
+	Shape []int  `json:"shape"`
+}
+
+// TimeSeriesPlayerLabel holds the labels of a player. Players are listed
+// in the order of the first dimension of the stats array.
+type TimeSeriesPlayerLabel struct {
+	PlayerID int64   `json:"playerID"`
+	Race     string  `json:"race"`
+	Result   string  `json:"result"`
+	MMR      float64 `json:"MMR"`
+	APM      float64 `json:"APM"`
+}
@@ -0,0 +1,30 @@
+package datastruct
+
+// TimePrecisionEnum is an enum type which holds the available precisions
+// of the time at which the game was played.
+type TimePrecisionEnum int
+
+// Time precisions:
+const (
+	// FullTimePrecision keeps the time as it was recorded in the replay.
+	FullTimePrecision TimePrecisionEnum = iota
+	// DayTimePrecision truncates the time to the start of the day (UTC).
+	DayTimePrecision
+	// WeekTimePrecision truncates the time to the start of the week,
+	// Monday of the ISO week (UTC).
+	WeekTimePrecision
+)
+
+// timePrecisionNames maps the names that are accepted in the CLI to the time precisions.
+var timePrecisionNames = map[string]TimePrecisionEnum{
+	"full": FullTimePrecision,
+	"day":  DayTimePrecision,
+	"week": WeekTimePrecision,
+}
+
+// TimePrecisionFromString returns the time precision corresponding to the name
+// provided in the CLI and a boolean specifying if the name is known.
+func TimePrecisionFromString(timePrecisionName string) (TimePrecisionEnum, bool) {
+	timePrecision, ok := timePrecisionNames[timePrecisionName]
+	return timePrecision, ok
+}
@@ -0,0 +1,124 @@
+package datastruct
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	log "github.com/sirupsen/logrus"
+)
+
+// ValidityRules holds the validity rules of every game mode,
+// replays of the game modes without rules are not validated.
+type ValidityRules struct {
+	GameModes map[string]GameModeValidityRules `json:"gameModes"`
+}
+
+// GameModeValidityRules holds the rules that the replays of a game mode
+// have to satisfy to be considered valid. Every rule can be enabled on its own.
+type GameModeValidityRules struct {
+	MinMMR ThresholdRule `json:"minMMR"`
+	MaxMMR ThresholdRule `json:"maxMMR"`
+	// MaxMMRDifference is the difference between the average MMR of the teams:
+	MaxMMRDifference   ThresholdRule `json:"maxMMRDifference"`
+	MinAPM             ThresholdRule `json:"minAPM"`
+	MaxAPM             ThresholdRule `json:"maxAPM"`
+	MinDurationSeconds ThresholdRule `json:"minDurationSeconds"`
+	MaxDurationSeconds ThresholdRule `json:"maxDurationSeconds"`
+	// RequiredFields lists the fields described in settings.ValidityRequiredFields:
+	RequiredFields    ListRule   `json:"requiredFields"`
+	BlizzardMap       SwitchRule `json:"blizzardMap"`
+	AllowedMapAuthors ListRule   `json:"allowedMapAuthors"`
+}
+
+// ThresholdRule is a rule comparing a value of the replay against a threshold.
+type ThresholdRule struct {
+	Enabled bool    `json:"enabled"`
+	Value   float64 `json:"value"`
+}
+
+// ListRule is a rule checking a value of the replay against a list of values.
+type ListRule struct {
+	Enabled bool     `json:"enabled"`
+	Values  []string `json:"values"`
+}
+
+// SwitchRule is a rule that does not need any parameters.
+type SwitchRule struct {
+	Enabled bool `json:"enabled"`
+}
+
+// DefaultValidityRules returns the rules that are used if no rules file is provided.
+// Only ranked 1v1 replays are validated, in the history of StarCraft II
+// there was no player that reached 8000 MMR.
+func DefaultValidityRules() ValidityRules {
+	return ValidityRules{
+		GameModes: map[string]GameModeValidityRules{
+			"ranked_1v1": {
+				MaxMMR:           ThresholdRule{Enabled: true, Value: 8000},
+				MaxMMRDifference: ThresholdRule{Enabled: true, Value: 1200},
+				MinAPM:           ThresholdRule{Enabled: true, Value: 1},
+				BlizzardMap:      SwitchRule{Enabled: true},
+			},
+		},
+	}
+}
+
+// ReadValidityRulesFile reads and verifies the validity rules file.
+func ReadValidityRulesFile(validityRulesPath string) (ValidityRules, error) {
+
+	log.Debug("Entered ReadValidityRulesFile()")
+
+	validityRulesBytes, err := os.ReadFile(validityRulesPath)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to read the validity rules file!")
+		return ValidityRules{}, err
+	}
+
+	validityRules := ValidityRules{}
+	decoder := json.NewDecoder(bytes.NewReader(validityRulesBytes))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&validityRules)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to unmarshal the validity rules file!")
+		return ValidityRules{}, fmt.Errorf("failed to unmarshal %s: %v", validityRulesPath, err)
+	}
+
+	err = verifyValidityRules(validityRules)
+	if err != nil {
+		log.WithField("error", err).Error("Invalid validity rules!")
+		return ValidityRules{}, fmt.Errorf("invalid validity rules in %s: %v", validityRulesPath, err)
+	}
+
+	log.Debug("Finished ReadValidityRulesFile()")
+	return validityRules, nil
+}
+
+// verifyValidityRules checks that the rules refer to the known game modes and fields.
+func verifyValidityRules(validityRules ValidityRules) error {
+
+	for gameMode, gameModeRules := range validityRules.GameModes {
+		if !slices.Contains(settings.ValidityGameModes, gameMode) {
+			return fmt.Errorf(
+				"unknown game mode %q, available game modes: %v",
+				gameMode,
+				settings.ValidityGameModes,
+			)
+		}
+		for _, field := range gameModeRules.RequiredFields.Values {
+			if !slices.Contains(settings.ValidityRequiredFields, field) {
+				return fmt.Errorf(
+					"unknown required field %q of %s, available fields: %v",
+					field,
+					gameMode,
+					settings.ValidityRequiredFields,
+				)
+			}
+		}
+	}
+
+	return nil
+}
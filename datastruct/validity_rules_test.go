@@ -0,0 +1,70 @@
+package datastruct
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReadValidityRulesFile verifies that the rules file is read
+// and that the unknown game modes, fields and rules are rejected.
+func TestReadValidityRulesFile(t *testing.T) {
+
+	testCases := []struct {
+		name        string
+		rules       string
+		expectError bool
+	}{
+		{
+			name: "valid rules",
+			rules: `{"gameModes": {"ranked_2v2": {
+				"maxMMRDifference": {"enabled": true, "value": 1000},
+				"requiredFields": {"enabled": true, "values": ["mmr", "region"]}
+			}}}`,
+			expectError: false,
+		},
+		{
+			name:        "unknown game mode",
+			rules:       `{"gameModes": {"ranked_5v5": {}}}`,
+			expectError: true,
+		},
+		{
+			name:        "unknown required field",
+			rules:       `{"gameModes": {"ranked_1v1": {"requiredFields": {"enabled": true, "values": ["elo"]}}}}`,
+			expectError: true,
+		},
+		{
+			name:        "unknown rule",
+			rules:       `{"gameModes": {"ranked_1v1": {"maxElo": {"enabled": true, "value": 1}}}}`,
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rulesPath := filepath.Join(t.TempDir(), "validity_rules.json")
+			err := os.WriteFile(rulesPath, []byte(testCase.rules), 0644)
+			if err != nil {
+				t.Fatalf("Test Failed! Cannot write the rules file: %v", err)
+			}
+
+			validityRules, err := ReadValidityRulesFile(rulesPath)
+			if testCase.expectError {
+				if err == nil {
+					t.Errorf("Test Failed! Expected an error, got rules %+v", validityRules)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Test Failed! Unexpected error: %v", err)
+			}
+			rules := validityRules.GameModes["ranked_2v2"]
+			if !rules.MaxMMRDifference.Enabled || rules.MaxMMRDifference.Value != 1000 {
+				t.Errorf("Test Failed! Unexpected rules: %+v", rules)
+			}
+			if rules.MaxMMR.Enabled || len(rules.RequiredFields.Values) != 2 {
+				t.Errorf("Test Failed! Unexpected rules: %+v", rules)
+			}
+		})
+	}
+}
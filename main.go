@@ -2,10 +2,13 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"runtime/pprof"
 
 	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc"
 	"github.com/Kaszanas/SC2InfoExtractorGo/dataproc/downloader"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
 
 	"github.com/Kaszanas/SC2InfoExtractorGo/utils"
 	"github.com/Kaszanas/SC2InfoExtractorGo/utils/chunk_utils"
@@ -21,6 +24,14 @@ func main() {
 
 func mainReturnWithCode() int {
 
+	// Commands other than the default replay processing
+	// are selected with the first command line argument:
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			return command(os.Args[2:])
+		}
+	}
+
 	// Getting the information from user to start the processing:
 	CLIflags, okFlags := utils.ParseFlags()
 	if !okFlags {
@@ -48,12 +59,46 @@ func mainReturnWithCode() int {
 		"CLIflags.SkipDependencyDownload":     CLIflags.SkipDependencyDownload,
 		"CLIflags.DependencyDirectory":        CLIflags.DependencyDirectory,
 		"CLIflags.NumberOfPackages":           CLIflags.NumberOfPackages,
+		"CLIflags.OutputLayout":               CLIflags.OutputLayout,
+		"CLIflags.OutputTemplate":             CLIflags.OutputTemplate.String(),
+		"CLIflags.PartitionBy":                CLIflags.PartitionBy,
+		"CLIflags.MetadataOnly":               CLIflags.MetadataOnly,
+		"CLIflags.Deterministic":              CLIflags.Deterministic,
+		"CLIflags.ExportTimeSeries":           CLIflags.ExportTimeSeries,
+		"CLIflags.TimeSeriesIntervalLoops":    CLIflags.TimeSeriesIntervalLoops,
+		"CLIflags.SummaryBins":                CLIflags.SummaryBins,
 		"CLIflags.PerformIntegrityCheck":      CLIflags.PerformIntegrityCheck,
+		"CLIflags.IntegrityLevel":             CLIflags.IntegrityLevel,
+		"CLIflags.EventErrorsPolicy":          CLIflags.EventErrorsPolicy,
 		"CLIflags.PerformValidityCheck":       CLIflags.PerformValidityCheck,
+		"CLIflags.ValidityRules":              CLIflags.ValidityRules,
 		"CLIflags.PerformCleanup":             CLIflags.PerformCleanup,
 		"CLIflags.PerformPlayerAnonymization": CLIflags.PerformPlayerAnonymization,
 		"CLIflags.PerformChatAnonymization":   CLIflags.PerformChatAnonymization,
+		"CLIflags.ChatAnonymizationMode":      CLIflags.ChatAnonymizationMode,
+		"CLIflags.AnonymizerMode":             CLIflags.AnonymizerMode,
+		"CLIflags.AnonymizerKeyFile":          CLIflags.AnonymizerKeyFile,
+		"CLIflags.AnonymizerDatabase":         CLIflags.AnonymizerDatabase,
+		"CLIflags.AnonymizerCache":            CLIflags.AnonymizerCache,
+		"CLIflags.AnonymizerAddress":          CLIflags.AnonymizerAddress,
+		"CLIflags.AnonymizerTLS":              CLIflags.AnonymizerTLS,
+		"CLIflags.AnonymizerTLSCA":            CLIflags.AnonymizerTLSCA,
+		"CLIflags.AnonymizerTLSCert":          CLIflags.AnonymizerTLSCert,
+		"CLIflags.AnonymizerTLSKey":           CLIflags.AnonymizerTLSKey,
+		"CLIflags.TimePrecision":              CLIflags.TimePrecision,
+		"CLIflags.MMRBucketSize":              CLIflags.MMRBucketSize,
+		"CLIflags.APMRoundTo":                 CLIflags.APMRoundTo,
+		"CLIflags.RemoveRegion":               CLIflags.RemoveRegion,
+		"CLIflags.KAnonymityAttributes":       CLIflags.KAnonymityAttributes,
+		"CLIflags.KAnonymityK":                CLIflags.KAnonymityK,
 		"CLIflags.FilterGameMode":             CLIflags.FilterGameMode,
+		"CLIflags.FilterExpression":           CLIflags.FilterExpression.String(),
+		"CLIflags.FilterHumansOnly":           CLIflags.FilterHumansOnly,
+		"CLIflags.FilterNoObservers":          CLIflags.FilterNoObservers,
+		"CLIflags.FilterMinDuration":          CLIflags.FilterMinDuration,
+		"CLIflags.FilterEarlyLeaveSeconds":    CLIflags.FilterEarlyLeaveSeconds,
+		"CLIflags.QuarantineDirectory":        CLIflags.QuarantineDirectory,
+		"CLIflags.QuarantineMode":             CLIflags.QuarantineMode,
 		"CLIflags.NumberOfThreads":            CLIflags.NumberOfThreads,
 		"CLIflags.LogFlags.LogLevel":          CLIflags.LogFlags.LogLevelValue,
 		"CLIflags.LogFlags.LogPath":           CLIflags.LogFlags.LogPath,
@@ -82,6 +127,14 @@ func mainReturnWithCode() int {
 		return 1
 	}
 
+	// Assigning the files to the packages in a stable order:
+	if CLIflags.Deterministic {
+		listOfInputFiles = file_utils.SortFilesByRelativePath(
+			listOfInputFiles,
+			CLIflags.InputDirectory,
+		)
+	}
+
 	lenListOfInputFiles := len(listOfInputFiles)
 	if lenListOfInputFiles < CLIflags.NumberOfPackages {
 		log.WithFields(log.Fields{
@@ -109,6 +162,35 @@ func mainReturnWithCode() int {
 		lenListOfInputFiles,
 	)
 
+	// Setting up the anonymizer shared by all of the workers:
+	anonymizer, err := dataproc.NewAnonymizer(CLIflags)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to set up the anonymizer.")
+		return 1
+	}
+	if anonymizer != nil {
+		// In order to free up resources the anonymizer is closed
+		// when all of the files have been processed:
+		defer anonymizer.Close()
+
+		// The HMAC anonymizer derives the IDs without any requests:
+		if CLIflags.AnonymizerMode != datastruct.HMACAnonymizerMode &&
+			!dataproc.ResolveInputToons(
+				anonymizer,
+				listOfInputFiles,
+				CLIflags.NumberOfThreads,
+			) {
+			// IDs requested by the concurrent workers depend on the order of processing:
+			if CLIflags.Deterministic {
+				log.Error("Failed to resolve the anonymized IDs of all of the input toons, deterministic output cannot be created.")
+				return 1
+			}
+			// The toons that were not resolved are requested again by the workers,
+			// the replays that still fail are rejected instead of stopping the processing:
+			log.Warn("Failed to resolve the anonymized IDs of all of the input toons.")
+		}
+	}
+
 	// Compression method to be used for the output packages:
 	var compressionMethod uint16 = 8
 	// Initializing the processing:
@@ -117,9 +199,45 @@ func mainReturnWithCode() int {
 		packageToZipBool,
 		compressionMethod,
 		foreignToEnglishMapping,
+		anonymizer,
 		CLIflags,
 	)
 
+	// Merging the indexes and summaries of all of the packages:
+	if packageToZipBool {
+		err = persistent_data.CreateDatasetIndexFile(
+			CLIflags.OutputDirectory,
+			len(listOfChunksFiles),
+		)
+		if err != nil {
+			log.WithField("error", err).Error("Failed to create the dataset index.")
+			return 1
+		}
+
+		if len(CLIflags.KAnonymityAttributes) > 0 {
+			err = persistent_data.CreateKAnonymityReportFile(
+				filepath.Join(CLIflags.OutputDirectory, persistent_data.DatasetIndexFilename),
+				filepath.Join(CLIflags.OutputDirectory, persistent_data.KAnonymityReportFilename),
+				CLIflags.KAnonymityAttributes,
+				CLIflags.KAnonymityK,
+			)
+			if err != nil {
+				log.WithField("error", err).Error("Failed to create the k-anonymity report.")
+				return 1
+			}
+		}
+
+		err = persistent_data.MergePackageSummaries(
+			CLIflags.OutputDirectory,
+			len(listOfChunksFiles),
+			CLIflags.SummaryBins,
+		)
+		if err != nil {
+			log.WithField("error", err).Error("Failed to create the dataset summary.")
+			return 1
+		}
+	}
+
 	// Closing the log file manually:
 	logFile.Close()
 
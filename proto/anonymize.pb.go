@@ -2,7 +2,7 @@
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.26.0
+// 	protoc-gen-go v1.36.5
 // 	protoc        v3.17.3
 // source: anonymize.proto
 
@@ -13,6 +13,7 @@ import (
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -23,20 +24,17 @@ const (
 )
 
 type SendNickname struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nickname      string                 `protobuf:"bytes,1,opt,name=nickname,proto3" json:"nickname,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	Nickname string `protobuf:"bytes,1,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SendNickname) Reset() {
 	*x = SendNickname{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_anonymize_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_anonymize_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *SendNickname) String() string {
@@ -47,7 +45,7 @@ func (*SendNickname) ProtoMessage() {}
 
 func (x *SendNickname) ProtoReflect() protoreflect.Message {
 	mi := &file_anonymize_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -70,20 +68,17 @@ func (x *SendNickname) GetNickname() string {
 }
 
 type ReceiveID struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AnonymizedID  string                 `protobuf:"bytes,1,opt,name=anonymizedID,proto3" json:"anonymizedID,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	AnonymizedID string `protobuf:"bytes,1,opt,name=anonymizedID,proto3" json:"anonymizedID,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReceiveID) Reset() {
 	*x = ReceiveID{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_anonymize_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_anonymize_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *ReceiveID) String() string {
@@ -94,7 +89,7 @@ func (*ReceiveID) ProtoMessage() {}
 
 func (x *ReceiveID) ProtoReflect() protoreflect.Message {
 	mi := &file_anonymize_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -116,48 +111,149 @@ func (x *ReceiveID) GetAnonymizedID() string {
 	return ""
 }
 
+type SendNicknames struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nicknames     []string               `protobuf:"bytes,1,rep,name=nicknames,proto3" json:"nicknames,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendNicknames) Reset() {
+	*x = SendNicknames{}
+	mi := &file_anonymize_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendNicknames) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendNicknames) ProtoMessage() {}
+
+func (x *SendNicknames) ProtoReflect() protoreflect.Message {
+	mi := &file_anonymize_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendNicknames.ProtoReflect.Descriptor instead.
+func (*SendNicknames) Descriptor() ([]byte, []int) {
+	return file_anonymize_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SendNicknames) GetNicknames() []string {
+	if x != nil {
+		return x.Nicknames
+	}
+	return nil
+}
+
+type ReceiveIDs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AnonymizedIDs []string               `protobuf:"bytes,1,rep,name=anonymizedIDs,proto3" json:"anonymizedIDs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReceiveIDs) Reset() {
+	*x = ReceiveIDs{}
+	mi := &file_anonymize_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveIDs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveIDs) ProtoMessage() {}
+
+func (x *ReceiveIDs) ProtoReflect() protoreflect.Message {
+	mi := &file_anonymize_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveIDs.ProtoReflect.Descriptor instead.
+func (*ReceiveIDs) Descriptor() ([]byte, []int) {
+	return file_anonymize_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReceiveIDs) GetAnonymizedIDs() []string {
+	if x != nil {
+		return x.AnonymizedIDs
+	}
+	return nil
+}
+
 var File_anonymize_proto protoreflect.FileDescriptor
 
-var file_anonymize_proto_rawDesc = []byte{
+var file_anonymize_proto_rawDesc = string([]byte{
 	0x0a, 0x0f, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x22, 0x2a, 0x0a, 0x0c, 0x53, 0x65, 0x6e, 0x64, 0x4e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d,
 	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
 	0x01, 0x28, 0x09, 0x52, 0x08, 0x6e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x2f, 0x0a,
 	0x09, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x49, 0x44, 0x12, 0x22, 0x0a, 0x0c, 0x61, 0x6e,
 	0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x0c, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x49, 0x44, 0x32, 0x40,
-	0x0a, 0x10, 0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x12, 0x2c, 0x0a, 0x0f, 0x67, 0x65, 0x74, 0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69,
-	0x7a, 0x65, 0x64, 0x49, 0x44, 0x12, 0x0d, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4e, 0x69, 0x63, 0x6b,
-	0x6e, 0x61, 0x6d, 0x65, 0x1a, 0x0a, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x49, 0x44,
-	0x42, 0x28, 0x5a, 0x26, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x4b,
-	0x61, 0x73, 0x7a, 0x61, 0x6e, 0x61, 0x73, 0x2f, 0x47, 0x6f, 0x53, 0x43, 0x32, 0x53, 0x63, 0x69,
-	0x65, 0x6e, 0x63, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
-}
+	0x52, 0x0c, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x49, 0x44, 0x22, 0x2d,
+	0x0a, 0x0d, 0x53, 0x65, 0x6e, 0x64, 0x4e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x12,
+	0x1c, 0x0a, 0x09, 0x6e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x09, 0x6e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x22, 0x32, 0x0a,
+	0x0a, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x49, 0x44, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x61,
+	0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x49, 0x44, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x0d, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x49, 0x44,
+	0x73, 0x32, 0x71, 0x0a, 0x10, 0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x2c, 0x0a, 0x0f, 0x67, 0x65, 0x74, 0x41, 0x6e, 0x6f, 0x6e,
+	0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x49, 0x44, 0x12, 0x0d, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4e,
+	0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x1a, 0x0a, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x65, 0x49, 0x44, 0x12, 0x2f, 0x0a, 0x10, 0x67, 0x65, 0x74, 0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d,
+	0x69, 0x7a, 0x65, 0x64, 0x49, 0x44, 0x73, 0x12, 0x0e, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4e, 0x69,
+	0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x1a, 0x0b, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x65, 0x49, 0x44, 0x73, 0x42, 0x28, 0x5a, 0x26, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x4b, 0x61, 0x73, 0x7a, 0x61, 0x6e, 0x61, 0x73, 0x2f, 0x47, 0x6f, 0x53, 0x43,
+	0x32, 0x53, 0x63, 0x69, 0x65, 0x6e, 0x63, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+})
 
 var (
 	file_anonymize_proto_rawDescOnce sync.Once
-	file_anonymize_proto_rawDescData = file_anonymize_proto_rawDesc
+	file_anonymize_proto_rawDescData []byte
 )
 
 func file_anonymize_proto_rawDescGZIP() []byte {
 	file_anonymize_proto_rawDescOnce.Do(func() {
-		file_anonymize_proto_rawDescData = protoimpl.X.CompressGZIP(file_anonymize_proto_rawDescData)
+		file_anonymize_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_anonymize_proto_rawDesc), len(file_anonymize_proto_rawDesc)))
 	})
 	return file_anonymize_proto_rawDescData
 }
 
-var file_anonymize_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
-var file_anonymize_proto_goTypes = []interface{}{
-	(*SendNickname)(nil), // 0: SendNickname
-	(*ReceiveID)(nil),    // 1: ReceiveID
+var file_anonymize_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_anonymize_proto_goTypes = []any{
+	(*SendNickname)(nil),  // 0: SendNickname
+	(*ReceiveID)(nil),     // 1: ReceiveID
+	(*SendNicknames)(nil), // 2: SendNicknames
+	(*ReceiveIDs)(nil),    // 3: ReceiveIDs
 }
 var file_anonymize_proto_depIdxs = []int32{
 	0, // 0: AnonymizeService.getAnonymizedID:input_type -> SendNickname
-	1, // 1: AnonymizeService.getAnonymizedID:output_type -> ReceiveID
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	2, // 1: AnonymizeService.getAnonymizedIDs:input_type -> SendNicknames
+	1, // 2: AnonymizeService.getAnonymizedID:output_type -> ReceiveID
+	3, // 3: AnonymizeService.getAnonymizedIDs:output_type -> ReceiveIDs
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -168,39 +264,13 @@ func file_anonymize_proto_init() {
 	if File_anonymize_proto != nil {
 		return
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_anonymize_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SendNickname); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_anonymize_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReceiveID); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_anonymize_proto_rawDesc,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_anonymize_proto_rawDesc), len(file_anonymize_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -209,7 +279,6 @@ func file_anonymize_proto_init() {
 		MessageInfos:      file_anonymize_proto_msgTypes,
 	}.Build()
 	File_anonymize_proto = out.File
-	file_anonymize_proto_rawDesc = nil
 	file_anonymize_proto_goTypes = nil
 	file_anonymize_proto_depIdxs = nil
 }
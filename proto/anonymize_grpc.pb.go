@@ -19,6 +19,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AnonymizeServiceClient interface {
 	GetAnonymizedID(ctx context.Context, in *SendNickname, opts ...grpc.CallOption) (*ReceiveID, error)
+	GetAnonymizedIDs(ctx context.Context, in *SendNicknames, opts ...grpc.CallOption) (*ReceiveIDs, error)
 }
 
 type anonymizeServiceClient struct {
@@ -38,11 +39,21 @@ func (c *anonymizeServiceClient) GetAnonymizedID(ctx context.Context, in *SendNi
 	return out, nil
 }
 
+func (c *anonymizeServiceClient) GetAnonymizedIDs(ctx context.Context, in *SendNicknames, opts ...grpc.CallOption) (*ReceiveIDs, error) {
+	out := new(ReceiveIDs)
+	err := c.cc.Invoke(ctx, "/AnonymizeService/getAnonymizedIDs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AnonymizeServiceServer is the server API for AnonymizeService service.
 // All implementations must embed UnimplementedAnonymizeServiceServer
 // for forward compatibility
 type AnonymizeServiceServer interface {
 	GetAnonymizedID(context.Context, *SendNickname) (*ReceiveID, error)
+	GetAnonymizedIDs(context.Context, *SendNicknames) (*ReceiveIDs, error)
 	mustEmbedUnimplementedAnonymizeServiceServer()
 }
 
@@ -53,6 +64,9 @@ type UnimplementedAnonymizeServiceServer struct {
 func (UnimplementedAnonymizeServiceServer) GetAnonymizedID(context.Context, *SendNickname) (*ReceiveID, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetAnonymizedID not implemented")
 }
+func (UnimplementedAnonymizeServiceServer) GetAnonymizedIDs(context.Context, *SendNicknames) (*ReceiveIDs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAnonymizedIDs not implemented")
+}
 func (UnimplementedAnonymizeServiceServer) mustEmbedUnimplementedAnonymizeServiceServer() {}
 
 // UnsafeAnonymizeServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -84,6 +98,24 @@ func _AnonymizeService_GetAnonymizedID_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AnonymizeService_GetAnonymizedIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendNicknames)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnonymizeServiceServer).GetAnonymizedIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/AnonymizeService/getAnonymizedIDs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnonymizeServiceServer).GetAnonymizedIDs(ctx, req.(*SendNicknames))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AnonymizeService_ServiceDesc is the grpc.ServiceDesc for AnonymizeService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,6 +127,10 @@ var AnonymizeService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "getAnonymizedID",
 			Handler:    _AnonymizeService_GetAnonymizedID_Handler,
 		},
+		{
+			MethodName: "getAnonymizedIDs",
+			Handler:    _AnonymizeService_GetAnonymizedIDs_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "anonymize.proto",
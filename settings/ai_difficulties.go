@@ -0,0 +1,16 @@
+package settings
+
+// AIDifficulties maps the difficulty of the lobby slots
+// of the computer players to the names shown in the lobby.
+var AIDifficulties = map[int64]string{
+	1:  "VeryEasy",
+	2:  "Easy",
+	3:  "Medium",
+	4:  "Hard",
+	5:  "Harder",
+	6:  "VeryHard",
+	7:  "Elite",
+	8:  "CheatVision",
+	9:  "CheatMoney",
+	10: "CheatInsane",
+}
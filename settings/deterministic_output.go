@@ -0,0 +1,8 @@
+package settings
+
+import "time"
+
+// DeterministicArchiveModified is the modification time that is written
+// into every zip entry when the deterministic output is requested.
+// It is the earliest date that can be represented in the MS-DOS format used by zip.
+var DeterministicArchiveModified = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
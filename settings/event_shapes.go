@@ -0,0 +1,168 @@
+package settings
+
+// Event shapes map the evtTypeName of an event to the fields that are known
+// to be present in it and their JSON Schema types. Fields that are common
+// to all of the events of a given stream are listed separately.
+// Fields differ between game versions so the shapes are not exhaustive.
+
+// CommonTrackerEventFields are present in every tracker event.
+var CommonTrackerEventFields = map[string]string{
+	"evtTypeName": "string",
+	"id":          "integer",
+	"loop":        "integer",
+}
+
+// KnownTrackerEventShapes lists the fields of the tracker events.
+var KnownTrackerEventShapes = map[string]map[string]string{
+	"PlayerStats": {
+		"playerId": "integer",
+		"stats":    "object",
+	},
+	"UnitBorn": {
+		"unitTagIndex":          "integer",
+		"unitTagRecycle":        "integer",
+		"unitTypeName":          "string",
+		"controlPlayerId":       "integer",
+		"upkeepPlayerId":        "integer",
+		"x":                     "integer",
+		"y":                     "integer",
+		"creatorUnitTagIndex":   "integer",
+		"creatorUnitTagRecycle": "integer",
+		"creatorAbilityName":    "string",
+	},
+	"UnitDied": {
+		"unitTagIndex":         "integer",
+		"unitTagRecycle":       "integer",
+		"killerPlayerId":       "integer",
+		"x":                    "integer",
+		"y":                    "integer",
+		"killerUnitTagIndex":   "integer",
+		"killerUnitTagRecycle": "integer",
+	},
+	"UnitOwnerChange": {
+		"unitTagIndex":    "integer",
+		"unitTagRecycle":  "integer",
+		"controlPlayerId": "integer",
+		"upkeepPlayerId":  "integer",
+	},
+	"UnitTypeChange": {
+		"unitTagIndex":   "integer",
+		"unitTagRecycle": "integer",
+		"unitTypeName":   "string",
+	},
+	"Upgrade": {
+		"playerId":        "integer",
+		"upgradeTypeName": "string",
+		"count":           "integer",
+	},
+	"UnitInit": {
+		"unitTagIndex":    "integer",
+		"unitTagRecycle":  "integer",
+		"unitTypeName":    "string",
+		"controlPlayerId": "integer",
+		"upkeepPlayerId":  "integer",
+		"x":               "integer",
+		"y":               "integer",
+	},
+	"UnitDone": {
+		"unitTagIndex":   "integer",
+		"unitTagRecycle": "integer",
+	},
+	"UnitPositions": {
+		"firstUnitIndex": "integer",
+		"items":          "array",
+	},
+	"PlayerSetup": {
+		"playerId": "integer",
+		"type":     "integer",
+		"userId":   "integer",
+		"slotId":   "integer",
+	},
+}
+
+// CommonGameEventFields are present in every game event.
+var CommonGameEventFields = map[string]string{
+	"evtTypeName": "string",
+	"id":          "integer",
+	"loop":        "integer",
+	"userid":      "object",
+}
+
+// KnownGameEventShapes lists the fields of the game events
+// after they were cleaned by the tool.
+var KnownGameEventShapes = map[string]map[string]string{
+	"Cmd": {
+		"cmdFlags":  "array",
+		"abil":      "object",
+		"data":      "object",
+		"sequence":  "integer",
+		"otherUnit": "integer",
+		"unitGroup": "integer",
+	},
+	"SelectionDelta": {
+		"controlGroupId": "integer",
+		"delta":          "object",
+	},
+	"ControlGroupUpdate": {
+		"controlGroupIndex":  "integer",
+		"controlGroupUpdate": "integer",
+		"mask":               "object",
+	},
+	"CameraSave": {
+		"which":  "integer",
+		"target": "object",
+	},
+	"CameraUpdate": {
+		"target":   "object",
+		"distance": "number",
+		"pitch":    "number",
+		"yaw":      "number",
+		"reason":   "integer",
+		"follow":   "boolean",
+	},
+	"CmdUpdateTargetPoint": {
+		"target": "object",
+	},
+	"CmdUpdateTargetUnit": {
+		"target": "object",
+	},
+	"PlayerLeave": {},
+	"GameUserLeave": {
+		"leaveReason": "integer",
+	},
+	"GameUserJoin": {
+		"observe":               "integer",
+		"name":                  "string",
+		"toonHandle":            "string",
+		"clanTag":               "string",
+		"clanLogo":              "object",
+		"hijack":                "boolean",
+		"hijackCloneGameUserId": "integer",
+	},
+}
+
+// CommonMessageEventFields are present in every message event.
+var CommonMessageEventFields = map[string]string{
+	"evtTypeName": "string",
+	"id":          "integer",
+	"loop":        "integer",
+	"userid":      "object",
+}
+
+// KnownMessageEventShapes lists the fields of the message events.
+var KnownMessageEventShapes = map[string]map[string]string{
+	"Chat": {
+		"recipient": "integer",
+		"string":    "string",
+	},
+	"Ping": {
+		"recipient": "integer",
+		"point":     "object",
+	},
+	"LoadingProgress": {
+		"progress": "integer",
+	},
+	"ReconnectNotify": {
+		"status": "integer",
+	},
+}
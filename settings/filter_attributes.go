@@ -0,0 +1,43 @@
+package settings
+
+// Types of the attributes that can be used in the filter expressions.
+const (
+	FilterTypeNumber  = "number"
+	FilterTypeString  = "string"
+	FilterTypeBool    = "bool"
+	FilterTypeVersion = "version"
+)
+
+// FilterAttribute describes an attribute of a replay
+// that can be used in the filter expressions.
+type FilterAttribute struct {
+	Name string
+	Type string
+	// PerPlayer attributes hold a value for every player of the replay.
+	PerPlayer   bool
+	Description string
+}
+
+// FilterAttributes are the attributes that can be used in the filter expressions.
+// They are read from the replay before the events are decoded.
+var FilterAttributes = []FilterAttribute{
+	{"duration", FilterTypeNumber, false, "length of the game in seconds"},
+	{"loops", FilterTypeNumber, false, "length of the game in game loops"},
+	{"map", FilterTypeString, false, "name of the map as saved in the replay"},
+	{"version", FilterTypeVersion, false, "version of the game, such as 5.0.11.81102"},
+	{"build", FilterTypeNumber, false, "base build of the game"},
+	{"date", FilterTypeString, false, "date of the game (UTC) formatted as YYYY-MM-DD"},
+	{"year", FilterTypeNumber, false, "year of the game (UTC)"},
+	{"players", FilterTypeNumber, false, "number of the players, excluding the observers"},
+	{"matchup", FilterTypeString, false, "races of the teams, such as P-vs-T or PT-vs-ZZ"},
+	{"speed", FilterTypeString, false, "game speed, such as Faster"},
+	{"blizzard_map", FilterTypeBool, false, "true if the map was published by Blizzard"},
+	{"computers", FilterTypeNumber, false, "number of the computer players"},
+	{"observers", FilterTypeNumber, false, "number of the observers and the referees"},
+	{"race", FilterTypeString, true, "assigned race of the player: Prot, Terr or Zerg"},
+	{"mmr", FilterTypeNumber, true, "MMR of the player, 0 if it is not available"},
+	{"apm", FilterTypeNumber, true, "APM of the player"},
+	{"result", FilterTypeString, true, "result of the player: Win, Loss, Tie or Undecided"},
+	{"region", FilterTypeString, true, "region of the player, such as EU"},
+	{"slot", FilterTypeString, true, "role of the player: human or computer"},
+}
@@ -0,0 +1,17 @@
+package settings
+
+// WalkoverMaxSeconds is the effective length of the game in seconds
+// below which a game decided by a team leaving is considered a walkover.
+const WalkoverMaxSeconds = 30.0
+
+// NormalEndToleranceSeconds is the time in seconds before the end of the replay
+// within which a team leaving the game ends it normally. Surrender of the losing side
+// issues the leave events as well, the replay ends right after them.
+const NormalEndToleranceSeconds = 5.0
+
+// LeaveEventNames are the names of the game events issued when a user leaves the game,
+// PlayerLeave was replaced by GameUserLeave in the base build 24764.
+var LeaveEventNames = []string{
+	"PlayerLeave",
+	"GameUserLeave",
+}
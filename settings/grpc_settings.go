@@ -1,3 +1,25 @@
 package settings
 
+import "time"
+
+// GrpcServerAddress is the default address of the anonymization server.
 var GrpcServerAddress = "localhost:9999"
+
+// AnonymizeBatchSize is the maximum number of toons
+// that are sent to the anonymization server in a single request.
+const AnonymizeBatchSize = 1000
+
+// AnonymizeRequestTimeout limits the time of a single request to the anonymization server.
+const AnonymizeRequestTimeout = 10 * time.Second
+
+// AnonymizeMaxAttempts is the maximum number of attempts of a request
+// to the anonymization server that failed with a transient error,
+// the replay is rejected if all of the attempts fail.
+const AnonymizeMaxAttempts = 4
+
+// AnonymizeRetryInitialBackoff and AnonymizeRetryMaxBackoff bound the time
+// between the attempts, which doubles after every attempt.
+const (
+	AnonymizeRetryInitialBackoff = 500 * time.Millisecond
+	AnonymizeRetryMaxBackoff     = 5 * time.Second
+)
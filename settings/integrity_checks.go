@@ -0,0 +1,14 @@
+package settings
+
+// PlayerStatsIntervalLoops is the number of game loops between the consecutive
+// PlayerStats tracker events of a player (160 loops is ~7 seconds).
+// The deep integrity checks reject the replays with longer gaps between the events.
+const PlayerStatsIntervalLoops = 160
+
+// EventStreams are the names of the event streams that are accepted
+// by the -reject_event_errors flag.
+var EventStreams = []string{
+	"game",
+	"message",
+	"tracker",
+}
@@ -0,0 +1,25 @@
+package settings
+
+// OutputNameFields are the fields of a replay that can be used
+// in the output path template and as the partitions of the output:
+//   - year, month, day: date of the game (UTC), zero padded,
+//   - map: English name of the map,
+//   - matchup: races of the teams, such as P-vs-T or PT-vs-ZZ,
+//   - fingerprint: game fingerprint that is shared by the replays of the same game,
+//     it is hidden if any of the privacy options is set,
+//   - gameVersion: version of the game,
+//   - replay: name of the replay file without its extension.
+var OutputNameFields = []string{
+	"year",
+	"month",
+	"day",
+	"map",
+	"matchup",
+	"fingerprint",
+	"gameVersion",
+	"replay",
+}
+
+// HivePartitionDefault is the partition value that is used when
+// the replay does not hold the information required by the partition.
+const HivePartitionDefault = "__HIVE_DEFAULT_PARTITION__"
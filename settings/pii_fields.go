@@ -0,0 +1,17 @@
+package settings
+
+// IdentifyingEventFields lists the fields of the game events that identify the users,
+// keyed by the name of the event. Their values are replaced during the player anonymization
+// even if they do not belong to any of the players or the observers of the game.
+var IdentifyingEventFields = map[string][]string{
+	"GameUserJoin":  {"name", "toonHandle", "clanTag", "clanLogo"},
+	"BankSignature": {"toonHandle"},
+}
+
+// FreeTextEventFields lists the fields of the events holding the text written by the users,
+// keyed by the name of the event. The nicknames and the clan tags are searched only within
+// these fields, the toons are searched within all of the text fields of the events.
+var FreeTextEventFields = map[string][]string{
+	"Chat":               {ChatTextField},
+	"TriggerChatMessage": {"chatMessage"},
+}
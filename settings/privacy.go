@@ -0,0 +1,17 @@
+package settings
+
+// QuasiIdentifierAttributes are the attributes of the games that are saved
+// in the package indexes and can be chosen for the k-anonymity report.
+// The attributes of the players (mmr, apm, league, region and realm)
+// combine the values of all of the players of the game.
+var QuasiIdentifierAttributes = []string{
+	"date",
+	"map",
+	"matchup",
+	"gameVersion",
+	"mmr",
+	"apm",
+	"league",
+	"region",
+	"realm",
+}
@@ -0,0 +1,6 @@
+package settings
+
+// OutputSchemaVersion is the version of the JSON layout of the replay outputs
+// and package summaries. It has to be bumped every time the layout
+// of any of the output structures changes.
+const OutputSchemaVersion = "2.8.0"
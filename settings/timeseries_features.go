@@ -0,0 +1,26 @@
+package settings
+
+// TimeSeriesPlayerStatsFeatures are the fields of the stats of PlayerStats
+// tracker events that are exported as the per player time series.
+// The order of the features is the order of the last dimension of the exported array.
+var TimeSeriesPlayerStatsFeatures = []string{
+	"scoreValueMineralsCurrent",
+	"scoreValueVespeneCurrent",
+	"scoreValueMineralsCollectionRate",
+	"scoreValueVespeneCollectionRate",
+	"scoreValueWorkersActiveCount",
+	"scoreValueFoodUsed",
+	"scoreValueFoodMade",
+	"scoreValueMineralsUsedActiveForces",
+	"scoreValueVespeneUsedActiveForces",
+	"scoreValueMineralsUsedCurrentArmy",
+	"scoreValueVespeneUsedCurrentArmy",
+	"scoreValueMineralsUsedCurrentEconomy",
+	"scoreValueVespeneUsedCurrentEconomy",
+	"scoreValueMineralsUsedCurrentTechnology",
+	"scoreValueVespeneUsedCurrentTechnology",
+	"scoreValueMineralsKilledArmy",
+	"scoreValueVespeneKilledArmy",
+	"scoreValueMineralsLostArmy",
+	"scoreValueVespeneLostArmy",
+}
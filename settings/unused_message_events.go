@@ -11,3 +11,7 @@ var UnusedMessageEvents = []string{
 var AnonymizeMessageEvents = []string{
 	"Chat",
 }
+
+// ChatTextField is the field of the AnonymizeMessageEvents holding the text of the message,
+// it is the only field replaced when the chat text is redacted.
+const ChatTextField = "string"
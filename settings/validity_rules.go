@@ -0,0 +1,31 @@
+package settings
+
+// ValidityGameModes are the names of the game modes that the validity rules
+// can be defined for, they correspond to the game modes of -game_mode_filter.
+var ValidityGameModes = []string{
+	"ranked_1v1",
+	"ranked_2v2",
+	"ranked_3v3",
+	"ranked_4v4",
+	"custom_1v1",
+	"custom_2v2",
+	"custom_3v3",
+	"custom_4v4",
+}
+
+// ValidityRequiredFields are the fields that can be required by the validity rules,
+// a field is missing if it is empty or 0 (for any of the players in case of the per player fields):
+//   - mmr, apm, race, result, region: per player information from the metadata and details,
+//   - map: name of the map,
+//   - gameVersion: version of the game,
+//   - mapAuthor: name of the author of the map.
+var ValidityRequiredFields = []string{
+	"mmr",
+	"apm",
+	"race",
+	"result",
+	"region",
+	"map",
+	"gameVersion",
+	"mapAuthor",
+}
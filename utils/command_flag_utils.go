@@ -0,0 +1,496 @@
+package utils
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	log "github.com/sirupsen/logrus"
+)
+
+// GenerateSchemaFlags holds the information that was supplied by user
+// in CLI for the generate-schema command.
+type GenerateSchemaFlags struct {
+	OutputDirectory string
+	LogFlags        LogFlags
+}
+
+// ParseGenerateSchemaFlags contains logic which is responsible
+// for user input of the generate-schema command.
+func ParseGenerateSchemaFlags(args []string) (GenerateSchemaFlags, bool) {
+
+	flagSet := flag.NewFlagSet("generate-schema", flag.ContinueOnError)
+	outputDirectory := flagSet.String(
+		"output",
+		"./schemas",
+		"Output directory where the JSON Schema documents will be saved.",
+	)
+	logFlags := registerLogFlags(flagSet)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return GenerateSchemaFlags{}, false
+	}
+
+	absolutePathOutputDirectory, err := filepath.Abs(*outputDirectory)
+	if err != nil {
+		log.WithField("outputDirectory", *outputDirectory).
+			Error("Failed to get the absolute path to the output directory!")
+		return GenerateSchemaFlags{}, false
+	}
+
+	flags := GenerateSchemaFlags{
+		OutputDirectory: absolutePathOutputDirectory,
+		LogFlags:        logFlags(),
+	}
+
+	return flags, true
+}
+
+// registerLogFlags defines the logging flags on a command flag set.
+// The returned function reads the parsed values into LogFlags.
+func registerLogFlags(flagSet *flag.FlagSet) func() LogFlags {
+
+	logLevelFlag := flagSet.Int(
+		"log_level",
+		4,
+		`Specifies a log level from 1-7:
+		Panic - 1, Fatal - 2,
+		Error - 3, Warn - 4,
+		Info - 5, Debug - 6,
+		Trace - 7`,
+	)
+	logDirectoryFlag := flagSet.String(
+		"log_dir",
+		"./logs/",
+		"Specifies directory which will hold the logging information.",
+	)
+
+	return func() LogFlags {
+		return LogFlags{
+			LogLevelValue: datastruct.LogLevelEnum(*logLevelFlag),
+			LogPath:       *logDirectoryFlag,
+		}
+	}
+}
+
+// packageSummaryPattern and processingInfoPattern match the names
+// of the package summaries and the processing info files.
+const (
+	packageSummaryPattern = "package_summary_*.json"
+	processingInfoPattern = "processed_failed_*.log"
+)
+
+// MergeSummariesFlags holds the information that was supplied by user
+// in CLI for the merge-summaries command.
+type MergeSummariesFlags struct {
+	SummaryFiles []string
+	OutputFile   string
+	SummaryBins  persistent_data.SummaryBins
+	LogFlags     LogFlags
+}
+
+// ParseMergeSummariesFlags contains logic which is responsible
+// for user input of the merge-summaries command.
+// Positional arguments are summary files or directories
+// that are searched recursively for the package summaries.
+func ParseMergeSummariesFlags(args []string) (MergeSummariesFlags, bool) {
+
+	flagSet := flag.NewFlagSet("merge-summaries", flag.ContinueOnError)
+	outputFile := flagSet.String(
+		"output",
+		"./"+persistent_data.DatasetSummaryFilename,
+		"Output file where the merged dataset summary will be saved.",
+	)
+	summaryBinsFlags := registerSummaryBinFlags(flagSet)
+	logFlags := registerLogFlags(flagSet)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return MergeSummariesFlags{}, false
+	}
+
+	if flagSet.NArg() == 0 {
+		log.Error("No summary files or directories were provided!")
+		return MergeSummariesFlags{}, false
+	}
+
+	summaryFiles, ok := findFiles(flagSet.Args(), packageSummaryPattern)
+	if !ok {
+		return MergeSummariesFlags{}, false
+	}
+
+	summaryBins, ok := summaryBinsFlags()
+	if !ok {
+		return MergeSummariesFlags{}, false
+	}
+
+	absolutePathOutputFile, err := filepath.Abs(*outputFile)
+	if err != nil {
+		log.WithField("outputFile", *outputFile).
+			Error("Failed to get the absolute path to the output file!")
+		return MergeSummariesFlags{}, false
+	}
+
+	flags := MergeSummariesFlags{
+		SummaryFiles: summaryFiles,
+		OutputFile:   absolutePathOutputFile,
+		SummaryBins:  summaryBins,
+		LogFlags:     logFlags(),
+	}
+
+	return flags, true
+}
+
+// findFiles returns the supplied files and the files matching the pattern
+// found recursively within the supplied directories, sorted within each directory.
+func findFiles(inputs []string, pattern string) ([]string, bool) {
+
+	files := []string{}
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"input": input,
+			}).Error("Failed to access the input!")
+			return nil, false
+		}
+		if !info.IsDir() {
+			files = append(files, input)
+			continue
+		}
+
+		var directoryFiles []string
+		err = filepath.WalkDir(input, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			matched, _ := filepath.Match(pattern, entry.Name())
+			if !entry.IsDir() && matched {
+				directoryFiles = append(directoryFiles, path)
+			}
+			return nil
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"input":   input,
+				"pattern": pattern,
+			}).Error("Failed to search the directory for the matching files!")
+			return nil, false
+		}
+		sort.Strings(directoryFiles)
+		files = append(files, directoryFiles...)
+	}
+
+	return files, true
+}
+
+// ReportFlags holds the information that was supplied by user
+// in CLI for the report command.
+type ReportFlags struct {
+	SummaryFiles        []string
+	ProcessingInfoFiles []string
+	OutputDirectory     string
+	Title               string
+	MaxRows             int
+	SummaryBins         persistent_data.SummaryBins
+	LogFlags            LogFlags
+}
+
+// ParseReportFlags contains logic which is responsible
+// for user input of the report command.
+// Positional arguments are summary files or directories
+// that are searched recursively for the package summaries.
+func ParseReportFlags(args []string) (ReportFlags, bool) {
+
+	flagSet := flag.NewFlagSet("report", flag.ContinueOnError)
+	outputDirectory := flagSet.String(
+		"output",
+		"./report",
+		"Output directory where the HTML and Markdown reports will be saved.",
+	)
+	processingLogs := flagSet.String(
+		"processing_logs",
+		"",
+		`Optional processed_failed_N.log file or a directory searched recursively
+		for these files. Failure reasons are reported only if this is set.`,
+	)
+	title := flagSet.String(
+		"title",
+		"SC2InfoExtractorGo Dataset Report",
+		"Title of the report.",
+	)
+	maxRows := flagSet.Int(
+		"max_rows",
+		25,
+		`Maximum number of the most frequent values shown in every section,
+		the remaining values are reported as "Other". Set to 0 to show all values.`,
+	)
+	summaryBinsFlags := registerSummaryBinFlags(flagSet)
+	logFlags := registerLogFlags(flagSet)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return ReportFlags{}, false
+	}
+
+	if flagSet.NArg() == 0 {
+		log.Error("No summary files or directories were provided!")
+		return ReportFlags{}, false
+	}
+	if *maxRows < 0 {
+		log.WithField("max_rows", *maxRows).Error("max_rows cannot be negative!")
+		return ReportFlags{}, false
+	}
+
+	summaryFiles, ok := findFiles(flagSet.Args(), packageSummaryPattern)
+	if !ok {
+		return ReportFlags{}, false
+	}
+
+	processingInfoFiles := []string{}
+	if *processingLogs != "" {
+		processingInfoFiles, ok = findFiles(
+			[]string{*processingLogs},
+			processingInfoPattern,
+		)
+		if !ok {
+			return ReportFlags{}, false
+		}
+	}
+
+	summaryBins, ok := summaryBinsFlags()
+	if !ok {
+		return ReportFlags{}, false
+	}
+
+	absolutePathOutputDirectory, err := filepath.Abs(*outputDirectory)
+	if err != nil {
+		log.WithField("outputDirectory", *outputDirectory).
+			Error("Failed to get the absolute path to the output directory!")
+		return ReportFlags{}, false
+	}
+
+	flags := ReportFlags{
+		SummaryFiles:        summaryFiles,
+		ProcessingInfoFiles: processingInfoFiles,
+		OutputDirectory:     absolutePathOutputDirectory,
+		Title:               *title,
+		MaxRows:             *maxRows,
+		SummaryBins:         summaryBins,
+		LogFlags:            logFlags(),
+	}
+
+	return flags, true
+}
+
+// SummarizeFlags holds the information that was supplied by user
+// in CLI for the summarize command.
+type SummarizeFlags struct {
+	Inputs          []string
+	OutputDirectory string
+	NumberOfThreads int
+	SummaryBins     persistent_data.SummaryBins
+	LogFlags        LogFlags
+}
+
+// ParseSummarizeFlags contains logic which is responsible
+// for user input of the summarize command.
+// Positional arguments are zip packages or directories holding the outputs.
+func ParseSummarizeFlags(args []string) (SummarizeFlags, bool) {
+
+	flagSet := flag.NewFlagSet("summarize", flag.ContinueOnError)
+	outputDirectory := flagSet.String(
+		"output",
+		"./summaries",
+		"Output directory where the package and dataset summaries will be saved.",
+	)
+	numberOfThreads := flagSet.Int(
+		"max_procs",
+		runtime.NumCPU(),
+		"Specifies the number of packages that are summarized in parallel (default runtime.NumCPU()).",
+	)
+	summaryBinsFlags := registerSummaryBinFlags(flagSet)
+	logFlags := registerLogFlags(flagSet)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return SummarizeFlags{}, false
+	}
+
+	if flagSet.NArg() == 0 {
+		log.Error("No packages or output directories were provided!")
+		return SummarizeFlags{}, false
+	}
+	if *numberOfThreads < 1 {
+		log.WithField("max_procs", *numberOfThreads).Error("max_procs has to be positive!")
+		return SummarizeFlags{}, false
+	}
+
+	summaryBins, ok := summaryBinsFlags()
+	if !ok {
+		return SummarizeFlags{}, false
+	}
+
+	absolutePathOutputDirectory, err := filepath.Abs(*outputDirectory)
+	if err != nil {
+		log.WithField("outputDirectory", *outputDirectory).
+			Error("Failed to get the absolute path to the output directory!")
+		return SummarizeFlags{}, false
+	}
+
+	flags := SummarizeFlags{
+		Inputs:          flagSet.Args(),
+		OutputDirectory: absolutePathOutputDirectory,
+		NumberOfThreads: *numberOfThreads,
+		SummaryBins:     summaryBins,
+		LogFlags:        logFlags(),
+	}
+
+	return flags, true
+}
+
+// ServeAnonymizerFlags holds the information that was supplied by user
+// in CLI for the serve-anonymizer command.
+type ServeAnonymizerFlags struct {
+	Address            string
+	AnonymizerDatabase string
+	TLSCert            string
+	TLSKey             string
+	TLSClientCA        string
+	LogFlags           LogFlags
+}
+
+// ParseServeAnonymizerFlags contains logic which is responsible
+// for user input of the serve-anonymizer command.
+func ParseServeAnonymizerFlags(args []string) (ServeAnonymizerFlags, bool) {
+
+	flagSet := flag.NewFlagSet("serve-anonymizer", flag.ContinueOnError)
+	address := flagSet.String(
+		"address",
+		settings.GrpcServerAddress,
+		"Address on which the anonymization server listens.",
+	)
+	anonymizerDatabase := flagSet.String(
+		"anonymizer_db",
+		"./anonymizer/anonymized_toons.jsonl",
+		`Database file holding the assigned IDs,
+		it is created if it does not exist.`,
+	)
+	tlsCert := flagSet.String(
+		"tls_cert",
+		"",
+		"PEM file with the server certificate, the server accepts only TLS connections if set.",
+	)
+	tlsKey := flagSet.String(
+		"tls_key",
+		"",
+		"PEM file with the private key of -tls_cert.",
+	)
+	tlsClientCA := flagSet.String(
+		"tls_client_ca",
+		"",
+		`PEM file with the certificate authority of the client certificates,
+		the clients are required to present a certificate (mTLS) if set.`,
+	)
+	logFlags := registerLogFlags(flagSet)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return ServeAnonymizerFlags{}, false
+	}
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		log.Error("Both -tls_cert and -tls_key are required for TLS!")
+		return ServeAnonymizerFlags{}, false
+	}
+	if *tlsClientCA != "" && *tlsCert == "" {
+		log.Error("-tls_client_ca requires -tls_cert and -tls_key!")
+		return ServeAnonymizerFlags{}, false
+	}
+
+	flags := ServeAnonymizerFlags{
+		Address:            *address,
+		AnonymizerDatabase: *anonymizerDatabase,
+		TLSCert:            *tlsCert,
+		TLSKey:             *tlsKey,
+		TLSClientCA:        *tlsClientCA,
+		LogFlags:           logFlags(),
+	}
+
+	return flags, true
+}
+
+// KAnonymityFlags holds the information that was supplied by user
+// in CLI for the k-anonymity command.
+type KAnonymityFlags struct {
+	DatasetIndexFile string
+	Attributes       []string
+	K                int
+	OutputFile       string
+	LogFlags         LogFlags
+}
+
+// ParseKAnonymityFlags contains logic which is responsible
+// for user input of the k-anonymity command.
+// The positional argument is the dataset index file.
+func ParseKAnonymityFlags(args []string) (KAnonymityFlags, bool) {
+
+	flagSet := flag.NewFlagSet("k-anonymity", flag.ContinueOnError)
+	attributesFlag := flagSet.String(
+		"attributes",
+		"date,map,matchup,mmr,region",
+		`Comma separated list of the attributes of the report.
+		Available attributes: `+strings.Join(settings.QuasiIdentifierAttributes, ", "),
+	)
+	k := flagSet.Int(
+		"k",
+		5,
+		"Games whose combination of the attributes is shared by fewer games than this are flagged as rare.",
+	)
+	outputFile := flagSet.String(
+		"output",
+		"./"+persistent_data.KAnonymityReportFilename,
+		"Output file where the k-anonymity report will be saved.",
+	)
+	logFlags := registerLogFlags(flagSet)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return KAnonymityFlags{}, false
+	}
+
+	if flagSet.NArg() != 1 {
+		log.Error("Exactly one dataset index file has to be provided!")
+		return KAnonymityFlags{}, false
+	}
+
+	attributes, err := ParseKAnonymityAttributes(*attributesFlag)
+	if err != nil || len(attributes) == 0 {
+		log.WithField("error", err).Error("Failed to parse -attributes!")
+		return KAnonymityFlags{}, false
+	}
+	if *k < 1 {
+		log.WithField("k", *k).Error("-k has to be at least 1!")
+		return KAnonymityFlags{}, false
+	}
+
+	flags := KAnonymityFlags{
+		DatasetIndexFile: flagSet.Arg(0),
+		Attributes:       attributes,
+		K:                *k,
+		OutputFile:       *outputFile,
+		LogFlags:         logFlags(),
+	}
+
+	return flags, true
+}
@@ -0,0 +1,43 @@
+package file_utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FileSHA256 returns the hex encoded SHA-256 checksum of the contents of a file.
+func FileSHA256(filePath string) (string, error) {
+
+	log.Debug("Entered FileSHA256()")
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"filePath": filePath,
+			"error":    err}).Error("Failed to open the file to calculate its checksum.")
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"filePath": filePath,
+			"error":    err}).Error("Failed to read the file to calculate its checksum.")
+		return "", err
+	}
+
+	log.Debug("Finished FileSHA256()")
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// BytesSHA256 returns the hex encoded SHA-256 checksum of the bytes.
+func BytesSHA256(contents []byte) string {
+	checksum := sha256.Sum256(contents)
+	return hex.EncodeToString(checksum[:])
+}
@@ -142,29 +142,91 @@ func UnmarshalJsonFile(
 	return nil
 }
 
-// SaveReplayJSONFileToDrive is a helper function that takes
-// the json string of a StarCraft II replay and writes it to drive.
-func SaveReplayJSONFileToDrive(
-	replayString string,
-	replayFile string,
+// SaveBytesToDrive writes the bytes into a file under the path relative
+// to the output directory, creating the missing directories on the way.
+// The relative path uses forward slashes.
+func SaveBytesToDrive(
+	contents []byte,
+	relativePath string,
 	absolutePathOutputDirectory string) bool {
 
-	_, replayFileNameWithExt := filepath.Split(replayFile)
-
-	replayFileName := strings.TrimSuffix(
-		replayFileNameWithExt,
-		filepath.Ext(replayFileNameWithExt),
-	)
-
-	jsonAbsPath := filepath.Join(absolutePathOutputDirectory, replayFileName+".json")
-	jsonBytes := []byte(replayString)
+	fileAbsPath := filepath.Join(absolutePathOutputDirectory, filepath.FromSlash(relativePath))
+	err := os.MkdirAll(filepath.Dir(fileAbsPath), 0777)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"relativePath": relativePath,
+			"error":        err,
+		}).Error("Failed to create the output directory!")
+		return false
+	}
 
-	err := os.WriteFile(jsonAbsPath, jsonBytes, 0777)
+	err = os.WriteFile(fileAbsPath, contents, 0777)
 	if err != nil {
-		log.WithField("replayFile", replayFile).
-			Error("Failed to write .json to drive!")
+		log.WithFields(log.Fields{
+			"relativePath": relativePath,
+			"error":        err,
+		}).Error("Failed to write the output to drive!")
 		return false
 	}
 
 	return true
 }
+
+// LinkOrCopyFile places the source file under the destination path,
+// creating the missing directories on the way. If link is true the file
+// is hard linked and copied only if the link cannot be created.
+// An existing destination file is replaced.
+func LinkOrCopyFile(
+	sourcePath string,
+	destinationPath string,
+	link bool) error {
+
+	err := os.MkdirAll(filepath.Dir(destinationPath), 0777)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(destinationPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if link {
+		err = os.Link(sourcePath, destinationPath)
+		if err == nil {
+			return nil
+		}
+		log.WithFields(log.Fields{
+			"sourcePath": sourcePath,
+			"error":      err,
+		}).Debug("Failed to create a hard link, copying the file.")
+	}
+
+	sourceFile, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	destinationFile, err := os.Create(destinationPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(destinationFile, sourceFile)
+	if err != nil {
+		destinationFile.Close()
+		return err
+	}
+
+	return destinationFile.Close()
+}
+
+// ReplayFileNameWithoutExtension returns the base name
+// of the replay file without its extension.
+func ReplayFileNameWithoutExtension(replayFile string) string {
+	_, replayFileNameWithExt := filepath.Split(replayFile)
+	replayFileName := strings.TrimSuffix(
+		replayFileNameWithExt,
+		filepath.Ext(replayFileNameWithExt),
+	)
+	return replayFileName
+}
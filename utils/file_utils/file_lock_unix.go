@@ -0,0 +1,15 @@
+//go:build !windows
+
+package file_utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// LockFile takes an exclusive lock of the open file without waiting for it,
+// it fails if the file is locked by another process.
+// The lock is released when the file is closed.
+func LockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
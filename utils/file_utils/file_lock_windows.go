@@ -0,0 +1,24 @@
+//go:build windows
+
+package file_utils
+
+import (
+	"math"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// LockFile takes an exclusive lock of the open file without waiting for it,
+// it fails if the file is locked by another process.
+// The lock is released when the file is closed.
+func LockFile(file *os.File) error {
+	return windows.LockFileEx(
+		windows.Handle(file.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0,
+		math.MaxUint32,
+		math.MaxUint32,
+		&windows.Overlapped{},
+	)
+}
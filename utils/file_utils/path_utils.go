@@ -3,6 +3,7 @@ package file_utils
 import (
 	"io/fs"
 	"path/filepath"
+	"sort"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -121,3 +122,32 @@ func getAllFiles(inputPath string) ([]string, error) {
 	}
 	return listOfFiles, nil
 }
+
+// SortFilesByRelativePath returns a copy of the list of files ordered by their
+// paths relative to the base directory. Relative paths use forward slashes
+// so that the order is the same regardless of the operating system.
+func SortFilesByRelativePath(
+	listOfFiles []string,
+	baseDirectory string,
+) []string {
+
+	log.Debug("Entered SortFilesByRelativePath()")
+
+	sortKeys := make(map[string]string, len(listOfFiles))
+	for _, file := range listOfFiles {
+		relativePath, err := filepath.Rel(baseDirectory, file)
+		if err != nil {
+			relativePath = file
+		}
+		sortKeys[file] = filepath.ToSlash(relativePath)
+	}
+
+	sortedFiles := make([]string, len(listOfFiles))
+	copy(sortedFiles, listOfFiles)
+	sort.SliceStable(sortedFiles, func(i, j int) bool {
+		return sortKeys[sortedFiles[i]] < sortKeys[sortedFiles[j]]
+	})
+
+	log.Debug("Finished SortFilesByRelativePath()")
+	return sortedFiles
+}
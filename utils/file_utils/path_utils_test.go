@@ -1,6 +1,8 @@
 package file_utils
 
 import (
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
@@ -28,3 +30,28 @@ func TestGetChunksOfFiles(t *testing.T) {
 		t.Fatalf("Test Failed! lenghts of slices mismatch.")
 	}
 }
+
+// TestSortFilesByRelativePath verifies that the files are ordered
+// by their paths relative to the base directory.
+func TestSortFilesByRelativePath(t *testing.T) {
+
+	baseDirectory := filepath.Join("input", "replays")
+	files := []string{
+		filepath.Join(baseDirectory, "b", "1.SC2Replay"),
+		filepath.Join(baseDirectory, "a.SC2Replay"),
+		filepath.Join(baseDirectory, "a", "2.SC2Replay"),
+	}
+	expectedFiles := []string{
+		filepath.Join(baseDirectory, "a.SC2Replay"),
+		filepath.Join(baseDirectory, "a", "2.SC2Replay"),
+		filepath.Join(baseDirectory, "b", "1.SC2Replay"),
+	}
+
+	sortedFiles := SortFilesByRelativePath(files, baseDirectory)
+	if !reflect.DeepEqual(sortedFiles, expectedFiles) {
+		t.Fatalf("Test Failed! Expected %v, got %v", expectedFiles, sortedFiles)
+	}
+	if files[0] != filepath.Join(baseDirectory, "b", "1.SC2Replay") {
+		t.Fatalf("Test Failed! The input slice was modified.")
+	}
+}
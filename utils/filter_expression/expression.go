@@ -0,0 +1,82 @@
+package filter_expression
+
+import (
+	"fmt"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+)
+
+// Expression is a parsed and type checked filter expression.
+type Expression struct {
+	source string
+	root   node
+}
+
+// String returns the source of the expression,
+// or an empty string if there is no expression.
+func (expression *Expression) String() string {
+	if expression == nil {
+		return ""
+	}
+	return expression.source
+}
+
+// Evaluate checks if the replay described by the attribute values matches the expression.
+// All of the attributes that were available when the expression was parsed
+// have to be provided.
+func (expression *Expression) Evaluate(attributes map[string]Value) bool {
+	return expression.root.evaluate(attributes).boolean
+}
+
+// Parse parses the filter expression and verifies that it uses
+// only the known attributes with the operators that fit their types.
+// The returned error is an *ExpressionError pointing at the place
+// where the expression broke.
+func Parse(
+	source string,
+	attributes []settings.FilterAttribute,
+) (*Expression, error) {
+
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	attributeTypes := make(map[string]valueType, len(attributes))
+	for _, attribute := range attributes {
+		attributeTypes[attribute.Name] = valueType{
+			base:      attribute.Type,
+			perPlayer: attribute.PerPlayer,
+		}
+	}
+
+	parser := parser{
+		source:         source,
+		tokens:         tokens,
+		attributeTypes: attributeTypes,
+	}
+	root, err := parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if parser.peek().kind != tokenEOF {
+		return nil, parser.errorAt(parser.peek(), "unexpected %s", parser.peek().describe())
+	}
+	if root.resultType() != (valueType{base: typeBool}) {
+		return nil, newExpressionError(source, 0,
+			"expression has to result in true or false, got %s", describeType(root.resultType()))
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// describeType returns the type as it is shown in the error messages.
+func describeType(t valueType) string {
+	switch {
+	case t.perPlayer:
+		return fmt.Sprintf("%s of every player", t.base)
+	case t.list:
+		return fmt.Sprintf("list of %s", t.base)
+	}
+	return t.base
+}
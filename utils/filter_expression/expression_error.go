@@ -0,0 +1,42 @@
+package filter_expression
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExpressionError describes where and why the filter expression is invalid.
+type ExpressionError struct {
+	Expression string
+	// Position is the byte offset of the place where the expression broke.
+	Position int
+	Message  string
+}
+
+// newExpressionError creates an ExpressionError with a formatted message.
+func newExpressionError(
+	expression string,
+	position int,
+	format string,
+	args ...any,
+) *ExpressionError {
+	return &ExpressionError{
+		Expression: expression,
+		Position:   position,
+		Message:    fmt.Sprintf(format, args...),
+	}
+}
+
+// Error returns the message along with the expression
+// and a marker pointing at the place where the expression broke.
+func (err *ExpressionError) Error() string {
+	column := utf8.RuneCountInString(err.Expression[:err.Position])
+	return fmt.Sprintf(
+		"invalid filter expression at column %d: %s\n  %s\n  %s^",
+		column+1,
+		err.Message,
+		err.Expression,
+		strings.Repeat(" ", column),
+	)
+}
@@ -0,0 +1,105 @@
+package filter_expression
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+)
+
+// testAttributes describes a 1v1 replay that is used to evaluate the expressions.
+func testAttributes() map[string]Value {
+	return map[string]Value{
+		"duration":     NumberValue(600),
+		"map":          StringValue("Oceanborn LE"),
+		"version":      VersionValue("5.0.11.81102"),
+		"players":      NumberValue(2),
+		"blizzard_map": BoolValue(true),
+		"matchup":      StringValue("P-vs-Z"),
+		"race":         PlayerValues([]Value{StringValue("Prot"), StringValue("Zerg")}),
+		"mmr":          PlayerValues([]Value{NumberValue(4200), NumberValue(3900)}),
+	}
+}
+
+// TestEvaluate verifies the results of the valid expressions.
+func TestEvaluate(t *testing.T) {
+
+	testCases := []struct {
+		expression string
+		expected   bool
+	}{
+		{`duration > 180s && map in ["Oceanborn LE"] && version >= "5.0.11"`, true},
+		{`duration > 10m`, false},
+		{`duration >= 10m && duration <= 600`, true},
+		{`version == "5.0.11"`, true},
+		{`version < "5.0.12" && version > "4.12"`, true},
+		{`version == "5.0.11.81103"`, false},
+		{`mmr >= 4000`, false},
+		{`!(mmr < 4000)`, true},
+		{`mmr >= 3900 && 5000 > mmr`, true},
+		{`"Zerg" in race && !("Terr" in race)`, true},
+		{`race in ["Prot", "Zerg"]`, true},
+		{`race == "Prot"`, false},
+		{`blizzard_map && players == 2 || false`, true},
+		{`(matchup == "P-vs-T" || matchup == "P-vs-Z") && map != "Other"`, true},
+	}
+
+	for _, testCase := range testCases {
+		expression, err := Parse(testCase.expression, settings.FilterAttributes)
+		if err != nil {
+			t.Errorf("Test Failed! Could not parse %q: %v", testCase.expression, err)
+			continue
+		}
+		result := expression.Evaluate(testAttributes())
+		if result != testCase.expected {
+			t.Errorf("Test Failed! %q evaluated to %v, expected %v",
+				testCase.expression, result, testCase.expected)
+		}
+	}
+
+	emptyPlayers := testAttributes()
+	emptyPlayers["mmr"] = PlayerValues(nil)
+	expression, _ := Parse("mmr >= 0", settings.FilterAttributes)
+	if expression.Evaluate(emptyPlayers) {
+		t.Errorf("Test Failed! Comparisons of replays without players should be false.")
+	}
+}
+
+// TestParseErrors verifies that the errors point at the place
+// where the expression broke.
+func TestParseErrors(t *testing.T) {
+
+	testCases := []struct {
+		expression      string
+		expectedColumn  int
+		expectedMessage string
+	}{
+		{`mmr >= 4000 && && duration > 3m`, 16, "expected a value"},
+		{`mapp == "Oceanborn LE"`, 1, "unknown attribute"},
+		{`map == 5`, 5, "cannot compare string with number"},
+		{`duration > 180x`, 12, "invalid number"},
+		{`map == "Oceanborn LE`, 8, "string is not terminated"},
+		{`mmr >= 4000 && (duration > 3m`, 30, "expected \")\""},
+		{`duration`, 1, "has to result in true or false"},
+		{`mmr > apm`, 5, "two attributes of the players"},
+		{`map in ["a", 1]`, 14, "same type"},
+		{`duration > 3m # comment`, 15, "unexpected character"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := Parse(testCase.expression, settings.FilterAttributes)
+		var expressionError *ExpressionError
+		if !errors.As(err, &expressionError) {
+			t.Errorf("Test Failed! Expected an ExpressionError for %q, got %v", testCase.expression, err)
+			continue
+		}
+		column := expressionError.Position + 1
+		if column != testCase.expectedColumn ||
+			!strings.Contains(expressionError.Message, testCase.expectedMessage) {
+			t.Errorf("Test Failed! %q: expected %q at column %d, got %q at column %d",
+				testCase.expression, testCase.expectedMessage, testCase.expectedColumn,
+				expressionError.Message, column)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package filter_expression
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tokenKind is the kind of a lexical token of the filter expressions.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLeftParenthesis
+	tokenRightParenthesis
+	tokenLeftBracket
+	tokenRightBracket
+	tokenComma
+)
+
+// token is a single lexical token along with its position in the expression.
+type token struct {
+	kind     tokenKind
+	text     string
+	number   float64
+	position int
+}
+
+// describe returns the token as it is shown in the error messages.
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of the expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+var (
+	identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+	// Numbers can be followed by the units of a duration, such as 180s or 3m30s:
+	numberRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([a-zµ]+([0-9]+(\.[0-9]+)?[a-zµ]+)*)?`)
+	// Two character operators have to be matched before the single character ones:
+	operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!"}
+)
+
+// tokenize splits the expression into tokens.
+func tokenize(expression string) ([]token, error) {
+
+	var tokens []token
+	position := 0
+	for position < len(expression) {
+		rest := expression[position:]
+		switch {
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' || rest[0] == '\r':
+			position++
+			continue
+		case rest[0] == '(':
+			tokens = append(tokens, token{kind: tokenLeftParenthesis, text: "(", position: position})
+			position++
+			continue
+		case rest[0] == ')':
+			tokens = append(tokens, token{kind: tokenRightParenthesis, text: ")", position: position})
+			position++
+			continue
+		case rest[0] == '[':
+			tokens = append(tokens, token{kind: tokenLeftBracket, text: "[", position: position})
+			position++
+			continue
+		case rest[0] == ']':
+			tokens = append(tokens, token{kind: tokenRightBracket, text: "]", position: position})
+			position++
+			continue
+		case rest[0] == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", position: position})
+			position++
+			continue
+		case rest[0] == '"':
+			stringToken, length, err := tokenizeString(expression, position)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, stringToken)
+			position += length
+			continue
+		}
+
+		if text := identifierRegexp.FindString(rest); text != "" {
+			tokens = append(tokens, token{kind: tokenIdentifier, text: text, position: position})
+			position += len(text)
+			continue
+		}
+
+		if text := numberRegexp.FindString(rest); text != "" {
+			number, err := parseNumber(text)
+			if err != nil {
+				return nil, newExpressionError(expression, position, "invalid number %q", text)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, number: number, position: position})
+			position += len(text)
+			continue
+		}
+
+		operatorFound := false
+		for _, operator := range operators {
+			if strings.HasPrefix(rest, operator) {
+				tokens = append(tokens, token{kind: tokenOperator, text: operator, position: position})
+				position += len(operator)
+				operatorFound = true
+				break
+			}
+		}
+		if !operatorFound {
+			return nil, newExpressionError(expression, position, "unexpected character %q", rest[:1])
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, position: len(expression)})
+	return tokens, nil
+}
+
+// tokenizeString reads a double quoted string starting at the position.
+// Backslash escapes the quote and the backslash itself.
+func tokenizeString(expression string, position int) (token, int, error) {
+
+	var text strings.Builder
+	for i := position + 1; i < len(expression); i++ {
+		switch expression[i] {
+		case '\\':
+			if i+1 < len(expression) && (expression[i+1] == '"' || expression[i+1] == '\\') {
+				text.WriteByte(expression[i+1])
+				i++
+				continue
+			}
+			return token{}, 0, newExpressionError(expression, i, "invalid escape sequence in a string")
+		case '"':
+			return token{
+				kind:     tokenString,
+				text:     text.String(),
+				position: position,
+			}, i + 1 - position, nil
+		default:
+			text.WriteByte(expression[i])
+		}
+	}
+
+	return token{}, 0, newExpressionError(expression, position, "string is not terminated")
+}
+
+// parseNumber parses a number or a duration, which is converted to seconds.
+func parseNumber(text string) (float64, error) {
+
+	if strings.IndexFunc(text, isUnitLetter) == -1 {
+		return strconv.ParseFloat(text, 64)
+	}
+
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, err
+	}
+	return duration.Seconds(), nil
+}
+
+// isUnitLetter checks if the rune is a part of the unit of a duration.
+func isUnitLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || r == 'µ'
+}
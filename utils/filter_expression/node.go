@@ -0,0 +1,217 @@
+package filter_expression
+
+import "strings"
+
+// node is a single node of the parsed expression.
+type node interface {
+	resultType() valueType
+	evaluate(attributes map[string]Value) Value
+}
+
+// literalNode holds a number, string, boolean or a list literal.
+type literalNode struct {
+	value Value
+}
+
+func (n *literalNode) resultType() valueType {
+	return n.value.valueType
+}
+
+func (n *literalNode) evaluate(attributes map[string]Value) Value {
+	return n.value
+}
+
+// attributeNode reads the value of an attribute of the replay.
+type attributeNode struct {
+	name      string
+	valueType valueType
+}
+
+func (n *attributeNode) resultType() valueType {
+	return n.valueType
+}
+
+func (n *attributeNode) evaluate(attributes map[string]Value) Value {
+	value, ok := attributes[n.name]
+	if !ok {
+		// Missing attributes behave as empty values of their type:
+		return Value{valueType: n.valueType}
+	}
+	if n.valueType.base == typeVersion && value.version == nil {
+		value.version = parseVersion(value.text)
+	}
+	value.valueType = n.valueType
+	return value
+}
+
+// logicalNode joins two boolean operands with && or ||.
+type logicalNode struct {
+	operator string
+	left     node
+	right    node
+}
+
+func (n *logicalNode) resultType() valueType {
+	return valueType{base: typeBool}
+}
+
+func (n *logicalNode) evaluate(attributes map[string]Value) Value {
+	left := n.left.evaluate(attributes).boolean
+	if n.operator == "&&" {
+		return BoolValue(left && n.right.evaluate(attributes).boolean)
+	}
+	return BoolValue(left || n.right.evaluate(attributes).boolean)
+}
+
+// notNode negates a boolean operand.
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) resultType() valueType {
+	return valueType{base: typeBool}
+}
+
+func (n *notNode) evaluate(attributes map[string]Value) Value {
+	return BoolValue(!n.operand.evaluate(attributes).boolean)
+}
+
+// comparisonNode compares two values. Comparisons with an attribute
+// of the players hold only if they hold for every player. Looking for a value
+// in an attribute of the players holds if any of the players has the value.
+// Both are false for replays without any players.
+type comparisonNode struct {
+	operator string
+	left     node
+	right    node
+}
+
+func (n *comparisonNode) resultType() valueType {
+	return valueType{base: typeBool}
+}
+
+func (n *comparisonNode) evaluate(attributes map[string]Value) Value {
+
+	left := n.left.evaluate(attributes)
+	right := n.right.evaluate(attributes)
+
+	if n.operator == "in" && right.valueType.perPlayer {
+		return BoolValue(anyItem(right.items, func(item Value) bool {
+			return compareValues("==", left, item)
+		}))
+	}
+
+	compare := func(leftItem Value, rightItem Value) bool {
+		if n.operator == "in" {
+			return anyItem(rightItem.items, func(item Value) bool {
+				return compareValues("==", leftItem, item)
+			})
+		}
+		return compareValues(n.operator, leftItem, rightItem)
+	}
+
+	switch {
+	case left.valueType.perPlayer:
+		return BoolValue(allItems(left.items, func(item Value) bool {
+			return compare(item, right)
+		}))
+	case right.valueType.perPlayer:
+		return BoolValue(allItems(right.items, func(item Value) bool {
+			return compare(left, item)
+		}))
+	}
+
+	return BoolValue(compare(left, right))
+}
+
+// anyItem checks if the condition holds for any of the items.
+func anyItem(items []Value, condition func(Value) bool) bool {
+	for _, item := range items {
+		if condition(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// allItems checks if the condition holds for every item,
+// it is false if there are no items.
+func allItems(items []Value, condition func(Value) bool) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if !condition(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareValues compares two single values with the operator.
+func compareValues(operator string, left Value, right Value) bool {
+
+	var comparison int
+	switch {
+	case left.valueType.base == typeVersion || right.valueType.base == typeVersion:
+		comparison = compareVersionValues(left, right)
+	case left.valueType.base == typeNumber:
+		comparison = compareOrdered(left.number, right.number)
+	case left.valueType.base == typeBool:
+		if left.boolean == right.boolean {
+			comparison = 0
+		} else {
+			comparison = 1
+		}
+	default:
+		comparison = strings.Compare(left.text, right.text)
+	}
+
+	switch operator {
+	case "==":
+		return comparison == 0
+	case "!=":
+		return comparison != 0
+	case "<":
+		return comparison < 0
+	case "<=":
+		return comparison <= 0
+	case ">":
+		return comparison > 0
+	case ">=":
+		return comparison >= 0
+	}
+	return false
+}
+
+// compareVersionValues compares a version with another version or a string.
+// The precision of the comparison is set by the string literal if there is one.
+func compareVersionValues(left Value, right Value) int {
+
+	leftVersion, rightVersion := left.version, right.version
+	precision := len(leftVersion)
+	if len(rightVersion) > precision {
+		precision = len(rightVersion)
+	}
+	if left.valueType.base == typeString {
+		leftVersion = parseVersion(left.text)
+		precision = len(leftVersion)
+	}
+	if right.valueType.base == typeString {
+		rightVersion = parseVersion(right.text)
+		precision = len(rightVersion)
+	}
+
+	return compareVersions(leftVersion, rightVersion, precision)
+}
+
+// compareOrdered returns -1, 0 or 1 depending on the order of the numbers.
+func compareOrdered(number float64, other float64) int {
+	switch {
+	case number < other:
+		return -1
+	case number > other:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,265 @@
+package filter_expression
+
+// parser is a recursive descent parser of the filter expressions:
+//
+//	expression := and ("||" and)*
+//	and        := unary ("&&" unary)*
+//	unary      := "!" unary | comparison
+//	comparison := primary (("==" | "!=" | "<" | "<=" | ">" | ">=" | "in") primary)?
+//	primary    := number | string | "true" | "false" | attribute | list | "(" expression ")"
+//	list       := "[" (primary ("," primary)*)? "]"
+type parser struct {
+	source         string
+	tokens         []token
+	current        int
+	attributeTypes map[string]valueType
+}
+
+// peek returns the current token without consuming it.
+func (p *parser) peek() token {
+	return p.tokens[p.current]
+}
+
+// next consumes and returns the current token.
+func (p *parser) next() token {
+	currentToken := p.tokens[p.current]
+	if currentToken.kind != tokenEOF {
+		p.current++
+	}
+	return currentToken
+}
+
+// errorAt creates an error pointing at the token.
+func (p *parser) errorAt(t token, format string, args ...any) *ExpressionError {
+	return newExpressionError(p.source, t.position, format, args...)
+}
+
+// isOperator checks if the current token is the operator.
+func (p *parser) isOperator(operator string) bool {
+	currentToken := p.peek()
+	return currentToken.kind == tokenOperator && currentToken.text == operator
+}
+
+func (p *parser) parseExpression() (node, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogical("&&", p.parseUnary)
+}
+
+// parseLogical parses a chain of the operands joined by the logical operator.
+func (p *parser) parseLogical(
+	operator string,
+	parseOperand func() (node, error),
+) (node, error) {
+
+	left, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(operator) {
+		operatorToken := p.next()
+		right, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		for _, operand := range []node{left, right} {
+			if operand.resultType() != (valueType{base: typeBool}) {
+				return nil, p.errorAt(operatorToken,
+					"%s requires true or false on both sides, got %s",
+					operator, describeType(operand.resultType()))
+			}
+		}
+		left = &logicalNode{operator: operator, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+
+	if !p.isOperator("!") {
+		return p.parseComparison()
+	}
+
+	operatorToken := p.next()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if operand.resultType() != (valueType{base: typeBool}) {
+		return nil, p.errorAt(operatorToken,
+			"! requires true or false, got %s", describeType(operand.resultType()))
+	}
+
+	return &notNode{operand: operand}, nil
+}
+
+// comparisonOperators are the operators comparing two values.
+var comparisonOperators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+func (p *parser) parseComparison() (node, error) {
+
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	operatorToken := p.peek()
+	isComparison := operatorToken.kind == tokenOperator && comparisonOperators[operatorToken.text]
+	isIn := operatorToken.kind == tokenIdentifier && operatorToken.text == "in"
+	if !isComparison && !isIn {
+		return left, nil
+	}
+	p.next()
+
+	rightToken := p.peek()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &comparisonNode{
+		operator: operatorToken.text,
+		left:     left,
+		right:    right,
+	}
+	err = p.checkComparison(comparison, operatorToken, rightToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return comparison, nil
+}
+
+// checkComparison verifies that the types of the operands fit the operator.
+func (p *parser) checkComparison(
+	comparison *comparisonNode,
+	operatorToken token,
+	rightToken token,
+) error {
+
+	leftType := comparison.left.resultType()
+	rightType := comparison.right.resultType()
+
+	if leftType.perPlayer && rightType.perPlayer {
+		return p.errorAt(operatorToken, "cannot compare two attributes of the players")
+	}
+	if leftType.list {
+		return p.errorAt(operatorToken, "list can only be used on the right side of in")
+	}
+
+	if comparison.operator == "in" {
+		if !rightType.list && !rightType.perPlayer {
+			return p.errorAt(rightToken,
+				"in requires a list or an attribute of the players, got %s", describeType(rightType))
+		}
+		if rightType.perPlayer && leftType.perPlayer {
+			return p.errorAt(rightToken, "cannot compare two attributes of the players")
+		}
+		if !compatibleBases(leftType.base, rightType.base) {
+			return p.errorAt(rightToken,
+				"cannot look for %s in %s", describeType(leftType), describeType(rightType))
+		}
+		return nil
+	}
+
+	if rightType.list {
+		return p.errorAt(rightToken, "list can only be used with in")
+	}
+	if !compatibleBases(leftType.base, rightType.base) {
+		return p.errorAt(operatorToken,
+			"cannot compare %s with %s", describeType(leftType), describeType(rightType))
+	}
+	ordered := comparison.operator != "==" && comparison.operator != "!="
+	if ordered && leftType.base == typeBool {
+		return p.errorAt(operatorToken, "%s cannot be used with true or false", comparison.operator)
+	}
+
+	return nil
+}
+
+// compatibleBases checks if the values of the base types can be compared.
+// Strings are compared with versions as version literals.
+// Empty lists are compatible with anything.
+func compatibleBases(base string, other string) bool {
+	if base == "" || other == "" || base == other {
+		return true
+	}
+	return (base == typeVersion && other == typeString) ||
+		(base == typeString && other == typeVersion)
+}
+
+func (p *parser) parsePrimary() (node, error) {
+
+	currentToken := p.next()
+	switch currentToken.kind {
+	case tokenNumber:
+		return &literalNode{value: NumberValue(currentToken.number)}, nil
+	case tokenString:
+		return &literalNode{value: StringValue(currentToken.text)}, nil
+	case tokenIdentifier:
+		switch currentToken.text {
+		case "true":
+			return &literalNode{value: BoolValue(true)}, nil
+		case "false":
+			return &literalNode{value: BoolValue(false)}, nil
+		case "in":
+			return nil, p.errorAt(currentToken, "expected a value, found %s", currentToken.describe())
+		}
+		attributeType, ok := p.attributeTypes[currentToken.text]
+		if !ok {
+			return nil, p.errorAt(currentToken, "unknown attribute %s", currentToken.describe())
+		}
+		return &attributeNode{name: currentToken.text, valueType: attributeType}, nil
+	case tokenLeftParenthesis:
+		inner, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenRightParenthesis {
+			return nil, p.errorAt(p.peek(), "expected \")\", found %s", p.peek().describe())
+		}
+		p.next()
+		return inner, nil
+	case tokenLeftBracket:
+		return p.parseList()
+	}
+
+	return nil, p.errorAt(currentToken, "expected a value, found %s", currentToken.describe())
+}
+
+// parseList parses the items of a list literal after its opening bracket.
+func (p *parser) parseList() (node, error) {
+
+	list := &literalNode{value: Value{valueType: valueType{list: true}}}
+	for p.peek().kind != tokenRightBracket {
+		if len(list.value.items) > 0 {
+			if p.peek().kind != tokenComma {
+				return nil, p.errorAt(p.peek(), "expected \",\" or \"]\", found %s", p.peek().describe())
+			}
+			p.next()
+		}
+
+		itemToken := p.peek()
+		item, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		literal, ok := item.(*literalNode)
+		if !ok || literal.value.valueType.list {
+			return nil, p.errorAt(itemToken, "lists can only hold numbers, strings, true or false")
+		}
+		if len(list.value.items) > 0 && literal.value.valueType.base != list.value.valueType.base {
+			return nil, p.errorAt(itemToken, "all of the items of a list have to be of the same type")
+		}
+		list.value.valueType.base = literal.value.valueType.base
+		list.value.items = append(list.value.items, literal.value)
+	}
+	p.next()
+
+	return list, nil
+}
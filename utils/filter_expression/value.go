@@ -0,0 +1,107 @@
+package filter_expression
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Value is a value of an attribute, a literal or a result of an expression.
+type Value struct {
+	valueType valueType
+	number    float64
+	text      string
+	boolean   bool
+	version   []int64
+	// items hold the values of a list literal or of a per player attribute:
+	items []Value
+}
+
+// valueType is the type of a value, lists and per player values
+// are described by the type of their items.
+type valueType struct {
+	base      string
+	list      bool
+	perPlayer bool
+}
+
+// Base types of the values:
+const (
+	typeNumber  = "number"
+	typeString  = "string"
+	typeBool    = "bool"
+	typeVersion = "version"
+)
+
+// NumberValue returns a numeric Value.
+func NumberValue(number float64) Value {
+	return Value{valueType: valueType{base: typeNumber}, number: number}
+}
+
+// StringValue returns a string Value.
+func StringValue(text string) Value {
+	return Value{valueType: valueType{base: typeString}, text: text}
+}
+
+// BoolValue returns a boolean Value.
+func BoolValue(boolean bool) Value {
+	return Value{valueType: valueType{base: typeBool}, boolean: boolean}
+}
+
+// VersionValue returns a Value of a dotted version, such as 5.0.11.81102.
+func VersionValue(version string) Value {
+	return Value{
+		valueType: valueType{base: typeVersion},
+		text:      version,
+		version:   parseVersion(version),
+	}
+}
+
+// PlayerValues returns a Value holding the values of every player.
+func PlayerValues(values []Value) Value {
+	playerValues := Value{items: values}
+	if len(values) > 0 {
+		playerValues.valueType = values[0].valueType
+	}
+	playerValues.valueType.perPlayer = true
+	return playerValues
+}
+
+// parseVersion splits the version into its numeric components,
+// components that are not numbers are treated as 0.
+func parseVersion(version string) []int64 {
+
+	var components []int64
+	for _, component := range strings.Split(version, ".") {
+		number, err := strconv.ParseInt(strings.TrimSpace(component), 10, 64)
+		if err != nil {
+			number = 0
+		}
+		components = append(components, number)
+	}
+
+	return components
+}
+
+// compareVersions compares the first precision components of the versions,
+// missing components are treated as 0. Comparing with a version literal
+// uses the precision of the literal, so "5.0.11" matches any 5.0.11 build.
+func compareVersions(version []int64, other []int64, precision int) int {
+
+	for i := 0; i < precision; i++ {
+		var component, otherComponent int64
+		if i < len(version) {
+			component = version[i]
+		}
+		if i < len(other) {
+			otherComponent = other[i]
+		}
+		if component != otherComponent {
+			if component < otherComponent {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
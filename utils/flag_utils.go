@@ -2,11 +2,18 @@ package utils
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct/persistent_data"
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+	"github.com/Kaszanas/SC2InfoExtractorGo/utils/filter_expression"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -25,13 +32,47 @@ type CLIFlags struct {
 	DependencyDirectory        string
 	NumberOfThreads            int
 	NumberOfPackages           int
+	OutputLayout               datastruct.OutputLayoutEnum
+	OutputTemplate             OutputTemplate
+	PartitionBy                []string
+	MetadataOnly               bool
+	Deterministic              bool
+	ExportTimeSeries           bool
+	TimeSeriesIntervalLoops    int
+	SummaryBins                persistent_data.SummaryBins
 	PerformIntegrityCheck      bool
+	IntegrityLevel             datastruct.IntegrityLevelEnum
+	EventErrorsPolicy          datastruct.EventErrorsPolicy
 	PerformValidityCheck       bool
+	ValidityRules              datastruct.ValidityRules
 	PerformCleanup             bool
 	PerformPlayerAnonymization bool
 	PerformChatAnonymization   bool
+	ChatAnonymizationMode      datastruct.ChatAnonymizationModeEnum
+	AnonymizerMode             datastruct.AnonymizerModeEnum
+	AnonymizerKeyFile          string
+	AnonymizerDatabase         string
+	AnonymizerCache            string
+	AnonymizerAddress          string
+	AnonymizerTLS              bool
+	AnonymizerTLSCA            string
+	AnonymizerTLSCert          string
+	AnonymizerTLSKey           string
+	TimePrecision              datastruct.TimePrecisionEnum
+	MMRBucketSize              int
+	APMRoundTo                 int
+	RemoveRegion               bool
+	KAnonymityAttributes       []string
+	KAnonymityK                int
 	PerformFiltering           bool
 	FilterGameMode             int
+	FilterExpression           *filter_expression.Expression
+	FilterHumansOnly           bool
+	FilterNoObservers          bool
+	FilterMinDuration          int
+	FilterEarlyLeaveSeconds    int
+	QuarantineDirectory        string
+	QuarantineMode             datastruct.QuarantineModeEnum
 	LogFlags                   LogFlags
 	CPUProfilingPath           string
 }
@@ -76,6 +117,58 @@ func ParseFlags() (CLIFlags, bool) {
 		than the number of processed files. If set to 0, will ommit the
 		zip packaging and output .json directly to drive.`,
 	)
+	outputLayoutFlag := flag.String(
+		"output_layout",
+		"single",
+		`Specifies the layout of the output files for every replay:
+		single - the whole replay is saved as one .json file,
+		split - every replay becomes a directory holding meta.json,
+		tracker_events.json, game_events.json and message_events.json.`,
+	)
+	outputTemplateFlag := flag.String(
+		"output_template",
+		"",
+		`Specifies the path of the output of every replay relative to the output
+		directory or the root of the package, for example:
+		{year}/{month}/{matchup}/{map}_{fingerprint}.json
+		Available fields: year, month, day, map, matchup, fingerprint,
+		gameVersion, replay. If empty the outputs are named after the replay files.`,
+	)
+	partitionByFlag := flag.String(
+		"partition_by",
+		"",
+		`Comma separated list of the fields that the outputs are partitioned by,
+		creating Hive style directories such as year=2021/matchup=P-vs-T.
+		Accepts the same fields as output_template.`,
+	)
+	metadataOnlyFlag := flag.Bool(
+		"metadata_only",
+		false,
+		`Flag specifying if the tool is supposed to write only the replay metadata
+		(header, details, metadata, init data and ToonPlayerDescMap)
+		and skip writing the event streams altogether.`,
+	)
+	deterministicFlag := flag.Bool(
+		"deterministic",
+		false,
+		`Flag specifying if the output packages are supposed to be reproducible.
+		Input files are assigned to the packages in the order of their paths,
+		zip entries are sorted and use a fixed modification time.`,
+	)
+	exportTimeSeriesFlag := flag.Bool(
+		"export_timeseries",
+		false,
+		`Flag specifying if the PlayerStats tracker events are supposed to be
+		exported as per player time series in the NumPy format. Every replay gets
+		timeseries.npz holding the arrays and timeseries.json describing them.`,
+	)
+	timeSeriesIntervalFlag := flag.Int(
+		"timeseries_interval",
+		160,
+		`Specifies the number of game loops between the consecutive
+		steps of the exported time series (160 loops is ~7 seconds).`,
+	)
+	summaryBinsFlags := registerSummaryBinFlags(flag.CommandLine)
 
 	// Boolean Flags:
 	help := flag.Bool(
@@ -88,12 +181,36 @@ func ParseFlags() (CLIFlags, bool) {
 		`Flag specifying if the software is supposed to check the hardcoded
 		integrity checks for the provided replays`,
 	)
+	integrityLevelFlag := flag.String(
+		"integrity_level",
+		"basic",
+		`Specifies the level of the integrity checks:
+		basic - compares the redundant fields of the header, details and metadata,
+		deep - additionally verifies that the loops of the events do not decrease
+		and fit within the length of the game, that every player has PlayerStats
+		events at the expected cadence and that the unit tags are not reused
+		before the units die.`,
+	)
+	rejectEventErrorsFlag := flag.String(
+		"reject_event_errors",
+		"",
+		`Comma separated list of the event streams (game, message, tracker)
+		whose decoding errors reject the replay during the integrity checks.
+		Errors of the other streams are accepted and marked in the output.`,
+	)
 	performValidityCheckFlag := flag.Bool(
 		"perform_validity_checks",
 		false,
-		`Flag, specifying if the tool is supposed to use hardcoded validity checks
+		`Flag, specifying if the tool is supposed to use the validity rules
 		and verify if the replay file variables are within 'common sense' ranges.`,
 	)
+	validityRulesFlag := flag.String(
+		"validity_rules",
+		"",
+		`Path to a JSON file holding the validity rules of every game mode,
+		used if perform_validity_checks is set. If this is empty the default rules
+		validate the MMR, APM and the map of the ranked 1v1 replays.`,
+	)
 	performCleanupFlag := flag.Bool(
 		"perform_cleanup",
 		false,
@@ -113,6 +230,13 @@ func ParseFlags() (CLIFlags, bool) {
 		false,
 		"Flag, specifying if the chat anonymization should be performed.",
 	)
+	chatAnonymizationModeFlag := flag.String(
+		"chat_anonymization",
+		"drop",
+		`Specifies how the chat is anonymized with -perform_chat_anonymization:
+		drop - removes the chat messages,
+		redact_text - keeps the chat messages with their timing and recipients, replacing the text.`,
+	)
 
 	// TODO: Write the docs for other game modes:
 	performFilteringFlag := flag.Bool(
@@ -128,6 +252,153 @@ func ParseFlags() (CLIFlags, bool) {
 		in a format of a binary flag: AllGameModes: 0b11111111 (default 0b11111111)`,
 	)
 
+	filterExpressionFlag := flag.String(
+		"filter",
+		"",
+		`Expression selecting the replays that are processed, for example:
+		mmr >= 4000 && duration > 180s && map in ["Oceanborn LE"] && version >= "5.0.11"
+		Available attributes are listed in the README.
+		The expression is evaluated before the events of the replay are decoded.
+		If this is empty no replays are rejected by the expression.`,
+	)
+
+	humansOnlyFlag := flag.Bool(
+		"humans_only",
+		false,
+		`Flag specifying if the replays with computer players in the lobby
+		are supposed to be rejected.`,
+	)
+	noObserversFlag := flag.Bool(
+		"no_observers",
+		false,
+		`Flag specifying if the replays with observers or referees in the lobby
+		are supposed to be rejected.`,
+	)
+
+	minDurationFlag := flag.Int(
+		"min_duration",
+		0,
+		`Specifies the minimum effective length of the game in seconds,
+		the game is decided when all of the players of a team leave it.
+		Shorter games are rejected. If set to 0, the length is not verified.`,
+	)
+	earlyLeaveSecondsFlag := flag.Int(
+		"early_leave_seconds",
+		0,
+		`Specifies the number of seconds from the start of the game
+		before which no player is allowed to leave the game.
+		If set to 0, the leaves are not verified.`,
+	)
+
+	anonymizerModeFlag := flag.String(
+		"anonymizer",
+		"grpc",
+		`Specifies how the anonymized IDs of the players are derived:
+		grpc - requests the IDs from the anonymization server,
+		hmac - derives the IDs from a keyed HMAC of the toon (requires -anonymizer_key_file),
+		local_db - assigns incrementing IDs stored in -anonymizer_db.`,
+	)
+	anonymizerKeyFileFlag := flag.String(
+		"anonymizer_key_file",
+		"",
+		`File holding the secret key of the hmac anonymizer,
+		the same key always yields the same anonymized IDs.`,
+	)
+	anonymizerDatabaseFlag := flag.String(
+		"anonymizer_db",
+		"./anonymizer/anonymized_toons.jsonl",
+		`Database file of the local_db anonymizer holding the assigned IDs,
+		it is created if it does not exist.`,
+	)
+
+	anonymizerCacheFlag := flag.String(
+		"anonymizer_cache",
+		"",
+		`File caching the IDs received from the anonymization server between the runs,
+		it is created if it does not exist. If this is empty the IDs are cached only in memory.`,
+	)
+	anonymizerAddressFlag := flag.String(
+		"anonymizer_address",
+		settings.GrpcServerAddress,
+		"Address of the anonymization server used by the grpc anonymizer.",
+	)
+	anonymizerTLSFlag := flag.Bool(
+		"anonymizer_tls",
+		false,
+		`Flag specifying if the connection to the anonymization server uses TLS,
+		enabled implicitly by -anonymizer_tls_ca, -anonymizer_tls_cert and -anonymizer_tls_key.`,
+	)
+	anonymizerTLSCAFlag := flag.String(
+		"anonymizer_tls_ca",
+		"",
+		`PEM file with the certificate authority verifying the anonymization server,
+		the system roots are used if this is empty.`,
+	)
+	anonymizerTLSCertFlag := flag.String(
+		"anonymizer_tls_cert",
+		"",
+		"PEM file with the client certificate presented to the anonymization server (mTLS).",
+	)
+	anonymizerTLSKeyFlag := flag.String(
+		"anonymizer_tls_key",
+		"",
+		"PEM file with the private key of -anonymizer_tls_cert.",
+	)
+
+	timePrecisionFlag := flag.String(
+		"time_precision",
+		"full",
+		`Specifies the precision of the time of the game in the outputs:
+		full - keeps the time recorded in the replay,
+		day - truncates the time to the start of the day (UTC),
+		week - truncates the time to Monday of the week (UTC).`,
+	)
+	mmrBucketFlag := flag.Int(
+		"mmr_bucket",
+		0,
+		"Floors MMR of the players to a multiple of this number, 0 keeps the exact MMR.",
+	)
+	apmRoundFlag := flag.Int(
+		"apm_round",
+		0,
+		"Rounds APM of the players to the nearest multiple of this number, 0 keeps the exact APM.",
+	)
+	removeRegionFlag := flag.Bool(
+		"remove_region",
+		false,
+		`Flag specifying if the region and the realm of the players are removed from the outputs,
+		requires -perform_player_anonymization as the toons contain the region.`,
+	)
+	kAnonymityAttributesFlag := flag.String(
+		"k_anonymity_attributes",
+		"",
+		`Comma separated list of the attributes of the k-anonymity report
+		created next to the dataset index, if this is empty the report is not created.
+		Available attributes: `+strings.Join(settings.QuasiIdentifierAttributes, ", "),
+	)
+	kAnonymityKFlag := flag.Int(
+		"k_anonymity_k",
+		5,
+		`Games whose combination of the k-anonymity attributes
+		is shared by fewer games than this are flagged as rare.`,
+	)
+
+	quarantineDirectoryFlag := flag.String(
+		"quarantine_dir",
+		"",
+		`Directory where every rejected or failed replay is placed
+		in the subdirectories named by the stage and the reason of the rejection,
+		next to a sidecar JSON describing the rejection.
+		If this is empty the rejected replays are not quarantined.`,
+	)
+	quarantineModeFlag := flag.String(
+		"quarantine_mode",
+		"link",
+		`Specifies how the rejected replays are placed in the quarantine directory:
+		link - hard links the replays, copies them if the link cannot be created,
+		copy - copies the replays.`,
+	)
+
 	// processWithMultiprocessingFlag := flag.Bool("with_multiprocessing", false, "Specifies if the processing is supposed to be perform with maximum amount of available cores. If set to false, the program will use one core.")
 	numberOfThreadsUsedFlag := flag.Int(
 		"max_procs",
@@ -185,6 +456,153 @@ func ParseFlags() (CLIFlags, bool) {
 		return CLIFlags{}, false
 	}
 
+	outputLayout, ok := datastruct.OutputLayoutFromString(*outputLayoutFlag)
+	if !ok {
+		log.WithField("outputLayout", *outputLayoutFlag).
+			Error("Unknown output layout!")
+		return CLIFlags{}, false
+	}
+
+	outputTemplate, err := ParseOutputTemplate(*outputTemplateFlag)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"outputTemplate": *outputTemplateFlag,
+			"error":          err,
+		}).Error("Invalid output template!")
+		return CLIFlags{}, false
+	}
+
+	partitionBy, err := ParsePartitionFields(*partitionByFlag)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"partitionBy": *partitionByFlag,
+			"error":       err,
+		}).Error("Invalid partition fields!")
+		return CLIFlags{}, false
+	}
+
+	summaryBins, ok := summaryBinsFlags()
+	if !ok {
+		return CLIFlags{}, false
+	}
+
+	absolutePathQuarantineDirectory := ""
+	if *quarantineDirectoryFlag != "" {
+		absolutePathQuarantineDirectory, err = filepath.Abs(*quarantineDirectoryFlag)
+		if err != nil {
+			log.WithField("quarantineDirectory", *quarantineDirectoryFlag).
+				Error("Failed to get the absolute path to the quarantine directory!")
+			return CLIFlags{}, false
+		}
+	}
+
+	anonymizerMode, ok := datastruct.AnonymizerModeFromString(*anonymizerModeFlag)
+	if !ok {
+		log.WithField("anonymizer", *anonymizerModeFlag).
+			Error("Unknown anonymizer!")
+		return CLIFlags{}, false
+	}
+
+	chatAnonymizationMode, ok := datastruct.ChatAnonymizationModeFromString(
+		*chatAnonymizationModeFlag,
+	)
+	if !ok {
+		log.WithField("chatAnonymization", *chatAnonymizationModeFlag).
+			Error("Unknown chat anonymization mode!")
+		return CLIFlags{}, false
+	}
+
+	timePrecision, ok := datastruct.TimePrecisionFromString(*timePrecisionFlag)
+	if !ok {
+		log.WithField("timePrecision", *timePrecisionFlag).
+			Error("Unknown time precision!")
+		return CLIFlags{}, false
+	}
+	if *mmrBucketFlag < 0 || *apmRoundFlag < 0 {
+		log.WithFields(log.Fields{
+			"mmrBucket": *mmrBucketFlag,
+			"apmRound":  *apmRoundFlag,
+		}).Error("MMR bucket and APM rounding cannot be negative!")
+		return CLIFlags{}, false
+	}
+	// The toons contain the region, it would be left in the keys of ToonPlayerDescMap:
+	if *removeRegionFlag && !*performPlayerAnonymizationFlag {
+		log.Error("-remove_region requires -perform_player_anonymization!")
+		return CLIFlags{}, false
+	}
+
+	kAnonymityAttributes, err := ParseKAnonymityAttributes(*kAnonymityAttributesFlag)
+	if err != nil {
+		log.WithField("error", err).Error("Failed to parse -k_anonymity_attributes!")
+		return CLIFlags{}, false
+	}
+	if *kAnonymityKFlag < 1 {
+		log.WithField("kAnonymityK", *kAnonymityKFlag).
+			Error("-k_anonymity_k has to be at least 1!")
+		return CLIFlags{}, false
+	}
+
+	if (*anonymizerTLSCertFlag == "") != (*anonymizerTLSKeyFlag == "") {
+		log.Error("Both -anonymizer_tls_cert and -anonymizer_tls_key are required for mTLS!")
+		return CLIFlags{}, false
+	}
+	anonymizerTLS := *anonymizerTLSFlag ||
+		*anonymizerTLSCAFlag != "" ||
+		*anonymizerTLSCertFlag != ""
+
+	quarantineMode, ok := datastruct.QuarantineModeFromString(*quarantineModeFlag)
+	if !ok {
+		log.WithField("quarantineMode", *quarantineModeFlag).
+			Error("Unknown quarantine mode!")
+		return CLIFlags{}, false
+	}
+
+	integrityLevel, ok := datastruct.IntegrityLevelFromString(*integrityLevelFlag)
+	if !ok {
+		log.WithField("integrityLevel", *integrityLevelFlag).
+			Error("Unknown integrity level!")
+		return CLIFlags{}, false
+	}
+
+	eventErrorsPolicy, err := ParseEventErrorsPolicy(*rejectEventErrorsFlag)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"rejectEventErrors": *rejectEventErrorsFlag,
+			"error":             err,
+		}).Error("Invalid event streams!")
+		return CLIFlags{}, false
+	}
+
+	validityRules := datastruct.DefaultValidityRules()
+	if *validityRulesFlag != "" {
+		validityRules, err = datastruct.ReadValidityRulesFile(*validityRulesFlag)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"validityRules": *validityRulesFlag,
+				"error":         err,
+			}).Error("Invalid validity rules file!")
+			return CLIFlags{}, false
+		}
+	}
+
+	var filterExpression *filter_expression.Expression
+	if *filterExpressionFlag != "" {
+		filterExpression, err = filter_expression.Parse(
+			*filterExpressionFlag,
+			settings.FilterAttributes,
+		)
+		if err != nil {
+			log.WithField("error", err.Error()).Error("Invalid filter expression!")
+			return CLIFlags{}, false
+		}
+	}
+
+	if *timeSeriesIntervalFlag <= 0 {
+		log.WithField("timeSeriesInterval", *timeSeriesIntervalFlag).
+			Error("Time series interval has to be a positive number of game loops!")
+		return CLIFlags{}, false
+	}
+
 	logFlags := LogFlags{
 		LogLevelValue: datastruct.LogLevelEnum(*logLevelFlag),
 		LogPath:       *logDirectoryFlag,
@@ -197,13 +615,47 @@ func ParseFlags() (CLIFlags, bool) {
 		SkipDependencyDownload:     *skipDependencyDownload,
 		DependencyDirectory:        absolutePathDependencyDirectory,
 		NumberOfPackages:           *numberOfPackagesFlag,
+		OutputLayout:               outputLayout,
+		OutputTemplate:             outputTemplate,
+		PartitionBy:                partitionBy,
+		MetadataOnly:               *metadataOnlyFlag,
+		Deterministic:              *deterministicFlag,
+		ExportTimeSeries:           *exportTimeSeriesFlag,
+		TimeSeriesIntervalLoops:    *timeSeriesIntervalFlag,
+		SummaryBins:                summaryBins,
 		PerformIntegrityCheck:      *performIntegrityCheckFlag,
+		IntegrityLevel:             integrityLevel,
+		EventErrorsPolicy:          eventErrorsPolicy,
 		PerformValidityCheck:       *performValidityCheckFlag,
+		ValidityRules:              validityRules,
 		PerformCleanup:             *performCleanupFlag,
 		PerformPlayerAnonymization: *performPlayerAnonymizationFlag,
 		PerformChatAnonymization:   *performChatAnonymizationFlag,
+		ChatAnonymizationMode:      chatAnonymizationMode,
+		AnonymizerMode:             anonymizerMode,
+		AnonymizerKeyFile:          *anonymizerKeyFileFlag,
+		AnonymizerDatabase:         *anonymizerDatabaseFlag,
+		AnonymizerCache:            *anonymizerCacheFlag,
+		AnonymizerAddress:          *anonymizerAddressFlag,
+		AnonymizerTLS:              anonymizerTLS,
+		AnonymizerTLSCA:            *anonymizerTLSCAFlag,
+		AnonymizerTLSCert:          *anonymizerTLSCertFlag,
+		AnonymizerTLSKey:           *anonymizerTLSKeyFlag,
+		TimePrecision:              timePrecision,
+		MMRBucketSize:              *mmrBucketFlag,
+		APMRoundTo:                 *apmRoundFlag,
+		RemoveRegion:               *removeRegionFlag,
+		KAnonymityAttributes:       kAnonymityAttributes,
+		KAnonymityK:                *kAnonymityKFlag,
 		PerformFiltering:           *performFilteringFlag,
 		FilterGameMode:             *gameModeFilterFlag,
+		FilterExpression:           filterExpression,
+		FilterHumansOnly:           *humansOnlyFlag,
+		FilterNoObservers:          *noObserversFlag,
+		FilterMinDuration:          *minDurationFlag,
+		FilterEarlyLeaveSeconds:    *earlyLeaveSecondsFlag,
+		QuarantineDirectory:        absolutePathQuarantineDirectory,
+		QuarantineMode:             quarantineMode,
 		NumberOfThreads:            *numberOfThreadsUsedFlag,
 		LogFlags:                   logFlags,
 		CPUProfilingPath:           *performCPUProfilingFlag,
@@ -211,3 +663,130 @@ func ParseFlags() (CLIFlags, bool) {
 
 	return flags, true
 }
+
+// registerSummaryBinFlags defines the flags of the histogram bin edges
+// of the summaries on a flag set. The returned function parses the values
+// into SummaryBins and returns false if any of them is invalid.
+func registerSummaryBinFlags(flagSet *flag.FlagSet) func() (persistent_data.SummaryBins, bool) {
+
+	gameLengthBinsFlag := flagSet.String(
+		"game_length_bins",
+		"60,180,300,480,600,900,1200,1500,1800,2700,3600",
+		`Comma separated, ascending bin edges (in seconds) of the game length
+		histogram in the summaries.`,
+	)
+	apmBinsFlag := flagSet.String(
+		"apm_bins",
+		"25,50,75,100,150,200,250,300,400",
+		"Comma separated, ascending bin edges of the APM histogram in the summaries.",
+	)
+	mmrBinsFlag := flagSet.String(
+		"mmr_bins",
+		"1000,2000,2500,3000,3500,4000,4500,5000,5500,6000,6500,7000",
+		"Comma separated, ascending bin edges of the MMR histogram in the summaries.",
+	)
+
+	return func() (persistent_data.SummaryBins, bool) {
+		summaryBins := persistent_data.SummaryBins{}
+		binFlags := []struct {
+			name     string
+			value    string
+			binEdges *[]float64
+		}{
+			{"game_length_bins", *gameLengthBinsFlag, &summaryBins.GameLengthSeconds},
+			{"apm_bins", *apmBinsFlag, &summaryBins.APM},
+			{"mmr_bins", *mmrBinsFlag, &summaryBins.MMR},
+		}
+		for _, binFlag := range binFlags {
+			binEdges, err := ParseBinEdges(binFlag.value)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"flag":  binFlag.name,
+					"value": binFlag.value,
+					"error": err,
+				}).Error("Invalid histogram bin edges!")
+				return persistent_data.SummaryBins{}, false
+			}
+			*binFlag.binEdges = binEdges
+		}
+		return summaryBins, true
+	}
+}
+
+// ParseEventErrorsPolicy parses the comma separated list of the event streams
+// whose decoding errors reject the replay.
+func ParseEventErrorsPolicy(rejectEventErrors string) (datastruct.EventErrorsPolicy, error) {
+
+	eventErrorsPolicy := datastruct.EventErrorsPolicy{}
+	if strings.TrimSpace(rejectEventErrors) == "" {
+		return eventErrorsPolicy, nil
+	}
+
+	for _, eventStream := range strings.Split(rejectEventErrors, ",") {
+		switch strings.TrimSpace(eventStream) {
+		case "game":
+			eventErrorsPolicy.RejectGameEvtsErr = true
+		case "message":
+			eventErrorsPolicy.RejectMessageEvtsErr = true
+		case "tracker":
+			eventErrorsPolicy.RejectTrackerEvtsErr = true
+		default:
+			return datastruct.EventErrorsPolicy{}, fmt.Errorf(
+				"unknown event stream %s, available event streams: %s",
+				eventStream,
+				strings.Join(settings.EventStreams, ", "),
+			)
+		}
+	}
+
+	return eventErrorsPolicy, nil
+}
+
+// ParseBinEdges parses a comma separated list of ascending histogram bin edges.
+func ParseBinEdges(binEdgesString string) ([]float64, error) {
+
+	binEdges := []float64{}
+	if strings.TrimSpace(binEdgesString) == "" {
+		return binEdges, nil
+	}
+
+	for _, binEdgeString := range strings.Split(binEdgesString, ",") {
+		binEdge, err := strconv.ParseFloat(strings.TrimSpace(binEdgeString), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bin edge %q: %v", binEdgeString, err)
+		}
+		if len(binEdges) > 0 && binEdge <= binEdges[len(binEdges)-1] {
+			return nil, fmt.Errorf("bin edges have to be ascending: %s", binEdgesString)
+		}
+		binEdges = append(binEdges, binEdge)
+	}
+
+	return binEdges, nil
+}
+
+// ParseKAnonymityAttributes parses the comma separated list of the attributes
+// of the k-anonymity report, every attribute has to be one of the
+// settings.QuasiIdentifierAttributes.
+func ParseKAnonymityAttributes(attributesString string) ([]string, error) {
+
+	attributes := []string{}
+	if strings.TrimSpace(attributesString) == "" {
+		return attributes, nil
+	}
+
+	for _, attribute := range strings.Split(attributesString, ",") {
+		attribute = strings.TrimSpace(attribute)
+		if !slices.Contains(settings.QuasiIdentifierAttributes, attribute) {
+			return nil, fmt.Errorf(
+				"unknown attribute %s, available attributes: %s",
+				attribute,
+				strings.Join(settings.QuasiIdentifierAttributes, ", "),
+			)
+		}
+		if !slices.Contains(attributes, attribute) {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	return attributes, nil
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/datastruct"
+)
+
+// TestParseEventErrorsPolicy verifies that only the known event streams are accepted.
+func TestParseEventErrorsPolicy(t *testing.T) {
+
+	eventErrorsPolicy, err := ParseEventErrorsPolicy("game, tracker")
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	expectedPolicy := datastruct.EventErrorsPolicy{
+		RejectGameEvtsErr:    true,
+		RejectTrackerEvtsErr: true,
+	}
+	if eventErrorsPolicy != expectedPolicy {
+		t.Errorf("Test Failed! Unexpected policy: %+v", eventErrorsPolicy)
+	}
+
+	_, err = ParseEventErrorsPolicy("game,attributes")
+	if err == nil {
+		t.Errorf("Test Failed! Expected an error for an unknown event stream.")
+	}
+}
+
+// TestParseKAnonymityAttributes verifies that only the quasi-identifiers
+// are accepted and that the repeated attributes are listed once.
+func TestParseKAnonymityAttributes(t *testing.T) {
+
+	attributes, err := ParseKAnonymityAttributes("map, mmr,map")
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	if len(attributes) != 2 || attributes[0] != "map" || attributes[1] != "mmr" {
+		t.Errorf("Test Failed! Unexpected attributes: %v", attributes)
+	}
+
+	_, err = ParseKAnonymityAttributes("map,nickname")
+	if err == nil {
+		t.Errorf("Test Failed! Expected an error for an unknown attribute.")
+	}
+}
@@ -0,0 +1,137 @@
+package npy_utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+)
+
+// npyMagic starts every file in the NumPy .npy format.
+const npyMagic = "\x93NUMPY"
+
+// npyHeaderAlignment is the alignment of the array data required by NumPy.
+const npyHeaderAlignment = 64
+
+// NpyArray is a single named array that is saved in a .npz archive.
+type NpyArray struct {
+	Name  string
+	Bytes []byte
+}
+
+// EncodeFloat32 returns the .npy file contents of a little endian
+// float32 array with the supplied shape stored in C order.
+func EncodeFloat32(data []float32, shape []int) ([]byte, error) {
+
+	buffer, err := npyHeader("<f4", shape, len(data))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]byte, 4*len(data))
+	for i, value := range data {
+		binary.LittleEndian.PutUint32(values[4*i:], math.Float32bits(value))
+	}
+	buffer.Write(values)
+
+	return buffer.Bytes(), nil
+}
+
+// EncodeInt64 returns the .npy file contents of a little endian
+// int64 array with the supplied shape stored in C order.
+func EncodeInt64(data []int64, shape []int) ([]byte, error) {
+
+	buffer, err := npyHeader("<i8", shape, len(data))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]byte, 8*len(data))
+	for i, value := range data {
+		binary.LittleEndian.PutUint64(values[8*i:], uint64(value))
+	}
+	buffer.Write(values)
+
+	return buffer.Bytes(), nil
+}
+
+// EncodeNpz returns the contents of a .npz archive holding the arrays
+// in the supplied order. The archive entries are stored without compression
+// and with a fixed modification time so that the output is reproducible.
+func EncodeNpz(arrays []NpyArray) ([]byte, error) {
+
+	buffer := new(bytes.Buffer)
+	writer := zip.NewWriter(buffer)
+
+	for _, array := range arrays {
+		fh := &zip.FileHeader{
+			Name:     array.Name + ".npy",
+			Method:   zip.Store,
+			Modified: settings.DeterministicArchiveModified,
+		}
+		fw, err := writer.CreateHeader(fh)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create npz entry %s: %v", array.Name, err)
+		}
+		_, err = fw.Write(array.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write npz entry %s: %v", array.Name, err)
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close npz archive: %v", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// npyHeader creates a buffer holding the version 1.0 .npy header
+// describing an array of the supplied type and shape.
+func npyHeader(descr string, shape []int, length int) (*bytes.Buffer, error) {
+
+	elements := 1
+	shapeElements := make([]string, len(shape))
+	for i, dimension := range shape {
+		elements *= dimension
+		shapeElements[i] = strconv.Itoa(dimension)
+	}
+	if elements != length {
+		return nil, fmt.Errorf("shape %v does not match %d elements", shape, length)
+	}
+
+	// Tuples with a single element require a trailing comma:
+	shapeString := strings.Join(shapeElements, ", ")
+	if len(shape) == 1 {
+		shapeString += ","
+	}
+
+	header := fmt.Sprintf(
+		"{'descr': '%s', 'fortran_order': False, 'shape': (%s), }",
+		descr,
+		shapeString,
+	)
+	// Magic string, version and header length take 10 bytes,
+	// the header is padded with spaces and terminated with a newline:
+	padding := npyHeaderAlignment - (10+len(header)+1)%npyHeaderAlignment
+	if padding == npyHeaderAlignment {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	buffer := new(bytes.Buffer)
+	buffer.WriteString(npyMagic)
+	buffer.Write([]byte{1, 0})
+	headerLength := make([]byte, 2)
+	binary.LittleEndian.PutUint16(headerLength, uint16(len(header)))
+	buffer.Write(headerLength)
+	buffer.WriteString(header)
+
+	return buffer, nil
+}
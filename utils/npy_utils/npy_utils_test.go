@@ -0,0 +1,80 @@
+package npy_utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestEncodeFloat32 verifies the header and the data of an encoded array.
+func TestEncodeFloat32(t *testing.T) {
+
+	data := []float32{1, 2, 3, 4, 5, 6}
+	encoded, err := EncodeFloat32(data, []int{2, 3})
+	if err != nil {
+		t.Fatalf("Test Failed! EncodeFloat32() returned an error: %v", err)
+	}
+
+	if string(encoded[:6]) != npyMagic {
+		t.Fatalf("Test Failed! Missing the magic string.")
+	}
+	headerLength := int(binary.LittleEndian.Uint16(encoded[8:10]))
+	if (10+headerLength)%npyHeaderAlignment != 0 {
+		t.Errorf("Test Failed! Header is not aligned, length %d.", headerLength)
+	}
+	header := string(encoded[10 : 10+headerLength])
+	if !strings.Contains(header, "'descr': '<f4'") || !strings.Contains(header, "'shape': (2, 3)") {
+		t.Errorf("Test Failed! Unexpected header: %s", header)
+	}
+
+	values := encoded[10+headerLength:]
+	if len(values) != 4*len(data) {
+		t.Fatalf("Test Failed! Expected %d bytes of data, got %d.", 4*len(data), len(values))
+	}
+	lastValue := math.Float32frombits(binary.LittleEndian.Uint32(values[20:]))
+	if lastValue != 6 {
+		t.Errorf("Test Failed! Expected the last value to be 6, got %f.", lastValue)
+	}
+}
+
+// TestEncodeInt64Shape verifies that mismatched shapes are rejected
+// and that single dimensional shapes are written as tuples.
+func TestEncodeInt64Shape(t *testing.T) {
+
+	_, err := EncodeInt64([]int64{1, 2, 3}, []int{2})
+	if err == nil {
+		t.Errorf("Test Failed! Expected an error for a mismatched shape.")
+	}
+
+	encoded, err := EncodeInt64([]int64{1, 2, 3}, []int{3})
+	if err != nil {
+		t.Fatalf("Test Failed! EncodeInt64() returned an error: %v", err)
+	}
+	if !bytes.Contains(encoded, []byte("'shape': (3,)")) {
+		t.Errorf("Test Failed! Single dimensional shape is not a tuple.")
+	}
+}
+
+// TestEncodeNpz verifies that the arrays are saved as .npy entries.
+func TestEncodeNpz(t *testing.T) {
+
+	array, err := EncodeInt64([]int64{1}, []int{1})
+	if err != nil {
+		t.Fatalf("Test Failed! EncodeInt64() returned an error: %v", err)
+	}
+	encoded, err := EncodeNpz([]NpyArray{{Name: "loops", Bytes: array}})
+	if err != nil {
+		t.Fatalf("Test Failed! EncodeNpz() returned an error: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(encoded), int64(len(encoded)))
+	if err != nil {
+		t.Fatalf("Test Failed! Could not read the npz archive: %v", err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Name != "loops.npy" {
+		t.Errorf("Test Failed! Unexpected npz entries.")
+	}
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Kaszanas/SC2InfoExtractorGo/settings"
+)
+
+// OutputTemplate is a parsed template of the output path of a replay,
+// such as "{year}/{month}/{matchup}/{map}_{fingerprint}".
+// An empty template keeps the names of the replay files.
+type OutputTemplate struct {
+	template string
+	segments []templateSegment
+}
+
+// templateSegment is either a literal part of the template or a field placeholder.
+type templateSegment struct {
+	literal string
+	field   string
+}
+
+// ParseOutputTemplate verifies the template and splits it into
+// literal parts and fields. Fields have to be one of settings.OutputNameFields.
+// The ".json" extension at the end of the template is optional.
+func ParseOutputTemplate(template string) (OutputTemplate, error) {
+
+	template = strings.TrimSuffix(template, ".json")
+	if template == "" {
+		return OutputTemplate{}, nil
+	}
+	if strings.Contains(template, "\\") {
+		return OutputTemplate{}, fmt.Errorf("template has to use forward slashes: %s", template)
+	}
+	if path.IsAbs(template) {
+		return OutputTemplate{}, fmt.Errorf("template has to be a relative path: %s", template)
+	}
+	for _, element := range strings.Split(template, "/") {
+		if element == "" || element == "." || element == ".." {
+			return OutputTemplate{}, fmt.Errorf("template contains an invalid path element: %s", template)
+		}
+	}
+
+	var segments []templateSegment
+	remaining := template
+	for remaining != "" {
+		openIndex := strings.Index(remaining, "{")
+		closeIndex := strings.Index(remaining, "}")
+		if openIndex == -1 {
+			if closeIndex != -1 {
+				return OutputTemplate{}, fmt.Errorf("unbalanced braces in template: %s", template)
+			}
+			segments = append(segments, templateSegment{literal: remaining})
+			break
+		}
+		if closeIndex < openIndex {
+			return OutputTemplate{}, fmt.Errorf("unbalanced braces in template: %s", template)
+		}
+
+		if openIndex > 0 {
+			segments = append(segments, templateSegment{literal: remaining[:openIndex]})
+		}
+		field := remaining[openIndex+1 : closeIndex]
+		if !isOutputNameField(field) {
+			return OutputTemplate{}, fmt.Errorf(
+				"unknown field {%s} in template, available fields: %s",
+				field,
+				strings.Join(settings.OutputNameFields, ", "),
+			)
+		}
+		segments = append(segments, templateSegment{field: field})
+		remaining = remaining[closeIndex+1:]
+	}
+
+	return OutputTemplate{template: template, segments: segments}, nil
+}
+
+// String returns the template as it was supplied by the user.
+func (template OutputTemplate) String() string {
+	return template.template
+}
+
+// IsEmpty returns true if the template keeps the names of the replay files.
+func (template OutputTemplate) IsEmpty() bool {
+	return len(template.segments) == 0
+}
+
+// Render substitutes the fields of the template with the supplied values.
+// Values are expected to be sanitized so that they do not create new path elements.
+func (template OutputTemplate) Render(fieldValues map[string]string) string {
+
+	var builder strings.Builder
+	for _, segment := range template.segments {
+		if segment.field == "" {
+			builder.WriteString(segment.literal)
+			continue
+		}
+		builder.WriteString(fieldValues[segment.field])
+	}
+
+	return builder.String()
+}
+
+// ParsePartitionFields splits the comma separated list of the fields
+// that the outputs are partitioned by and verifies them.
+func ParsePartitionFields(partitionBy string) ([]string, error) {
+
+	if partitionBy == "" {
+		return []string{}, nil
+	}
+
+	var partitionFields []string
+	for _, field := range strings.Split(partitionBy, ",") {
+		field = strings.TrimSpace(field)
+		if !isOutputNameField(field) {
+			return nil, fmt.Errorf(
+				"unknown partition field %s, available fields: %s",
+				field,
+				strings.Join(settings.OutputNameFields, ", "),
+			)
+		}
+		partitionFields = append(partitionFields, field)
+	}
+
+	return partitionFields, nil
+}
+
+// isOutputNameField checks if the field can be used in the output names.
+func isOutputNameField(field string) bool {
+	for _, outputNameField := range settings.OutputNameFields {
+		if field == outputNameField {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package utils
+
+import "testing"
+
+// TestParseOutputTemplate verifies that the templates are validated and rendered.
+func TestParseOutputTemplate(t *testing.T) {
+
+	fieldValues := map[string]string{
+		"year":        "2021",
+		"month":       "05",
+		"matchup":     "P-vs-T",
+		"map":         "Ever Dream LE",
+		"fingerprint": "abcd",
+	}
+
+	testCases := []struct {
+		template string
+		valid    bool
+		expected string
+	}{
+		{"", true, ""},
+		{"{year}/{month}/{matchup}/{map}_{fingerprint}.json", true, "2021/05/P-vs-T/Ever Dream LE_abcd"},
+		{"replays/{year}-{month}", true, "replays/2021-05"},
+		{"{unknown}", false, ""},
+		{"{year", false, ""},
+		{"year}", false, ""},
+		{"/{year}", false, ""},
+		{"../{year}", false, ""},
+		{"{year}//{month}", false, ""},
+		{"{year}\\{month}", false, ""},
+	}
+
+	for _, testCase := range testCases {
+		outputTemplate, err := ParseOutputTemplate(testCase.template)
+		if (err == nil) != testCase.valid {
+			t.Errorf("Template %q: expected valid = %v, got error %v",
+				testCase.template, testCase.valid, err)
+			continue
+		}
+		if !testCase.valid {
+			continue
+		}
+		rendered := outputTemplate.Render(fieldValues)
+		if rendered != testCase.expected {
+			t.Errorf("Template %q: expected %q, got %q",
+				testCase.template, testCase.expected, rendered)
+		}
+	}
+}
+
+// TestParsePartitionFields verifies that only the known fields are accepted.
+func TestParsePartitionFields(t *testing.T) {
+
+	partitionFields, err := ParsePartitionFields("year, matchup")
+	if err != nil {
+		t.Fatalf("Test Failed! Unexpected error: %v", err)
+	}
+	if len(partitionFields) != 2 || partitionFields[1] != "matchup" {
+		t.Errorf("Test Failed! Unexpected partition fields: %v", partitionFields)
+	}
+
+	_, err = ParsePartitionFields("year,league")
+	if err == nil {
+		t.Errorf("Test Failed! Expected an error for an unknown field.")
+	}
+}
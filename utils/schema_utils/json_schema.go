@@ -0,0 +1,124 @@
+package schema_utils
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDraft is the JSON Schema specification that the generated documents follow.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is a subset of the JSON Schema specification
+// that is sufficient to describe the outputs of the tool.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 any                    `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Const                any                    `json:"const,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// NewDocumentSchema returns a top level JSON Schema document describing the value.
+func NewDocumentSchema(value any, id string, title string) *JSONSchema {
+	schema := FromType(reflect.TypeOf(value))
+	schema.Schema = JSONSchemaDraft
+	schema.ID = id
+	schema.Title = title
+	return schema
+}
+
+// FromType creates a JSON Schema describing how encoding/json
+// marshals the values of the supplied type.
+func FromType(valueType reflect.Type) *JSONSchema {
+
+	for valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+
+	if valueType == timeType {
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch valueType.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// Nil slices are marshaled as null:
+		return &JSONSchema{
+			Type:  []string{"array", "null"},
+			Items: FromType(valueType.Elem()),
+		}
+	case reflect.Map:
+		// Maps with values of an arbitrary type allow for any properties:
+		if valueType.Elem().Kind() == reflect.Interface {
+			return &JSONSchema{Type: []string{"object", "null"}, AdditionalProperties: true}
+		}
+		return &JSONSchema{
+			Type:                 []string{"object", "null"},
+			AdditionalProperties: FromType(valueType.Elem()),
+		}
+	case reflect.Struct:
+		return structSchema(valueType)
+	}
+
+	// Interfaces can hold any value:
+	return &JSONSchema{}
+}
+
+// structSchema creates a JSON Schema of an object
+// with the properties named after the json struct tags.
+func structSchema(structType reflect.Type) *JSONSchema {
+
+	schema := &JSONSchema{
+		Type:       "object",
+		Properties: make(map[string]*JSONSchema),
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldName := field.Name
+		omitEmpty := false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagElements := strings.Split(tag, ",")
+			if tagElements[0] == "-" {
+				continue
+			}
+			if tagElements[0] != "" {
+				fieldName = tagElements[0]
+			}
+			for _, option := range tagElements[1:] {
+				if option == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+
+		schema.Properties[fieldName] = FromType(field.Type)
+		if !omitEmpty {
+			schema.Required = append(schema.Required, fieldName)
+		}
+	}
+
+	return schema
+}
@@ -0,0 +1,69 @@
+package schema_utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testStruct struct {
+	Name     string         `json:"name"`
+	Count    int            `json:"count,omitempty"`
+	Ratio    float64        `json:"ratio"`
+	Tags     []string       `json:"tags"`
+	Counts   map[string]int `json:"counts"`
+	Extra    map[string]any `json:"extra"`
+	Created  time.Time      `json:"created"`
+	Skipped  string         `json:"-"`
+	NoTag    bool
+	internal string
+}
+
+func TestFromType(t *testing.T) {
+
+	schema := FromType(reflect.TypeOf(testStruct{}))
+
+	if schema.Type != "object" {
+		t.Fatalf("Expected object type, got %v", schema.Type)
+	}
+
+	expectedTypes := map[string]any{
+		"name":    "string",
+		"count":   "integer",
+		"ratio":   "number",
+		"tags":    []string{"array", "null"},
+		"counts":  []string{"object", "null"},
+		"extra":   []string{"object", "null"},
+		"created": "string",
+		"NoTag":   "boolean",
+	}
+	if len(schema.Properties) != len(expectedTypes) {
+		t.Errorf("Expected %d properties, got %d", len(expectedTypes), len(schema.Properties))
+	}
+	for property, expectedType := range expectedTypes {
+		propertySchema, ok := schema.Properties[property]
+		if !ok {
+			t.Errorf("Missing property %s", property)
+			continue
+		}
+		if !reflect.DeepEqual(propertySchema.Type, expectedType) {
+			t.Errorf("Property %s has type %v, expected %v", property, propertySchema.Type, expectedType)
+		}
+	}
+
+	for _, required := range schema.Required {
+		if required == "count" {
+			t.Errorf("Property with omitempty should not be required")
+		}
+	}
+	if len(schema.Required) != len(expectedTypes)-1 {
+		t.Errorf("Expected %d required properties, got %d", len(expectedTypes)-1, len(schema.Required))
+	}
+
+	if schema.Properties["tags"].Items.Type != "string" {
+		t.Errorf("Expected string items, got %v", schema.Properties["tags"].Items.Type)
+	}
+	if schema.Properties["created"].Format != "date-time" {
+		t.Errorf("Expected date-time format, got %s", schema.Properties["created"].Format)
+	}
+}
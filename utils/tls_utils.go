@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// ClientTLSConfig creates the TLS configuration of a client verifying the server
+// against the certificate authority in caFile, or the system roots if caFile is empty.
+// Setting certFile and keyFile presents the client certificate to the server (mTLS).
+func ClientTLSConfig(caFile string, certFile string, keyFile string) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		certPool, err := readCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if certFile != "" || keyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// ServerTLSConfig creates the TLS configuration of a server presenting the certificate
+// in certFile. Setting clientCAFile requires the clients to present certificates
+// signed by the certificate authority in clientCAFile (mTLS).
+func ServerTLSConfig(certFile string, keyFile string, clientCAFile string) (*tls.Config, error) {
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the server certificate: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+	}
+
+	if clientCAFile != "" {
+		certPool, err := readCertPool(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = certPool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// readCertPool reads the PEM encoded certificates of the certificate authority.
+func readCertPool(caFile string) (*x509.CertPool, error) {
+
+	caBytes, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the certificate authority: %v", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+
+	return certPool, nil
+}
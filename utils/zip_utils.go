@@ -3,7 +3,6 @@ package utils
 import (
 	"archive/zip"
 	"bytes"
-	"path/filepath"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -24,24 +23,23 @@ func InitBufferWriter() (*bytes.Buffer, *zip.Writer) {
 	return buf, w
 }
 
-// SaveFileToArchive creates a file header and saves replayString (JSON) bytes into the zip writer
-func SaveFileToArchive(
-	replayString string,
-	replayFile string,
+// SaveBytesToArchive creates a file header with the supplied entry name
+// and modification time and saves the bytes into the zip writer.
+func SaveBytesToArchive(
+	contents []byte,
+	entryName string,
 	compressionMethod uint16,
+	modified time.Time,
 	writer *zip.Writer,
 ) bool {
 
-	log.Debug("Entered saveFileToArchive()")
-
-	jsonBytes := []byte(replayString)
-	_, fileHeaderFilename := filepath.Split(replayFile)
+	log.Debug("Entered SaveBytesToArchive()")
 
 	fh := &zip.FileHeader{
-		Name:               filepath.Base(fileHeaderFilename) + ".json",
-		UncompressedSize64: uint64(len(jsonBytes)),
+		Name:               entryName,
+		UncompressedSize64: uint64(len(contents)),
 		Method:             compressionMethod,
-		Modified:           time.Now(),
+		Modified:           modified,
 	}
 	fh.SetMode(0777)
 	log.WithFields(log.Fields{
@@ -53,23 +51,23 @@ func SaveFileToArchive(
 	fw, err := writer.CreateHeader(fh)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"file":  replayFile,
-			"error": err}).
+			"entryName": entryName,
+			"error":     err}).
 			Error("Got error when adding a file header to the archive.")
 		return false
 	}
 
-	_, err = fw.Write(jsonBytes)
+	_, err = fw.Write(contents)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"file":             replayFile,
+			"entryName":        entryName,
 			"error":            err,
 			"compressionError": true}).
 			Error("Got error when adding a file header to the archive.")
 		return false
 	}
 
-	log.Debug("Finished SaveFileToArchive()")
+	log.Debug("Finished SaveBytesToArchive()")
 
 	return true
 }